@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/alecthomas/kong"
 
@@ -16,42 +18,152 @@ var (
 		Debug                  bool     `help:"Display debugging information" short:"d"`
 		Trace                  bool     `help:"Trace solver execution" short:"t"`
 		PrintFoundAssignment   bool     `help:"Print variables assignment on SAT result" short:"a"`
-		SolverName             string   `help:"Specify solver to use" short:"s" default:"cdcl"`
+		SolverName             string   `help:"Specify solver to use. By default the 2sat or horn solver is used if the formula allows it and cdcl otherwise." short:"s" default:""`
 		LoaderName             string   `help:"Specify format of the loaded input" short:"f" default:"haskell"`
+		StrictHeader           bool     `help:"Reject the DIMACS CNF files with a different number of clauses than declared in the header (by default it's only a warning)." default:"false"`
 		ExpectedResult         int      `help:"Specify expected result. This is useful when debugging the solver. Terribly slows down computation." enum:"-1,0,1" default:"-1"`
 		DisableCNFConversion   bool     `help:"Disable conversion to CNF." default:"false"`
 		EnableASTOptimization  bool     `help:"Enable input AST mangling." default:"false"`
 		EnableCNFOptimizations bool     `help:"Enable CNF preprocessing" default:"false"`
+		Preprocess             string   `help:"Comma-separated list of preprocessing passes (up, taut, subsume, bve, bce, pure, probe). Implies CNF preprocessing." default:""`
+		DisableInprocessing    bool     `help:"Disable simplifications of the clause database during the search." default:"false"`
+		PBEncoding             string   `help:"Encoding of the pseudo-Boolean constraints into CNF (bdd, adder, sorter)." enum:"bdd,adder,sorter" default:"bdd"`
+		DisableModelChecking   bool     `help:"Do not check the found assignment against the loaded formula." default:"false"`
+		PartialModel           bool     `help:"Reduce the printed assignment to the variables that are needed to satisfy the formula." default:"false"`
+		Backbone               bool     `help:"Print variables that have the same value in all the satisfying assignments." default:"false"`
+		MaxSATAlgorithm        string   `name:"maxsat-algorithm" help:"Algorithm used by the maxsat solver (oll, linear)." enum:"oll,linear" default:"oll"`
+		SLSAlgorithm           string   `name:"sls-algorithm" help:"Algorithm used by the sls solver (probsat, walksat)." enum:"probsat,walksat" default:"probsat"`
+		SLSNoise               float64  `name:"sls-noise" help:"Noise of the sls solver: cb parameter for probsat or random walk probability for walksat. Use 0 for the default value." default:"0"`
+		SLSMaxFlips            int64    `name:"sls-max-flips" help:"Number of flips done by the sls solver before each restart. Use 0 for the default value." default:"0"`
+		SLSRestarts            int      `name:"sls-restarts" help:"Number of restarts of the sls solver before it gives up." default:"10"`
+		SLSSeed                int64    `name:"sls-seed" help:"Random seed used by the sls solver." default:"0"`
+		SLSRephase             bool     `name:"sls-rephase" help:"Use short local search runs to set the decision phases of the cdcl solver." default:"false"`
+		DecisionHeuristic      string   `help:"Decision heuristic of the stable mode of the cdcl solver (avsids, vmtf, chb, lrb). The focused mode always uses vmtf." enum:"avsids,vmtf,chb,lrb" default:"avsids"`
+		DisableChronoBacktrack bool     `help:"Always jump back to the assertion level after a conflict in the cdcl solver." default:"false"`
+		ChronoJumpLimit        int      `help:"Backtrack chronologically when the jump after a conflict would skip more decision levels than this. Use 0 for the default value." default:"0"`
+		ChronoMinConflicts     int      `help:"Number of conflicts before the chronological backtracking is used for the first time. Use 0 for the default value." default:"0"`
+		SearchMode             string   `help:"Search mode of the cdcl solver: alternate between the focused and stable modes or use only one of them." enum:"alternate,focused,stable" default:"alternate"`
+		Equiv                  bool     `help:"Check the equivalence of two BENCH netlists given as the input files." default:"false"`
+		BMC                    int      `name:"bmc" help:"Check the sequential AIGER circuit with the bounded model checking up to the given number of steps and print the AIGER witness." default:"-1"`
+		Output                 string   `help:"Format of the printed results (text, json). The json format prints one object per input file." enum:"text,json" default:"text"`
 	}
 )
 
+/*
+ * Create the context of the solver configured by the command line flags.
+ */
+func createContext(file string) *sat_solver.SATContext {
+	var expectedResult *bool = nil
+	if cli.ExpectedResult == 0 || cli.ExpectedResult == 1 {
+		expectedResultVal := cli.ExpectedResult == 1
+		expectedResult = &expectedResultVal
+	}
+	return sat_solver.NewSATContext(sat_solver.SATConfiguration{
+		InputFile:              file,
+		ExpectedResult:         expectedResult,
+		EnableSelfVerification: expectedResult != nil,
+		EnableEventCollector:   cli.Trace || cli.Debug,
+		EnableSolverTracing:    cli.Trace,
+		EnableCNFConversion:    !cli.DisableCNFConversion,
+		EnableASTOptimization:  cli.EnableASTOptimization,
+		EnableCNFOptimizations: cli.EnableCNFOptimizations || len(cli.Preprocess) > 0,
+		EnableInprocessing:     !cli.DisableInprocessing,
+		PreprocessingPipeline:  cli.Preprocess,
+		SolverName:             cli.SolverName,
+		LoaderName:             cli.LoaderName,
+		EnableStrictHeaders:    cli.StrictHeader,
+		PBEncoding:             cli.PBEncoding,
+		MaxSATAlgorithm:        cli.MaxSATAlgorithm,
+		EnablePartialModels:    cli.PartialModel,
+		EnableModelChecking:    !cli.DisableModelChecking,
+		SLSAlgorithm:           cli.SLSAlgorithm,
+		SLSNoise:               cli.SLSNoise,
+		SLSMaxFlips:            cli.SLSMaxFlips,
+		SLSRestarts:            cli.SLSRestarts,
+		SLSSeed:                cli.SLSSeed,
+		EnableSLSRephasing:     cli.SLSRephase,
+		DecisionHeuristic:      cli.DecisionHeuristic,
+		EnableChronoBacktrack:  !cli.DisableChronoBacktrack,
+		ChronoJumpLimit:        cli.ChronoJumpLimit,
+		ChronoMinConflicts:     cli.ChronoMinConflicts,
+		SearchMode:             cli.SearchMode,
+	})
+}
+
+/*
+ * Print the result as JSON with the time spent on loading and solving the formula.
+ */
+func printJSONResult(result solver.SolverResult, startTime time.Time) {
+	jsonResult := solver.NewJSONResult(result)
+	jsonResult.Stats["time_ms"] = time.Since(startTime).Milliseconds()
+	fmt.Printf("%s\n", jsonResult.String())
+}
+
 func main() {
 	ctx := kong.Parse(&cli)
 	if len(cli.Files) == 0 {
 		cli.Files = []string{ "-" }
 	}
+	if cli.Equiv {
+		if len(cli.Files) != 2 {
+			ctx.Fatalf("--equiv expects exactly two input files")
+		}
+		err, result := core.CheckEquivalenceOfFilePaths(cli.Files[0], cli.Files[1], createContext(cli.Files[0]))
+		ctx.FatalIfErrorf(err)
+		if cli.Output == "json" {
+			fmt.Printf("%s\n", result.JSON())
+		} else {
+			fmt.Printf("%s\n", result.String())
+		}
+		return
+	}
+	if cli.Output == "json" && (cli.BMC >= 0 || cli.LoaderName == "smt2") {
+		ctx.Fatalf("--output json cannot be used with the bounded model checking or the SMT-LIB scripts, they have their own output formats")
+	}
 	for _, file := range cli.Files {
-		var expectedResult *bool = nil
-		if cli.ExpectedResult == 0 || cli.ExpectedResult == 1 {
-			expectedResultVal := cli.ExpectedResult == 1
-			expectedResult = &expectedResultVal
-		}
-		err, result := core.RunSATSolverOnFilePath(file, sat_solver.NewSATContext(sat_solver.SATConfiguration{
-			InputFile:              file,
-			ExpectedResult:         expectedResult,
-			EnableSelfVerification: expectedResult != nil,
-			EnableEventCollector:   cli.Trace || cli.Debug,
-			EnableSolverTracing:    cli.Trace,
-			EnableCNFConversion:    !cli.DisableCNFConversion,
-			EnableASTOptimization:  cli.EnableASTOptimization,
-			EnableCNFOptimizations: cli.EnableCNFOptimizations,
-			SolverName:             cli.SolverName,
-			LoaderName:             cli.LoaderName,
-		}))
+		context := createContext(file)
+		if cli.BMC >= 0 {
+			ctx.FatalIfErrorf(core.RunBMCOnFilePath(file, cli.BMC, os.Stdout, context))
+			continue
+		}
+		if cli.LoaderName == "smt2" {
+			// SMT-LIB scripts print the responses of their commands
+			ctx.FatalIfErrorf(core.RunSMTLibSessionOnFilePath(file, os.Stdout, context))
+			continue
+		}
+		startTime := time.Now()
+		if cli.Backbone {
+			err, result := core.RunBackboneOnFilePath(file, context)
+			ctx.FatalIfErrorf(err)
+			if cli.Output == "json" {
+				printJSONResult(result, startTime)
+				continue
+			}
+			if cli.PrintFoundAssignment {
+				fmt.Printf("%s\n", solver.GetSolverResultSatisfyingAssignmentString(result))
+			}
+			fmt.Printf("%s\n", solver.GetBackboneString(result))
+			if assumptionsResult, ok := result.SolverResult.(solver.AssumptionsSolverResult); ok && result.IsUNSAT() {
+				fmt.Printf("%s\n", solver.GetCoreString(assumptionsResult))
+			}
+			fmt.Printf("%d\n", solver.ResultToInt(result))
+			continue
+		}
+		err, result := core.RunSATSolverOnFilePath(file, context)
 		ctx.FatalIfErrorf(err)
+		if cli.Output == "json" {
+			printJSONResult(result, startTime)
+			continue
+		}
 		if cli.PrintFoundAssignment {
 			fmt.Printf("%s\n", solver.GetSolverResultSatisfyingAssignmentString(result))
 		}
-		fmt.Printf("%d\n", result.ToInt())
+		if optimum, ok := result.(solver.OptimumSolverResult); ok {
+			fmt.Printf("Optimum: %d\n", optimum.Cost)
+		}
+		if assumptionsResult, ok := result.(solver.AssumptionsSolverResult); ok && result.IsUNSAT() {
+			fmt.Printf("%s\n", solver.GetCoreString(assumptionsResult))
+		}
+		fmt.Printf("%d\n", solver.ResultToInt(result))
 	}
 }
\ No newline at end of file
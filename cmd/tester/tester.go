@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -13,6 +15,10 @@ import (
 
 	"github.com/styczynski/go-sat-solver/sat_solver"
 	"github.com/styczynski/go-sat-solver/sat_solver/core"
+	solver2 "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+	"github.com/styczynski/go-sat-solver/sat_solver/preprocessor"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver/cdcl_solver"
 )
 
 var (
@@ -23,6 +29,416 @@ var (
 
 var TESTS_REGEX = `.*test([0-9]+)\.txt`
 
+/**
+ * Configuration of the test
+ */
+type TestOptions struct {
+	Configuration sat_solver.SATConfiguration
+	// Compute the backbone instead of solving the formula
+	Backbone      bool
+	// Solve the formula with the cdcl solver and check the events of the search
+	ObserveSearch bool
+	// Fail if the cdcl solver did not backtrack chronologically
+	ExpectChronoBacktracks bool
+	// Print the found assignment before the result, like the -a flag of go-sat-solver
+	PrintFoundAssignment bool
+	// Bound of the bounded model checking of the AIGER circuit (-1 if the formula is solved instead)
+	BMC           int
+	// Second netlist (relative to the tests directory) that is checked for the equivalence with the test
+	Equiv         string
+	// Format of the printed results (text or json)
+	Output        string
+}
+
+/**
+ * Options of the test (optionsNN.txt next to the testNN.txt). Each line is "key=value", empty lines and lines
+ * starting with # are skipped. The keys are the same as the command line flags of go-sat-solver.
+ */
+var TEST_OPTIONS = map[string]func(options *TestOptions, value string) error{
+	"loader": func(options *TestOptions, value string) error {
+		options.Configuration.LoaderName = value
+		return nil
+	},
+	"strict-header": func(options *TestOptions, value string) (err error) {
+		options.Configuration.EnableStrictHeaders, err = strconv.ParseBool(value)
+		return
+	},
+	"solver": func(options *TestOptions, value string) error {
+		options.Configuration.SolverName = value
+		return nil
+	},
+	"preprocess": func(options *TestOptions, value string) error {
+		options.Configuration.PreprocessingPipeline = value
+		options.Configuration.EnableCNFOptimizations = len(value) > 0
+		return nil
+	},
+	"enable-cnf-optimizations": func(options *TestOptions, value string) (err error) {
+		options.Configuration.EnableCNFOptimizations, err = strconv.ParseBool(value)
+		return
+	},
+	"enable-ast-optimization": func(options *TestOptions, value string) (err error) {
+		options.Configuration.EnableASTOptimization, err = strconv.ParseBool(value)
+		return
+	},
+	"disable-inprocessing": func(options *TestOptions, value string) error {
+		disable, err := strconv.ParseBool(value)
+		options.Configuration.EnableInprocessing = !disable
+		return err
+	},
+	"pb-encoding": func(options *TestOptions, value string) error {
+		options.Configuration.PBEncoding = value
+		return nil
+	},
+	"maxsat-algorithm": func(options *TestOptions, value string) error {
+		options.Configuration.MaxSATAlgorithm = value
+		return nil
+	},
+	"sls-algorithm": func(options *TestOptions, value string) error {
+		options.Configuration.SLSAlgorithm = value
+		return nil
+	},
+	"sls-seed": func(options *TestOptions, value string) (err error) {
+		options.Configuration.SLSSeed, err = strconv.ParseInt(value, 10, 64)
+		return
+	},
+	"sls-rephase": func(options *TestOptions, value string) (err error) {
+		options.Configuration.EnableSLSRephasing, err = strconv.ParseBool(value)
+		return
+	},
+	"decision-heuristic": func(options *TestOptions, value string) error {
+		options.Configuration.DecisionHeuristic = value
+		return nil
+	},
+	"disable-chrono-backtrack": func(options *TestOptions, value string) error {
+		disable, err := strconv.ParseBool(value)
+		options.Configuration.EnableChronoBacktrack = !disable
+		return err
+	},
+	"chrono-jump-limit": func(options *TestOptions, value string) (err error) {
+		options.Configuration.ChronoJumpLimit, err = strconv.Atoi(value)
+		return
+	},
+	"chrono-min-conflicts": func(options *TestOptions, value string) (err error) {
+		options.Configuration.ChronoMinConflicts, err = strconv.Atoi(value)
+		return
+	},
+	"self-verification": func(options *TestOptions, value string) (err error) {
+		options.Configuration.EnableSelfVerification, err = strconv.ParseBool(value)
+		return
+	},
+	"expect-chrono-backtracks": func(options *TestOptions, value string) (err error) {
+		options.ExpectChronoBacktracks, err = strconv.ParseBool(value)
+		return
+	},
+	"search-mode": func(options *TestOptions, value string) error {
+		options.Configuration.SearchMode = value
+		return nil
+	},
+	"partial-model": func(options *TestOptions, value string) (err error) {
+		options.Configuration.EnablePartialModels, err = strconv.ParseBool(value)
+		return
+	},
+	"backbone": func(options *TestOptions, value string) (err error) {
+		options.Backbone, err = strconv.ParseBool(value)
+		return
+	},
+	"print-found-assignment": func(options *TestOptions, value string) (err error) {
+		options.PrintFoundAssignment, err = strconv.ParseBool(value)
+		return
+	},
+	"bmc": func(options *TestOptions, value string) (err error) {
+		options.BMC, err = strconv.Atoi(value)
+		return
+	},
+	"equiv": func(options *TestOptions, value string) error {
+		options.Equiv = value
+		return nil
+	},
+	"output": func(options *TestOptions, value string) error {
+		if value != "text" && value != "json" {
+			return fmt.Errorf("expected text or json")
+		}
+		options.Output = value
+		return nil
+	},
+	"observe-search": func(options *TestOptions, value string) (err error) {
+		options.ObserveSearch, err = strconv.ParseBool(value)
+		return
+	},
+}
+
+/*
+ * Read the options of the test. If there's no options file, then the default configuration is used.
+ */
+func readTestOptions(dir string, testNo string) (error, TestOptions) {
+	options := TestOptions{
+		Configuration: sat_solver.DefaultSATConfiguration(),
+		BMC:           -1,
+		Output:        "text",
+	}
+	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("options%s.txt", testNo)))
+	if os.IsNotExist(err) {
+		return nil, options
+	} else if err != nil {
+		return err, options
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keyValue := strings.SplitN(line, "=", 2)
+		if len(keyValue) != 2 {
+			return fmt.Errorf("options%s.txt: expected key=value, got '%s'", testNo, line), options
+		}
+		key, value := strings.TrimSpace(keyValue[0]), strings.TrimSpace(keyValue[1])
+		setOption, ok := TEST_OPTIONS[key]
+		if !ok {
+			return fmt.Errorf("options%s.txt: unknown option '%s'", testNo, key), options
+		}
+		if err := setOption(&options, value); err != nil {
+			return fmt.Errorf("options%s.txt: invalid value of '%s': %v", testNo, key, err), options
+		}
+	}
+	return scanner.Err(), options
+}
+
+/*
+ * Observer that checks if the events of the search are consistent with each other and with the result.
+ */
+type searchEventsChecker struct {
+	cdcl_solver.NoopSolverObserver
+	conflicts int
+	learned   int
+	models    int
+	err       error
+}
+
+func (checker *searchEventsChecker) OnDecision(literal sat_solver.CNFLiteral, level int) {
+	if level < 1 && checker.err == nil {
+		checker.err = fmt.Errorf("decision on the level %d", level)
+	}
+}
+
+func (checker *searchEventsChecker) OnConflict(clause sat_solver.CNFClause) {
+	checker.conflicts++
+}
+
+func (checker *searchEventsChecker) OnLearn(clause sat_solver.CNFClause, lbd int) {
+	checker.learned++
+	if checker.learned > checker.conflicts && checker.err == nil {
+		checker.err = fmt.Errorf("clause learned without the conflict")
+	}
+}
+
+func (checker *searchEventsChecker) OnModel(model map[string]bool) {
+	checker.models++
+}
+
+/*
+ * Solve the formula with the cdcl solver observed by the searchEventsChecker.
+ */
+func solveObserved(path string, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	f, err := os.Open(path)
+	if err != nil {
+		return err, solver.EmptySolverResult{}
+	}
+	defer f.Close()
+	err, loadedFormula := solver2.LoadFormula(context.GetConfiguration().LoaderName, f, context)
+	if err != nil {
+		return err, solver.EmptySolverResult{}
+	}
+	err, formula := preprocessor.PreprocessAST(loadedFormula, context)
+	if err != nil {
+		return err, solver.EmptySolverResult{}
+	}
+	checker := &searchEventsChecker{}
+	s := cdcl_solver.NewCDCLSolver()
+	s.AddObserver(checker)
+	err, result := s.Solve(formula, context)
+	if err != nil {
+		return err, result
+	}
+	if checker.err != nil {
+		return fmt.Errorf("Inconsistent search events: %v", checker.err), result
+	}
+	if statistics, ok := result.(solver.StatisticsSolverResult); ok && int64(checker.learned) != statistics.GetStatistics()["conflicts"] {
+		return fmt.Errorf("Inconsistent search events: %d clauses learned after %d conflicts", checker.learned, statistics.GetStatistics()["conflicts"]), result
+	}
+	if (result.IsSAT() && checker.models != 1) || (!result.IsSAT() && checker.models != 0) {
+		return fmt.Errorf("Inconsistent search events: %d models reported for the %s result", checker.models, result.Brief()), result
+	}
+	return nil, result
+}
+
+/*
+ * Read the expected output of the test (outputNN.txt next to the testNN.txt). If there's no such file,
+ * then only the result is checked and nil is returned.
+ */
+func readExpectedOutput(dir string, testNo string) (error, *string) {
+	outputBytes, err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("output%s.txt", testNo)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return err, nil
+	}
+	output := normalizeOutput(string(outputBytes))
+	return nil, &output
+}
+
+/*
+ * Remove the trailing whitespace of the lines and the empty lines at the end, so the expected outputs
+ * can be edited without caring about them.
+ */
+func normalizeOutput(output string) string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+/*
+ * Result read from the output of the commands that do not return the solver results, for example the first line
+ * of the AIGER witness: SAT if the property is violated, UNSAT if it holds and undefined if it's not known.
+ * The equivalence checking is SAT if the netlists are not equivalent.
+ */
+type outputResult int
+
+func (result outputResult) ToBool() bool {
+	return result.IsSAT()
+}
+
+func (result outputResult) ToInt() int {
+	return solver.ResultToInt(result)
+}
+
+func (result outputResult) String() string {
+	if result.IsSAT() {
+		return "SAT"
+	} else if result.IsUNSAT() {
+		return "UNSAT"
+	}
+	return "Undefined"
+}
+
+func (result outputResult) Brief() string {
+	return result.String()
+}
+
+func (result outputResult) GetSatisfyingAssignment() map[string]bool {
+	return map[string]bool{}
+}
+
+func (result outputResult) IsSAT() bool {
+	return result == 1
+}
+
+func (result outputResult) IsUNSAT() bool {
+	return result == 0
+}
+
+func (result outputResult) IsUndefined() bool {
+	return result != 0 && result != 1
+}
+
+/*
+ * Print the result as JSON like go-sat-solver --output json. The stats are left out, because the counters of
+ * the search and the time differ between the runs.
+ */
+func printJSONResult(output io.Writer, result solver.SolverResult) {
+	jsonResult := solver.NewJSONResult(result)
+	jsonResult.Stats = map[string]int64{}
+	fmt.Fprintf(output, "%s\n", jsonResult.String())
+}
+
+/*
+ * Run the test and return its result together with the output that go-sat-solver prints for the same options.
+ */
+func runTest(path string, options TestOptions) (error, solver.SolverResult, string) {
+	var output strings.Builder
+	if options.Output == "json" && (options.BMC >= 0 || options.Configuration.LoaderName == "smt2") {
+		return fmt.Errorf("output=json cannot be used with the bounded model checking or the SMT-LIB scripts"), solver.EmptySolverResult{}, ""
+	}
+	if options.BMC >= 0 {
+		err := core.RunBMCOnFilePath(path, options.BMC, &output, sat_solver.NewSATContext(options.Configuration))
+		if err != nil {
+			return err, solver.EmptySolverResult{}, ""
+		}
+		// The witness starts with 1 if a property is violated, 0 if the properties hold and 2 if it's not known
+		status, _ := strconv.Atoi(strings.SplitN(output.String(), "\n", 2)[0])
+		return nil, outputResult(status), output.String()
+	}
+	if len(options.Equiv) > 0 {
+		err, result := core.CheckEquivalenceOfFilePaths(path, filepath.Join(filepath.Dir(path), options.Equiv), sat_solver.NewSATContext(options.Configuration))
+		if err != nil {
+			return err, solver.EmptySolverResult{}, ""
+		}
+		if options.Output == "json" {
+			fmt.Fprintf(&output, "%s\n", result.JSON())
+		} else {
+			fmt.Fprintf(&output, "%s\n", result.String())
+		}
+		// The miter is SAT if the netlists are not equivalent
+		if result.IsEquivalent {
+			return nil, outputResult(0), output.String()
+		}
+		return nil, outputResult(1), output.String()
+	}
+	if options.Backbone {
+		err, result := core.RunBackboneOnFilePath(path, sat_solver.NewSATContext(options.Configuration))
+		if err != nil {
+			return err, result, ""
+		}
+		if options.Output == "json" {
+			printJSONResult(&output, result)
+			return nil, result, output.String()
+		}
+		if options.PrintFoundAssignment {
+			fmt.Fprintf(&output, "%s\n", solver.GetSolverResultSatisfyingAssignmentString(result))
+		}
+		fmt.Fprintf(&output, "%s\n", solver.GetBackboneString(result))
+		if assumptionsResult, ok := result.SolverResult.(solver.AssumptionsSolverResult); ok && result.IsUNSAT() {
+			fmt.Fprintf(&output, "%s\n", solver.GetCoreString(assumptionsResult))
+		}
+		fmt.Fprintf(&output, "%d\n", solver.ResultToInt(result))
+		return nil, result, output.String()
+	}
+
+	var err error
+	var result solver.SolverResult
+	if options.ObserveSearch {
+		err, result = solveObserved(path, sat_solver.NewSATContext(options.Configuration))
+	} else {
+		err, result = core.RunSATSolverOnFilePath(path, sat_solver.NewSATContext(options.Configuration))
+	}
+	if err != nil {
+		return err, result, ""
+	}
+	if options.Configuration.LoaderName == "smt2" {
+		// SMT-LIB scripts print the responses of their commands, the result is the one of the first check-sat
+		err = core.RunSMTLibSessionOnFilePath(path, &output, sat_solver.NewSATContext(options.Configuration))
+		return err, result, output.String()
+	}
+	if options.Output == "json" {
+		printJSONResult(&output, result)
+		return nil, result, output.String()
+	}
+	if options.PrintFoundAssignment {
+		fmt.Fprintf(&output, "%s\n", solver.GetSolverResultSatisfyingAssignmentString(result))
+	}
+	if optimum, ok := result.(solver.OptimumSolverResult); ok {
+		fmt.Fprintf(&output, "Optimum: %d\n", optimum.Cost)
+	}
+	if assumptionsResult, ok := result.(solver.AssumptionsSolverResult); ok && result.IsUNSAT() {
+		fmt.Fprintf(&output, "%s\n", solver.GetCoreString(assumptionsResult))
+	}
+	fmt.Fprintf(&output, "%d\n", solver.ResultToInt(result))
+	return nil, result, output.String()
+}
+
 func main() {
 	ctx := kong.Parse(&cli)
 	r, err := regexp.Compile(TESTS_REGEX)
@@ -41,18 +457,33 @@ func main() {
 			if err != nil {
 				return err
 			}
+			err, options := readTestOptions(dir, testNoPostfix)
+			if err != nil {
+				return err
+			}
+
+			err, expectedOutput := readExpectedOutput(dir, testNoPostfix)
+			if err != nil {
+				return err
+			}
 
 			fmt.Printf("Execute test %s: ", testNoPostfix)
 
-			err, result := core.RunSATSolverOnFilePath(path, sat_solver.DefaultSATContext())
+			err, result, output := runTest(path, options)
 			if err != nil {
 				fmt.Printf("______________RESULT____________:\n  Test: %s, Err: %s\n___________________", testNoPostfix, err.Error())
 				return nil
 			}
 
 			if result.IsUndefined() || result.ToInt() != expectedTestResult {
-				fmt.Printf(" ERR\n______________RESULT____________:\n  Test: %s, Got: %d, Expected: %d\n___________________", testNoPostfix, result.ToInt(), expectedTestResult)
+				fmt.Printf(" ERR\n______________RESULT____________:\n  Test: %s, Got: %d, Expected: %d\n___________________", testNoPostfix, solver.ResultToInt(result), expectedTestResult)
 				panic(fmt.Sprintf("WRONG ANSWER ON TEST %s", testNoPostfix))
+			} else if expectedOutput != nil && normalizeOutput(output) != *expectedOutput {
+				fmt.Printf(" ERR\n______________RESULT____________:\n  Test: %s, Got output:\n%s\nExpected output:\n%s\n___________________", testNoPostfix, normalizeOutput(output), *expectedOutput)
+				panic(fmt.Sprintf("WRONG OUTPUT ON TEST %s", testNoPostfix))
+			} else if statistics, ok := result.(solver.StatisticsSolverResult); options.ExpectChronoBacktracks && (!ok || statistics.GetStatistics()["chrono_backtracks"] == 0) {
+				fmt.Printf(" ERR\n______________RESULT____________:\n  Test: %s, No chronological backtracks\n___________________", testNoPostfix)
+				panic(fmt.Sprintf("NO CHRONOLOGICAL BACKTRACKS ON TEST %s", testNoPostfix))
 			} else {
 				fmt.Printf(" OK\n")
 			}
@@ -60,4 +491,4 @@ func main() {
 		return nil
 	})
 	ctx.FatalIfErrorf(err)
-}
\ No newline at end of file
+}
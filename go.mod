@@ -10,5 +10,6 @@ require (
 	github.com/crillab/gophersat v1.3.1
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mitchellh/go-sat v0.0.0-20170303184941-fc0e735aff48
+	github.com/ulikunitz/xz v0.5.12
 	github.com/urfave/cli v1.22.4
 )
@@ -41,6 +41,13 @@ func (astNode *Variable) String() string {
 	return fmt.Sprintf("Var \"%s\"", trimVarQuotes(astNode.Name))
 }
 
+/**
+ * Name of the variable without the quotes (as it's used in the satisfying assignments)
+ */
+func (astNode *Variable) GetName() string {
+	return trimVarQuotes(astNode.Name)
+}
+
 func MakeVar(name string) *Formula {
 	return &Formula{
 		Variable: &Variable{
@@ -140,6 +147,128 @@ func MakeIff(Arg1 *Formula, Arg2 *Formula) *Formula {
 	}
 }
 
+type Xor struct {
+	Arg1 *Formula `"Xor" @@`
+	Arg2 *Formula ` @@`
+}
+
+func (astNode *Xor) String() string {
+	return fmt.Sprintf("Xor (%s) (%s)", astNode.Arg1.String(), astNode.Arg2.String())
+}
+
+func MakeXor(Arg1 *Formula, Arg2 *Formula) *Formula {
+	return &Formula{
+		Xor:      &Xor{
+			Arg1: Arg1,
+			Arg2: Arg2,
+		},
+	}
+}
+
+/**
+ * Join the formulas with the associative binary connective (MakeAnd, MakeOr or MakeXor) into a tree
+ * of logarithmic depth, so long lists do not create deep recursion in the converters.
+ * The empty list gives the neutral element of the connective.
+ */
+func MakeBalancedTree(args []*Formula, join func(*Formula, *Formula) *Formula, neutral bool) *Formula {
+	if len(args) == 0 {
+		return MakeBoolConstant(neutral)
+	} else if len(args) == 1 {
+		return args[0]
+	}
+	middle := len(args) / 2
+	return join(MakeBalancedTree(args[:middle], join, neutral), MakeBalancedTree(args[middle:], join, neutral))
+}
+
+const CARDINALITY_AT_LEAST = "AtLeast"
+const CARDINALITY_AT_MOST = "AtMost"
+const CARDINALITY_EXACTLY = "Exactly"
+
+/**
+ * Cardinality constraint, for example: AtMost 1 [Var "a", Var "b", Not (Var "c")]
+ */
+type Cardinality struct {
+	Kind  string     `@( "AtLeast" | "AtMost" | "Exactly" )`
+	Bound int        `@Number`
+	Args  []*Formula `"[" ( @@ { "," @@ } )? "]"`
+}
+
+func (astNode *Cardinality) String() string {
+	args := make([]string, len(astNode.Args))
+	for i, arg := range astNode.Args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%s %d [%s]", astNode.Kind, astNode.Bound, strings.Join(args, ", "))
+}
+
+/**
+ * Expand the constraint into plain boolean connectives.
+ * AtLeast k is expanded by the case split on the first argument without sharing the subformulas,
+ * so the size of the result grows exponentially. It's used only by the converters that cannot keep the constraint.
+ * Constants are produced only when the whole constraint is trivially true or false.
+ */
+func (astNode *Cardinality) Expand() *Formula {
+	n := len(astNode.Args)
+	k := astNode.Bound
+	switch astNode.Kind {
+	case CARDINALITY_AT_LEAST:
+		return expandAtLeast(astNode.Args, k)
+	case CARDINALITY_AT_MOST:
+		return expandAtMost(astNode.Args, k)
+	default:
+		if k < 0 || k > n {
+			return MakeBoolConstant(false)
+		} else if k == 0 {
+			return expandAtMost(astNode.Args, 0)
+		} else if k == n {
+			return expandAtLeast(astNode.Args, n)
+		}
+		return MakeAnd(expandAtLeast(astNode.Args, k), expandAtMost(astNode.Args, k))
+	}
+}
+
+func expandAtMost(args []*Formula, k int) *Formula {
+	if k >= len(args) {
+		return MakeBoolConstant(true)
+	} else if k < 0 {
+		return MakeBoolConstant(false)
+	}
+	return MakeNot(expandAtLeast(args, k + 1))
+}
+
+func expandAtLeast(args []*Formula, k int) *Formula {
+	if k <= 0 {
+		return MakeBoolConstant(true)
+	} else if k > len(args) {
+		return MakeBoolConstant(false)
+	}
+	if k == 1 {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = MakeOr(result, arg)
+		}
+		return result
+	} else if k == len(args) {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = MakeAnd(result, arg)
+		}
+		return result
+	}
+	// Either the first argument is true and k-1 of the rest are true or k of the rest are true
+	return MakeOr(MakeAnd(args[0], expandAtLeast(args[1:], k-1)), expandAtLeast(args[1:], k))
+}
+
+func MakeCardinality(kind string, bound int, args []*Formula) *Formula {
+	return &Formula{
+		Cardinality: &Cardinality{
+			Kind:  kind,
+			Bound: bound,
+			Args:  args,
+		},
+	}
+}
+
 type BooleanConstant struct {
 	Bool string `( @"T" | @"F" )`
 }
@@ -171,6 +300,8 @@ type Formula struct {
 	Or       *Or              ` | ( @@ | "(" @@ ")" )`
 	Implies  *Implies         ` | ( @@ | "(" @@ ")" )`
 	Iff      *Iff             ` | ( @@ | "(" @@ ")" )`
+	Xor      *Xor             ` | ( @@ | "(" @@ ")" )`
+	Cardinality *Cardinality  ` | ( @@ | "(" @@ ")" )`
 }
 
 func (f *Formula) AST() *Formula {
@@ -192,6 +323,10 @@ func (astNode *Formula) String() string {
 		return astNode.Implies.String()
 	} else if astNode.Iff != nil {
 		return astNode.Iff.String()
+	} else if astNode.Xor != nil {
+		return astNode.Xor.String()
+	} else if astNode.Cardinality != nil {
+		return astNode.Cardinality.String()
 	}
 
 	panic(fmt.Errorf("Unknown AST node given to Formula.Name() method."))
@@ -0,0 +1,191 @@
+package sat_solver
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * Cardinality constraint: at least Bound of the Literals must be true.
+ * AtMost and Exactly constraints are expressed as AtLeast constraints over the negated literals.
+ * The same literal can occur multiple times and then each occurrence is counted separately
+ * (this is used when the constraint is reified).
+ */
+type CardinalityClause struct {
+	Literals []CNFLiteral
+	Bound    int
+}
+
+/**
+ * Create new constraint requiring at least k of the literals to be true.
+ * T/F constants are folded into the bound.
+ */
+func NewAtLeastClause(literals []CNFLiteral, k int) CardinalityClause {
+	newLiterals := make([]CNFLiteral, 0, len(literals))
+	for _, literal := range literals {
+		if literal == 1 {
+			k--
+		} else if literal != -1 {
+			newLiterals = append(newLiterals, literal)
+		}
+	}
+	return CardinalityClause{
+		Literals: newLiterals,
+		Bound:    k,
+	}
+}
+
+/**
+ * Create new constraint requiring at most k of the literals to be true.
+ */
+func NewAtMostClause(literals []CNFLiteral, k int) CardinalityClause {
+	negated := make([]CNFLiteral, len(literals))
+	for i, literal := range literals {
+		negated[i] = -literal
+	}
+	return NewAtLeastClause(negated, len(literals) - k)
+}
+
+/**
+ * Create constraints for the cardinality AST node kind ("AtLeast", "AtMost" or "Exactly").
+ */
+func NewCardinalityClauses(kind string, literals []CNFLiteral, k int) []CardinalityClause {
+	switch kind {
+	case CARDINALITY_AT_LEAST:
+		return []CardinalityClause{ NewAtLeastClause(literals, k) }
+	case CARDINALITY_AT_MOST:
+		return []CardinalityClause{ NewAtMostClause(literals, k) }
+	default:
+		return []CardinalityClause{ NewAtLeastClause(literals, k), NewAtMostClause(literals, k) }
+	}
+}
+
+// Constraint is satisfied by any assignment
+func (c CardinalityClause) IsTrivial() bool {
+	return c.Bound <= 0
+}
+
+// Constraint cannot be satisfied
+func (c CardinalityClause) IsUnsatisfiable() bool {
+	return c.Bound > len(c.Literals)
+}
+
+/**
+ * Create constraints that encode t <=> c.
+ *   t => c   is   AtLeast k (L + k * [-t])
+ *   -t => -c is   AtLeast (n-k+1) (-L + (n-k+1) * [t])
+ * The constraint must not be trivial nor unsatisfiable.
+ */
+func (c CardinalityClause) Reify(t CNFLiteral) []CardinalityClause {
+	n := len(c.Literals)
+	positive := make([]CNFLiteral, 0, n + c.Bound)
+	positive = append(positive, c.Literals...)
+	for i := 0; i < c.Bound; i++ {
+		positive = append(positive, -t)
+	}
+	negativeBound := n - c.Bound + 1
+	negative := make([]CNFLiteral, 0, n + negativeBound)
+	for _, literal := range c.Literals {
+		negative = append(negative, -literal)
+	}
+	for i := 0; i < negativeBound; i++ {
+		negative = append(negative, t)
+	}
+	return []CardinalityClause{
+		{ Literals: positive, Bound: c.Bound },
+		{ Literals: negative, Bound: negativeBound },
+	}
+}
+
+func (c CardinalityClause) Evaluate(vars []bool) bool {
+	count := 0
+	for _, literal := range c.Literals {
+		if (literal > 0 && vars[literal-1]) || (literal < 0 && !vars[-literal-1]) {
+			count++
+		}
+	}
+	return count >= c.Bound
+}
+
+/**
+ * Check if the constraint is satisfied by the model. Variables missing from the model are treated as false.
+ */
+func (c CardinalityClause) IsSatisfiedBy(model map[CNFLiteral]bool) bool {
+	count := 0
+	for _, literal := range c.Literals {
+		if model[literal.Var()] == (literal > 0) {
+			count++
+		}
+	}
+	return count >= c.Bound
+}
+
+func (c CardinalityClause) AST(vars *SATVariableMapping) *Formula {
+	args := make([]*Formula, len(c.Literals))
+	for i, literal := range c.Literals {
+		if literal < 0 {
+			args[i] = MakeNot(MakeVar(vars.Reverse(-literal)))
+		} else {
+			args[i] = MakeVar(vars.Reverse(literal))
+		}
+	}
+	return MakeCardinality(CARDINALITY_AT_LEAST, c.Bound, args)
+}
+
+func (c CardinalityClause) String(vars *SATVariableMapping) string {
+	partialResult := make([]string, len(c.Literals))
+	for i, literal := range c.Literals {
+		partialResult[i] = literal.String(vars)
+	}
+	return fmt.Sprintf("(%s >= %d)", strings.Join(partialResult, " + "), c.Bound)
+}
+
+/**
+ * Encode the constraint as clauses using the sequential counter (Sinz 2005).
+ * The constraint is encoded as "at most n-k of the negated literals are true".
+ * Register variables are returned by the fresh function. The encoding has O(n*k) clauses.
+ */
+func (c CardinalityClause) ToCNF(fresh func() CNFLiteral) []CNFClause {
+	if c.IsTrivial() {
+		return []CNFClause{}
+	} else if c.IsUnsatisfiable() {
+		return []CNFClause{ {} }
+	}
+	n := len(c.Literals)
+	m := n - c.Bound
+	x := make([]CNFLiteral, n)
+	for i, literal := range c.Literals {
+		x[i] = -literal
+	}
+	clauses := []CNFClause{}
+	if m == 0 {
+		for _, literal := range x {
+			clauses = append(clauses, CNFClause{ -literal })
+		}
+		return clauses
+	}
+
+	// s[i][j] is true if at least j+1 of x[0..i] are true
+	// The constraint is not trivial, so m < n and there are at least two literals
+	s := make([][]CNFLiteral, n-1)
+	for i := range s {
+		s[i] = make([]CNFLiteral, m)
+		for j := range s[i] {
+			s[i][j] = fresh()
+		}
+	}
+	clauses = append(clauses, CNFClause{ -x[0], s[0][0] })
+	for j := 1; j < m; j++ {
+		clauses = append(clauses, CNFClause{ -s[0][j] })
+	}
+	for i := 1; i < n-1; i++ {
+		clauses = append(clauses, CNFClause{ -x[i], s[i][0] }, CNFClause{ -s[i-1][0], s[i][0] })
+		for j := 1; j < m; j++ {
+			clauses = append(clauses,
+				CNFClause{ -x[i], -s[i-1][j-1], s[i][j] },
+				CNFClause{ -s[i-1][j], s[i][j] })
+		}
+		clauses = append(clauses, CNFClause{ -x[i], -s[i-1][m-1] })
+	}
+	return append(clauses, CNFClause{ -x[n-1], -s[n-2][m-1] })
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -21,6 +22,10 @@ type CNFFormula struct {
 	// x > 1 is a variable with unique id = x
 	// x < 1 is a variable with unique id = -x
 	Variables []CNFClause
+	// Parity constraints that are handled natively by the solvers
+	Xors      []XORClause
+	// Cardinality constraints that are handled natively by the solvers
+	Cardinalities []CardinalityClause
 }
 
 func (literal CNFLiteral) Sign() bool {
@@ -69,6 +74,20 @@ func (f *CNFFormula) AST(vars *SATVariableMapping) *Formula {
 			currentRet = MakeAnd(currentRet, currentClause)
 		}
 	}
+	for _, xor := range f.Xors {
+		if currentRet == nil {
+			currentRet = xor.AST(vars)
+		} else {
+			currentRet = MakeAnd(currentRet, xor.AST(vars))
+		}
+	}
+	for _, c := range f.Cardinalities {
+		if currentRet == nil {
+			currentRet = c.AST(vars)
+		} else {
+			currentRet = MakeAnd(currentRet, c.AST(vars))
+		}
+	}
 	return currentRet
 }
 
@@ -125,6 +144,58 @@ func (f *CNFFormula) NormalizeVars(vars *SATVariableMapping) (error, *SATVariabl
 		}
 	}
 
+	for i, xor := range f.Xors {
+		newXorVars := make([]CNFLiteral, len(xor.Vars))
+		for j, varID := range xor.Vars {
+			if entry, ok := newMapping[varID]; ok {
+				newXorVars[j] = entry
+			} else {
+				newID := newVars.uniqueID
+				newMapping[varID] = newID
+				newXorVars[j] = newID
+
+				newVars.reverse[newID] = vars.reverse[varID]
+				newVars.names[vars.reverse[varID]] = newID
+
+				newVars.uniqueID++
+			}
+		}
+		// The new IDs start at 1, so NewXORClause() would fold them as the constants
+		sort.Slice(newXorVars, func(i, j int) bool {
+			return newXorVars[i] < newXorVars[j]
+		})
+		f.Xors[i] = XORClause{
+			Vars:   newXorVars,
+			Parity: xor.Parity,
+		}
+	}
+
+	for i, c := range f.Cardinalities {
+		newLiterals := make([]CNFLiteral, len(c.Literals))
+		for j, literal := range c.Literals {
+			varID := literal.Var()
+			entry, ok := newMapping[varID]
+			if !ok {
+				entry = newVars.uniqueID
+				newMapping[varID] = entry
+
+				newVars.reverse[entry] = vars.reverse[varID]
+				newVars.names[vars.reverse[varID]] = entry
+
+				newVars.uniqueID++
+			}
+			if literal < 0 {
+				newLiterals[j] = -entry
+			} else {
+				newLiterals[j] = entry
+			}
+		}
+		f.Cardinalities[i] = CardinalityClause{
+			Literals: newLiterals,
+			Bound:    c.Bound,
+		}
+	}
+
 	return nil, newVars, len(newVars.names)
 }
 
@@ -144,11 +215,23 @@ func (f *CNFFormula) Evaluate(vars []bool) bool {
 			return false
 		}
 	}
+	for _, xor := range f.Xors {
+		if !xor.Evaluate(vars) {
+			return false
+		}
+	}
+	for _, c := range f.Cardinalities {
+		if !c.Evaluate(vars) {
+			return false
+		}
+	}
 	return true
 }
 
 func (f *CNFFormula) AndWith(e *CNFFormula) {
 	f.Variables = append(f.Variables, e.Variables...)
+	f.Xors = append(f.Xors, e.Xors...)
+	f.Cardinalities = append(f.Cardinalities, e.Cardinalities...)
 }
 
 func (f *CNFFormula) MulWith(e *CNFFormula) {
@@ -208,12 +291,24 @@ func (f *CNFFormula) Measure() *SATFormulaStatistics {
 			}
 		}
 	}
+	for _, xor := range f.Xors {
+		for _, varID := range xor.Vars {
+			varIDs[varID] = struct{}{}
+		}
+	}
+	for _, c := range f.Cardinalities {
+		for _, literal := range c.Literals {
+			varIDs[literal.Var()] = struct{}{}
+		}
+	}
 	varCount := int64(0)
 	for range varIDs {
 		varCount++
 	}
 	return &SATFormulaStatistics{
 		variableCount:    varCount,
+		xorCount:         int64(len(f.Xors)),
+		cardinalityCount: int64(len(f.Cardinalities)),
 		clauseCount:      clauseCount,
 		clauseLenSum:     clauseLenSum,
 		clauseDepth:      2,
@@ -223,11 +318,20 @@ func (f *CNFFormula) Measure() *SATFormulaStatistics {
 }
 
 func (f *CNFFormula) SaveDIMACSCNF(writer io.Writer, varNames *SATVariableMapping) error {
-	vars := make([]CNFClause, len(f.Variables))
+	clauses := f.Variables
+	if len(f.Cardinalities) > 0 {
+		// DIMACS has no cardinality constraints, so they are encoded as clauses with fresh variables
+		clauses = append([]CNFClause{}, f.Variables...)
+		fresh := f.freshVariableGenerator()
+		for _, c := range f.Cardinalities {
+			clauses = append(clauses, c.ToCNF(fresh)...)
+		}
+	}
+	vars := make([]CNFClause, len(clauses))
 	variableRemap := map[CNFLiteral]CNFLiteral{}
 	variableNames := map[CNFLiteral]string{}
 	freeID := CNFLiteral(1)
-	for i, clause := range f.Variables {
+	for i, clause := range clauses {
 		vars[i] = make(CNFClause, len(clause))
 		for j, v := range clause {
 			if v == 1 || v == -1 || v == 0 {
@@ -255,8 +359,33 @@ func (f *CNFFormula) SaveDIMACSCNF(writer io.Writer, varNames *SATVariableMappin
 		}
 	}
 
+	xors := make([]CNFClause, 0, len(f.Xors))
+	for _, xor := range f.Xors {
+		if len(xor.Vars) == 0 && !xor.Parity {
+			// Always satisfied
+			continue
+		}
+		i := len(xors)
+		xors = append(xors, make(CNFClause, len(xor.Vars)))
+		for j, v := range xor.Vars {
+			if k, ok := variableRemap[v]; ok {
+				xors[i][j] = k
+			} else {
+				variableRemap[v] = freeID
+				variableNames[v] = varNames.Reverse(v)
+				xors[i][j] = freeID
+				freeID++
+			}
+		}
+		// The XOR line is satisfied when the parity is odd, so the even parity is written by negating a literal
+		if !xor.Parity && len(xors[i]) > 0 {
+			xors[i][0] = -xors[i][0]
+		}
+	}
+
 	for v, name := range variableNames {
-		if varNames.IsFounderVariable(v) {
+		// Fresh variables used to encode the cardinality constraints have no names
+		if len(name) > 0 && varNames.IsFounderVariable(v) {
 			_, err := writer.Write([]byte(fmt.Sprintf("c  %d => Variable \"%s\"\n", v, name)))
 			if err != nil {
 				return err
@@ -264,7 +393,7 @@ func (f *CNFFormula) SaveDIMACSCNF(writer io.Writer, varNames *SATVariableMappin
 		}
 	}
 
-	_, err := writer.Write([]byte(fmt.Sprintf("p cnf %d %d\n", len(variableRemap), len(vars))))
+	_, err := writer.Write([]byte(fmt.Sprintf("p cnf %d %d\n", len(variableRemap), len(vars) + len(xors))))
 	if err != nil {
 		return err
 	}
@@ -283,9 +412,57 @@ func (f *CNFFormula) SaveDIMACSCNF(writer io.Writer, varNames *SATVariableMappin
 			return err
 		}
 	}
+	for _, xor := range xors {
+		_, err = writer.Write([]byte("x"))
+		if err != nil {
+			return err
+		}
+		for _, v := range xor {
+			_, err = writer.Write([]byte(fmt.Sprintf("%d ", v)))
+			if err != nil {
+				return err
+			}
+		}
+		_, err = writer.Write([]byte("0\n"))
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+/**
+ * Returns function that creates variables that do not occur in the formula.
+ */
+func (f *CNFFormula) freshVariableGenerator() func() CNFLiteral {
+	freshID := CNFLiteral(1)
+	for _, clause := range f.Variables {
+		for _, literal := range clause {
+			if literal.Var() > freshID {
+				freshID = literal.Var()
+			}
+		}
+	}
+	for _, xor := range f.Xors {
+		for _, v := range xor.Vars {
+			if v > freshID {
+				freshID = v
+			}
+		}
+	}
+	for _, c := range f.Cardinalities {
+		for _, literal := range c.Literals {
+			if literal.Var() > freshID {
+				freshID = literal.Var()
+			}
+		}
+	}
+	return func() CNFLiteral {
+		freshID++
+		return freshID
+	}
+}
+
 func (f *CNFFormula) SaveDIMACSCNFToFile(filePath string, vars *SATVariableMapping) error {
 	outputFile, err := os.Create(filePath)
 	if err != nil {
@@ -349,9 +526,15 @@ func (clause CNFClause) String(vars *SATVariableMapping) string {
 }
 
 func (f *CNFFormula) String(vars *SATVariableMapping) string {
-	result := make([]string, len(f.Variables))
-	for j, clause := range f.Variables {
-		result[j] = clause.String(vars)
+	result := make([]string, 0, len(f.Variables) + len(f.Xors) + len(f.Cardinalities))
+	for _, clause := range f.Variables {
+		result = append(result, clause.String(vars))
+	}
+	for _, xor := range f.Xors {
+		result = append(result, xor.String(vars))
+	}
+	for _, c := range f.Cardinalities {
+		result = append(result, c.String(vars))
 	}
 	return strings.Join(result, "^")
 }
\ No newline at end of file
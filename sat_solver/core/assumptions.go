@@ -0,0 +1,116 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver2 "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+	"github.com/styczynski/go-sat-solver/sat_solver/preprocessor"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/**
+ * Solve the formula under the assumptions with the incremental solver.
+ * If the result is UNSAT, then the failed assumptions are returned as the core.
+ * The assumed variables that are not used by the formula are added to it, so they are included in the model.
+ */
+func RunSATSolverUnderAssumptions(problem solver2.AssumptionsProblem, context *sat_solver.SATContext) (error, solver.AssumptionsSolverResult) {
+	emptyResult := solver.AssumptionsSolverResult{ SolverResult: solver.EmptySolverResult{} }
+	err, assumptionsContext := context.StartProcessing("Solve under assumptions", "")
+	if err != nil {
+		return err, emptyResult
+	}
+
+	// Variables eliminated by the preprocessing cannot be used in the assumptions
+	conf := *context.GetConfiguration()
+	if conf.EnableCNFOptimizations {
+		assumptionsContext.Trace("assumptions", "CNF preprocessing is disabled when solving under assumptions.")
+	}
+	conf.EnableCNFOptimizations = false
+	err, satFormula := preprocessor.PreprocessAST(problem, assumptionsContext.WithConfiguration(conf))
+	if err != nil {
+		if _, ok := err.(*sat_solver.UnsatError); ok {
+			return nil, solver.AssumptionsSolverResult{ SolverResult: solver.SolverQuickUnsatResult{}, Core: []string{} }
+		}
+		return err, emptyResult
+	}
+	if satFormula.IsQuickUNSAT() {
+		return nil, solver.AssumptionsSolverResult{ SolverResult: solver.SolverQuickUnsatResult{}, Core: []string{} }
+	}
+
+	err, s := solver.CreateIncrementalSolver(conf.SolverName, satFormula, assumptionsContext)
+	if err != nil {
+		return err, emptyResult
+	}
+	vars := satFormula.Variables()
+	literals, names := assumedLiterals(problem, vars, s)
+
+	err, result, failed := s.SolveWithAssumptions(literals)
+	if err != nil {
+		return err, emptyResult
+	}
+	core := []string{}
+	if result.IsSAT() && conf.EnableModelChecking {
+		err = checkAssumptions(result.GetSatisfyingAssignment(), literals, names, vars)
+		if err != nil {
+			return err, emptyResult
+		}
+	} else if result.IsUNSAT() {
+		core = assumptionsCore(failed, names)
+		assumptionsContext.Trace("assumptions", "Found core of %d out of %d assumptions.", len(core), len(literals))
+	}
+	assumptionsResult := solver.AssumptionsSolverResult{
+		SolverResult: result,
+		Core:         core,
+	}
+	err = assumptionsContext.EndProcessing(assumptionsResult)
+	if err != nil {
+		return err, emptyResult
+	}
+	return nil, assumptionsResult
+}
+
+/*
+ * Convert the assumptions into the literals of the solver.
+ * The returned map contains the assumptions written as the literals ("x" or "-x") by the assumed literals.
+ */
+func assumedLiterals(problem solver2.AssumptionsProblem, vars *sat_solver.SATVariableMapping, s solver.IncrementalSolver) ([]sat_solver.CNFLiteral, map[sat_solver.CNFLiteral]string) {
+	literals := []sat_solver.CNFLiteral{}
+	names := map[sat_solver.CNFLiteral]string{}
+	for _, assumption := range problem.GetAssumptions() {
+		v, ok := vars.Lookup(assumption.Name)
+		if !ok {
+			v = s.NewNamedVariable(assumption.Name)
+		}
+		name := vars.Reverse(v)
+		if !assumption.Value {
+			v, name = -v, "-" + name
+		}
+		literals = append(literals, v)
+		names[v] = name
+	}
+	return literals, names
+}
+
+/*
+ * Check if the model satisfies all the assumed literals.
+ */
+func checkAssumptions(model map[string]bool, literals []sat_solver.CNFLiteral, names map[sat_solver.CNFLiteral]string, vars *sat_solver.SATVariableMapping) error {
+	for _, literal := range literals {
+		if model[vars.Reverse(literal.Var())] != (literal > 0) {
+			return fmt.Errorf("The model does not satisfy the assumption %s.", names[literal])
+		}
+	}
+	return nil
+}
+
+/*
+ * Convert the failed assumed literals into the core.
+ */
+func assumptionsCore(failed []sat_solver.CNFLiteral, names map[sat_solver.CNFLiteral]string) []string {
+	core := []string{}
+	for _, literal := range failed {
+		core = append(core, names[literal])
+	}
+	return core
+}
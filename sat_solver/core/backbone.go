@@ -0,0 +1,162 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver2 "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+	"github.com/styczynski/go-sat-solver/sat_solver/preprocessor"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+func RunBackboneOnFilePath(filePath string, context *sat_solver.SATContext) (error, solver.BackboneSolverResult) {
+	err, loadedFormula := loadFormulaFromFilePath(filePath, context)
+	if err != nil {
+		return err, solver.BackboneSolverResult{ SolverResult: solver.EmptySolverResult{} }
+	}
+	return RunBackbone(loadedFormula, context)
+}
+
+/**
+ * Find the backbone of the formula: founder variables that have the same value in all the models.
+ *
+ * The first model gives the candidates. Each candidate literal l is checked by solving with the assumption -l.
+ * If the result is UNSAT then l is in the backbone and it's added as a unit clause to speed up the next calls.
+ * Otherwise the new model removes from the candidates all the variables with a different value (so usually
+ * many candidates are rejected with a single call).
+ * All the calls are done using the same incremental solver, so the learned clauses are reused.
+ * If the formula has the assumptions (see AssumptionsProblem), then all the calls are done under them and
+ * the backbone of the formula under the assumptions is computed. If it's UNSAT under them, the core is reported.
+ *
+ * For more details please see the paper by Janota, Lynce and Marques-Silva:
+ *   "Algorithms for computing backbones of propositional formulae" (AI Communications 2015)
+ */
+func RunBackbone(formula solver2.LoadedFormula, context *sat_solver.SATContext) (error, solver.BackboneSolverResult) {
+	emptyResult := solver.BackboneSolverResult{ SolverResult: solver.EmptySolverResult{} }
+	var checker sat_solver.ModelChecker = nil
+	if context.GetConfiguration().EnableModelChecking {
+		checker = solver2.GetModelChecker(formula)
+	}
+	err, backboneContext := context.StartProcessing("Compute backbone", "")
+	if err != nil {
+		return err, emptyResult
+	}
+
+	problem, hasAssumptions := formula.(solver2.AssumptionsProblem)
+	var quickUnsatResult solver.SolverResult = solver.SolverQuickUnsatResult{}
+	if hasAssumptions {
+		quickUnsatResult = solver.AssumptionsSolverResult{ SolverResult: quickUnsatResult, Core: []string{} }
+	}
+
+	// Variables eliminated by the preprocessing cannot be used in the assumptions
+	conf := *context.GetConfiguration()
+	if conf.EnableCNFOptimizations {
+		backboneContext.Trace("backbone", "CNF preprocessing is disabled for the backbone computation.")
+	}
+	conf.EnableCNFOptimizations = false
+	err, satFormula := preprocessor.PreprocessAST(formula, backboneContext.WithConfiguration(conf))
+	if err != nil {
+		if _, ok := err.(*sat_solver.UnsatError); ok {
+			return nil, solver.BackboneSolverResult{ SolverResult: quickUnsatResult }
+		}
+		return err, emptyResult
+	}
+	if satFormula.IsQuickUNSAT() {
+		return nil, solver.BackboneSolverResult{ SolverResult: quickUnsatResult }
+	}
+
+	err, s := solver.CreateIncrementalSolver(solver.DEFAULT_SOLVER_NAME, satFormula, backboneContext)
+	if err != nil {
+		return err, emptyResult
+	}
+	vars := satFormula.Variables()
+	founders := map[string]sat_solver.CNFLiteral{}
+	for _, v := range vars.GetAllVariables() {
+		if vars.IsFounderVariable(v) {
+			founders[vars.Reverse(v)] = v
+			s.FreezeVariable(v)
+		}
+	}
+
+	assumptions := []sat_solver.CNFLiteral{}
+	assumptionNames := map[sat_solver.CNFLiteral]string{}
+	if hasAssumptions {
+		assumptions, assumptionNames = assumedLiterals(problem, vars, s)
+	}
+
+	err, firstResult, failed := s.SolveWithAssumptions(assumptions)
+	if err != nil {
+		return err, emptyResult
+	}
+	if hasAssumptions && firstResult.IsUNSAT() {
+		core := assumptionsCore(failed, assumptionNames)
+		backboneContext.Trace("backbone", "Found core of %d out of %d assumptions.", len(core), len(assumptions))
+		return nil, solver.BackboneSolverResult{ SolverResult: solver.AssumptionsSolverResult{ SolverResult: firstResult, Core: core } }
+	}
+	if !firstResult.IsSAT() {
+		return nil, solver.BackboneSolverResult{ SolverResult: firstResult }
+	}
+	if checker != nil {
+		err = sat_solver.CheckModel(checker, firstResult.GetSatisfyingAssignment())
+		if err != nil {
+			return err, emptyResult
+		}
+		err = checkAssumptions(firstResult.GetSatisfyingAssignment(), assumptions, assumptionNames, vars)
+		if err != nil {
+			return err, emptyResult
+		}
+	}
+
+	candidates := map[string]bool{}
+	names := []string{}
+	for name, value := range firstResult.GetSatisfyingAssignment() {
+		if _, ok := founders[name]; ok {
+			candidates[name] = value
+			names = append(names, name)
+		}
+	}
+	// Check the candidates in a fixed order, so the results are reproducible
+	sort.Strings(names)
+
+	backbone := map[string]bool{}
+	for _, name := range names {
+		value, ok := candidates[name]
+		if !ok {
+			continue
+		}
+		literal := founders[name]
+		if !value {
+			literal = -literal
+		}
+		// The full slice expression makes append copy the assumptions instead of overwriting them
+		err, result, _ := s.SolveWithAssumptions(append(assumptions[:len(assumptions):len(assumptions)], -literal))
+		if err != nil {
+			return err, emptyResult
+		}
+		if result.IsSAT() {
+			model := result.GetSatisfyingAssignment()
+			for candidate, candidateValue := range candidates {
+				if modelValue, ok := model[candidate]; !ok || modelValue != candidateValue {
+					delete(candidates, candidate)
+				}
+			}
+		} else if result.IsUNSAT() {
+			backbone[name] = value
+			delete(candidates, name)
+			s.AddClause(sat_solver.CNFClause{ literal })
+		} else {
+			return nil, solver.BackboneSolverResult{ SolverResult: result }
+		}
+	}
+	backboneContext.Trace("backbone", "Found backbone with %d of %d variables.", len(backbone), len(names))
+
+	result := solver.BackboneSolverResult{
+		SolverResult: firstResult,
+		Backbone:     backbone,
+	}
+	err = backboneContext.EndProcessing(result)
+	if err != nil {
+		return err, result
+	}
+	return nil, result
+}
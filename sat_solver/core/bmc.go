@@ -0,0 +1,203 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver2 "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+	"github.com/styczynski/go-sat-solver/sat_solver/loaders/aiger"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/**
+ * Run the bounded model checking of the AIGER circuit from the file or from the standard input if the path is "-".
+ */
+func RunBMCOnFilePath(filePath string, bound int, output io.Writer, context *sat_solver.SATContext) error {
+	var r io.Reader = os.Stdin
+	if filePath != "-" {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	err, input := solver2.DecompressInput(r)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	err, aig := aiger.ParseAIG(input)
+	if err != nil {
+		return err
+	}
+	return RunBMC(aig, bound, output, context)
+}
+
+/*
+ * Create the incremental solver without any clauses.
+ */
+func createEmptyIncrementalSolver(context *sat_solver.SATContext) (error, solver.IncrementalSolver) {
+	formula := sat_solver.NewSATFormula(&sat_solver.CNFFormula{
+		Variables: []sat_solver.CNFClause{},
+	}, sat_solver.NewSATVariableMapping(), nil)
+	return solver.CreateIncrementalSolver(context.GetConfiguration().SolverName, formula, context)
+}
+
+/**
+ * Check the properties of the circuit with the bounded model checking and print the result in the AIGER witness format.
+ *
+ * The transition relation is unrolled step by step using one incremental solver. In the step k the solver
+ * looks for the path of length k+1 from the initial state that satisfies the invariant constraints in all the steps
+ * and reaches a bad state in the last step. If there is no such path, then the properties are added as
+ * false in the step k, which is implied by the formula and helps the next checks.
+ *
+ * On SAT the witness is printed: "1", the violated properties, the initial values of the latches, the values
+ * of the inputs in each step and ".". The witness is verified by the simulation of the circuit before it's printed.
+ * If no property is violated up to the bound, then "2" (unknown) is printed. If the constraints cannot be satisfied
+ * in some step, then no longer paths exist and "0" (the properties hold) is printed.
+ */
+func RunBMC(aig *aiger.AIG, bound int, output io.Writer, context *sat_solver.SATContext) error {
+	properties := aig.Properties()
+	if len(properties) == 0 {
+		if len(aig.Justice) > 0 {
+			return fmt.Errorf("Justice properties are not supported by the bounded model checking.")
+		}
+		return fmt.Errorf("The circuit does not have any outputs or bad state properties.")
+	}
+	err, bmcContext := context.StartProcessing("Bounded model checking", "")
+	if err != nil {
+		return err
+	}
+	err, s := createEmptyIncrementalSolver(bmcContext)
+	if err != nil {
+		return err
+	}
+	isUnsat := false
+	addClause := func(clause sat_solver.CNFClause) {
+		if !s.AddClause(clause) {
+			isUnsat = true
+		}
+	}
+	encoder := aiger.NewEncoder(s.NewVariable, addClause)
+	propertyNames := make([]string, len(properties))
+	for i := range properties {
+		propertyNames[i] = fmt.Sprintf("b%d", i)
+	}
+
+	latches := make([]sat_solver.CNFLiteral, len(aig.Latches))
+	for i, latch := range aig.Latches {
+		switch latch.Reset {
+		case 0:
+			latches[i] = -encoder.True()
+		case 1:
+			latches[i] = encoder.True()
+		default:
+			latches[i] = s.NewNamedVariable(fmt.Sprintf("l%d@0", i))
+		}
+	}
+
+	var result solver.SolverResult = solver.EmptySolverResult{}
+	for step := 0; step <= bound; step++ {
+		inputs := make([]sat_solver.CNFLiteral, len(aig.Inputs))
+		for i := range aig.Inputs {
+			inputs[i] = s.NewNamedVariable(fmt.Sprintf("i%d@%d", i, step))
+		}
+		frame := encoder.EncodeFrame(aig, inputs, latches)
+		for _, constraint := range aig.Constraints {
+			addClause(sat_solver.CNFClause{ frame.Literal(constraint) })
+		}
+		if isUnsat {
+			bmcContext.Trace("bmc", "The constraints cannot be satisfied in the step %d.", step)
+			result = solver.SolverQuickUnsatResult{}
+			fmt.Fprintf(output, "0\n%s\n.\n", strings.Join(propertyNames, " "))
+			return bmcContext.EndProcessing(result)
+		}
+
+		bad := make(sat_solver.CNFClause, len(properties))
+		for i, property := range properties {
+			bad[i] = frame.Literal(property)
+		}
+		selector := s.NewVariable()
+		addClause(append(sat_solver.CNFClause{ -selector }, bad...))
+		err, result, _ = s.SolveWithAssumptions([]sat_solver.CNFLiteral{ selector })
+		if err != nil {
+			return err
+		}
+		if result.IsSAT() {
+			bmcContext.Trace("bmc", "Found counterexample of length %d.", step + 1)
+			if err := printBMCWitness(aig, step, result.GetSatisfyingAssignment(), output); err != nil {
+				return err
+			}
+			return bmcContext.EndProcessing(result)
+		} else if result.IsUndefined() {
+			break
+		}
+		bmcContext.Trace("bmc", "No counterexample of length %d.", step + 1)
+		for _, literal := range bad {
+			addClause(sat_solver.CNFClause{ -literal })
+		}
+
+		next := make([]sat_solver.CNFLiteral, len(aig.Latches))
+		for i, latch := range aig.Latches {
+			next[i] = frame.Literal(latch.Next)
+		}
+		latches = next
+	}
+	fmt.Fprintf(output, "2\n%s\n.\n", strings.Join(propertyNames, " "))
+	return bmcContext.EndProcessing(result)
+}
+
+func formatWitnessValues(values []bool) string {
+	text := make([]byte, len(values))
+	for i, value := range values {
+		text[i] = '0'
+		if value {
+			text[i] = '1'
+		}
+	}
+	return string(text)
+}
+
+/*
+ * Print the witness of the counterexample of length lastStep+1. The variables missing from the model can have
+ * any value, so they are set to false.
+ */
+func printBMCWitness(aig *aiger.AIG, lastStep int, model map[string]bool, output io.Writer) error {
+	latches := make([]bool, len(aig.Latches))
+	for i, latch := range aig.Latches {
+		if latch.Reset == latch.Literal {
+			latches[i] = model[fmt.Sprintf("l%d@0", i)]
+		} else {
+			latches[i] = latch.Reset == 1
+		}
+	}
+	inputs := make([][]bool, lastStep + 1)
+	for step := range inputs {
+		inputs[step] = make([]bool, len(aig.Inputs))
+		for i := range aig.Inputs {
+			inputs[step][i] = model[fmt.Sprintf("i%d@%d", i, step)]
+		}
+	}
+
+	values, constraintsHold := aig.Simulate(latches, inputs)
+	violated := []string{}
+	for i, value := range values {
+		if value {
+			violated = append(violated, fmt.Sprintf("b%d", i))
+		}
+	}
+	if len(violated) == 0 || !constraintsHold {
+		return fmt.Errorf("The counterexample found by the solver is invalid: it does not reach a bad state in the simulation.")
+	}
+
+	lines := []string{ "1", strings.Join(violated, " "), formatWitnessValues(latches) }
+	for _, step := range inputs {
+		lines = append(lines, formatWitnessValues(step))
+	}
+	fmt.Fprintf(output, "%s\n.\n", strings.Join(lines, "\n"))
+	return nil
+}
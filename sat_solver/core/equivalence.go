@@ -0,0 +1,217 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver2 "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+	"github.com/styczynski/go-sat-solver/sat_solver/loaders/bench"
+)
+
+/**
+ * Result of the equivalence checking of two netlists.
+ * If the netlists are not equivalent, then the distinguishing input vector is given
+ * together with the outputs that have different values for it.
+ */
+type EquivalenceResult struct {
+	IsEquivalent     bool               `json:"equivalent"`
+	// Names of the inputs and the outputs of the flip-flops in the order of the first netlist
+	InputNames       []string           `json:"-"`
+	Inputs           map[string]bool    `json:"inputs,omitempty"`
+	// Outputs (or the flip-flops with the different next states) with their values in both netlists
+	DifferentOutputs []string           `json:"different_outputs,omitempty"`
+	Values           map[string][2]bool `json:"values,omitempty"`
+}
+
+func formatBit(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (result EquivalenceResult) String() string {
+	if result.IsEquivalent {
+		return "Equivalent"
+	}
+	lines := []string{ "Not equivalent. Distinguishing input vector:" }
+	for _, name := range result.InputNames {
+		lines = append(lines, fmt.Sprintf("\t%s = %s", name, formatBit(result.Inputs[name])))
+	}
+	lines = append(lines, "Different outputs:")
+	for _, name := range result.DifferentOutputs {
+		values := result.Values[name]
+		lines = append(lines, fmt.Sprintf("\t%s = %s (first), %s (second)", name, formatBit(values[0]), formatBit(values[1])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+/**
+ * Format the result as JSON, for example:
+ *   {"equivalent":false,"inputs":{"a":true,"b":false},"different_outputs":["z"],"values":{"z":[true,false]}}
+ */
+func (result EquivalenceResult) JSON() string {
+	text, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+	return string(text)
+}
+
+/*
+ * Load the netlist from the file (compressed files are decompressed).
+ */
+func loadNetlistFromFilePath(filePath string) (error, *bench.Netlist) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err, nil
+	}
+	defer f.Close()
+	err, input := solver2.DecompressInput(f)
+	if err != nil {
+		return err, nil
+	}
+	defer input.Close()
+	err, netlist := bench.ParseNetlist(input)
+	if err != nil {
+		return fmt.Errorf("%s: %v", filePath, err), nil
+	}
+	return nil, netlist
+}
+
+func CheckEquivalenceOfFilePaths(firstPath string, secondPath string, context *sat_solver.SATContext) (error, EquivalenceResult) {
+	err, first := loadNetlistFromFilePath(firstPath)
+	if err != nil {
+		return err, EquivalenceResult{}
+	}
+	err, second := loadNetlistFromFilePath(secondPath)
+	if err != nil {
+		return err, EquivalenceResult{}
+	}
+	return CheckEquivalence(first, second, context)
+}
+
+/*
+ * Check that both lists contain the same names.
+ */
+func checkSameSignals(kind string, first []string, second []string) error {
+	inSecond := map[string]bool{}
+	for _, name := range second {
+		inSecond[name] = true
+	}
+	for _, name := range first {
+		if !inSecond[name] {
+			return fmt.Errorf("The %s %s of the first netlist is missing in the second one.", kind, name)
+		}
+		delete(inSecond, name)
+	}
+	for _, name := range second {
+		if inSecond[name] {
+			return fmt.Errorf("The %s %s of the second netlist is missing in the first one.", kind, name)
+		}
+	}
+	return nil
+}
+
+/**
+ * Check the combinational equivalence of two netlists with the miter: the inputs with the same names are shared
+ * and the formula is true if some pair of the outputs with the same names differs. The netlists are equivalent
+ * if the miter is UNSAT, otherwise its model is the distinguishing input vector.
+ *
+ * The flip-flops are matched by their names as well: their outputs are shared inputs and their next states are
+ * compared like the outputs. So the sequential netlists with the same state encoding can be checked.
+ */
+func CheckEquivalence(first *bench.Netlist, second *bench.Netlist, context *sat_solver.SATContext) (error, EquivalenceResult) {
+	if err := checkSameSignals("input", first.Inputs, second.Inputs); err != nil {
+		return err, EquivalenceResult{}
+	}
+	if err := checkSameSignals("output", first.Outputs, second.Outputs); err != nil {
+		return err, EquivalenceResult{}
+	}
+	if err := checkSameSignals("flip-flop", first.FlipFlops, second.FlipFlops); err != nil {
+		return err, EquivalenceResult{}
+	}
+
+	// Both netlists share the gates, so their identical parts are the same nodes
+	table := bench.NewGateTable()
+	builders := [2]*bench.Builder{ bench.NewBuilder(first, table), bench.NewBuilder(second, table) }
+	// Compared signals: outputs and the next states of the flip-flops
+	compared := [][2]*sat_solver.Formula{}
+	comparedNames := []string{}
+	for _, output := range first.Outputs {
+		pair := [2]*sat_solver.Formula{}
+		for i, builder := range builders {
+			err, formula := builder.Signal(output)
+			if err != nil {
+				return err, EquivalenceResult{}
+			}
+			pair[i] = formula
+		}
+		compared = append(compared, pair)
+		comparedNames = append(comparedNames, output)
+	}
+	for _, flipFlop := range first.FlipFlops {
+		pair := [2]*sat_solver.Formula{}
+		for i, builder := range builders {
+			err, formula := builder.NextState(flipFlop)
+			if err != nil {
+				return err, EquivalenceResult{}
+			}
+			pair[i] = formula
+		}
+		compared = append(compared, pair)
+		comparedNames = append(comparedNames, fmt.Sprintf("next state of %s", flipFlop))
+	}
+
+	// The signals with the same nodes are equal, so they are not included in the miter
+	differences := []*sat_solver.Formula{}
+	for _, pair := range compared {
+		if pair[0] != pair[1] {
+			differences = append(differences, sat_solver.MakeXor(pair[0], pair[1]))
+		}
+	}
+	context.Trace("equiv", "%d of %d compared signals are structurally equal.", len(compared) - len(differences), len(compared))
+	if len(differences) == 0 {
+		return nil, EquivalenceResult{ IsEquivalent: true }
+	}
+	miter := &sat_solver.Entry{
+		Formula: sat_solver.MakeBalancedTree(differences, sat_solver.MakeOr, false),
+	}
+	err, result := RunSATSolverOnLoadedFormula(miter, context)
+	if err != nil {
+		return err, EquivalenceResult{}
+	}
+	if result.IsUNSAT() {
+		return nil, EquivalenceResult{ IsEquivalent: true }
+	} else if !result.IsSAT() {
+		return fmt.Errorf("The solver could not decide the equivalence."), EquivalenceResult{}
+	}
+
+	// Inputs missing from the model do not change the outputs, so they are set to false
+	equivalence := EquivalenceResult{
+		InputNames: append(append([]string{}, first.Inputs...), first.FlipFlops...),
+		Inputs:     map[string]bool{},
+		Values:     map[string][2]bool{},
+	}
+	model := result.GetSatisfyingAssignment()
+	for _, name := range equivalence.InputNames {
+		equivalence.Inputs[name] = model[name]
+	}
+	for i, pair := range compared {
+		values := [2]bool{
+			pair[0].EvaluatePartial(equivalence.Inputs) == sat_solver.MODEL_VALUE_TRUE,
+			pair[1].EvaluatePartial(equivalence.Inputs) == sat_solver.MODEL_VALUE_TRUE,
+		}
+		if values[0] != values[1] {
+			equivalence.DifferentOutputs = append(equivalence.DifferentOutputs, comparedNames[i])
+			equivalence.Values[comparedNames[i]] = values
+		}
+	}
+	if len(equivalence.DifferentOutputs) == 0 {
+		return fmt.Errorf("The distinguishing input vector found by the solver does not change any output."), EquivalenceResult{}
+	}
+	return nil, equivalence
+}
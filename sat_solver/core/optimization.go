@@ -0,0 +1,54 @@
+package core
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver2 "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/**
+ * Find the assignment with the minimal value of the objective.
+ * The formula is solved repeatedly and after each solution the objective is bounded by its cost minus one.
+ * The last solution is optimal when the bounded formula becomes UNSAT (or the cost reaches the lower bound).
+ */
+func RunOptimization(problem solver2.OptimizationProblem, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	err, optimizationContext := context.StartProcessing("Optimize objective", "")
+	if err != nil {
+		return err, solver.EmptySolverResult{}
+	}
+
+	var best solver.SolverResult = nil
+	lowerBound := problem.GetObjectiveLowerBound()
+	var formula *sat_solver.SATFormula = problem.ConvertToFormula()
+	for {
+		err, result := solveLoadedFormula(formula, optimizationContext)
+		if err != nil {
+			return err, solver.EmptySolverResult{}
+		}
+		if !result.IsSAT() {
+			if best == nil {
+				best = result
+			}
+			break
+		}
+		cost := problem.GetObjectiveValue(result.GetSatisfyingAssignment())
+		best = solver.OptimumSolverResult{
+			SolverResult: result,
+			Cost:         cost,
+		}
+		context.Trace("optimize", "Found solution with cost %d.", cost)
+		if cost <= lowerBound {
+			break
+		}
+		err, formula = problem.ConvertToFormulaWithObjectiveBound(cost - 1)
+		if err != nil {
+			return err, solver.EmptySolverResult{}
+		}
+	}
+
+	err = optimizationContext.EndProcessing(best)
+	if err != nil {
+		return err, best
+	}
+	return nil, best
+}
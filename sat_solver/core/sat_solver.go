@@ -14,9 +14,22 @@ import (
 
 	_ "github.com/styczynski/go-sat-solver/sat_solver/solver/cdcl_solver"
 	_ "github.com/styczynski/go-sat-solver/sat_solver/solver/naive_solver"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/solver/dpll_solver"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/solver/lookahead_solver"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/solver/maxsat_solver"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/solver/sls_solver"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/solver/twosat_solver"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/solver/horn_solver"
 
 	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/haskell"
 	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/dimacs_cnf"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/opb"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/wcnf"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/expr"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/smtlib"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/aiger"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/bench"
+	_ "github.com/styczynski/go-sat-solver/sat_solver/loaders/json_formula"
 )
 
 func RunSATSolverOnString(input string, context *sat_solver.SATContext) (error, solver.SolverResult) {
@@ -32,21 +45,26 @@ func RunSATSolverOnString(input string, context *sat_solver.SATContext) (error,
 	return nil, result
 }
 
-func RunSATSolverOnFilePath(filePath string, context *sat_solver.SATContext) (error, solver.SolverResult) {
+/*
+ * Load the formula from the file or from the standard input if the path is "-".
+ */
+func loadFormulaFromFilePath(filePath string, context *sat_solver.SATContext) (error, solver2.LoadedFormula) {
 	var r io.Reader
-	var err error
 	if filePath == "-" {
 		r = bufio.NewReader(os.Stdin)
 	} else {
 		f, err := os.Open(filePath)
 		if err != nil {
-			return err, solver.EmptySolverResult{}
+			return err, nil
 		}
 		defer f.Close()
 		r = f
 	}
+	return solver2.LoadFormula(context.GetConfiguration().LoaderName, r, context)
+}
 
-	err, loadedFormula := solver2.LoadFormula(context.GetConfiguration().LoaderName, r, context)
+func RunSATSolverOnFilePath(filePath string, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	err, loadedFormula := loadFormulaFromFilePath(filePath, context)
 	if err != nil {
 		return err, solver.EmptySolverResult{}
 	}
@@ -62,6 +80,51 @@ type ConvertableToAST interface {
 }
 
 func RunSATSolverOnLoadedFormula(formula solver2.LoadedFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	var checker sat_solver.ModelChecker = nil
+	if context.GetConfiguration().EnableModelChecking || context.GetConfiguration().EnablePartialModels {
+		checker = solver2.GetModelChecker(formula)
+	}
+
+	var err error
+	var result solver.SolverResult
+	if problem, ok := formula.(solver2.OptimizationProblem); ok && problem.HasObjective() {
+		err, result = RunOptimization(problem, context)
+	} else if problem, ok := formula.(solver2.AssumptionsProblem); ok {
+		err, result = RunSATSolverUnderAssumptions(problem, context)
+	} else {
+		err, result = solveLoadedFormula(formula, context)
+	}
+	if err != nil {
+		return err, result
+	}
+
+	if context.GetConfiguration().EnableModelChecking && result.IsSAT() {
+		if checker == nil {
+			context.Trace("check", "The loaded formula does not support model checking.")
+		} else {
+			err = sat_solver.CheckModel(checker, result.GetSatisfyingAssignment())
+			if err != nil {
+				return err, solver.EmptySolverResult{}
+			}
+		}
+	}
+
+	// The objective value depends on all the variables, so the optimal solutions are not reduced
+	if _, isOptimum := result.(solver.OptimumSolverResult); context.GetConfiguration().EnablePartialModels && checker != nil && result.IsSAT() && !isOptimum {
+		model := sat_solver.MinimizeModel(checker, result.GetSatisfyingAssignment())
+		context.Trace("minimize", "Reduced the model from %d to %d variables.", len(result.GetSatisfyingAssignment()), len(model))
+		result = solver.PartialModelSolverResult{
+			SolverResult: result,
+			Model:        model,
+		}
+	}
+	return nil, result
+}
+
+/*
+ * Convert the formula into CNF, preprocess it and run the solver.
+ */
+func solveLoadedFormula(formula solver2.LoadedFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
 	context.Trace("init", "SAT solver inited with the following configuration:\n%s", context.DescribeConfiguration())
 
 	var globalResult solver.SolverResult
@@ -0,0 +1,462 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver2 "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+	"github.com/styczynski/go-sat-solver/sat_solver/loaders/smtlib"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/*
+ * Asserted formula that can be disabled. The clauses of the formula are guarded by the selector literal,
+ * so the formula is active only if the selector is assumed to be true.
+ */
+type smtAssertion struct {
+	selector sat_solver.CNFLiteral
+	// Name given with the :named annotation (empty if there is none)
+	name     string
+}
+
+/*
+ * Assumption of the check-sat-assuming command with its text used in the unsat cores.
+ */
+type smtAssumption struct {
+	literal sat_solver.CNFLiteral
+	text    string
+}
+
+type smtSession struct {
+	context      *sat_solver.SATContext
+	output       io.Writer
+	table        *smtlib.SymbolTable
+	solver       solver.IncrementalSolver
+	// Literal that is always true, it's used to encode the constants
+	trueLiteral  sat_solver.CNFLiteral
+	// Literals of the already encoded nodes of the AST
+	encoded      map[*sat_solver.Formula]sat_solver.CNFLiteral
+	// Guarded assertions on each level, the first level is the global one
+	levels       [][]smtAssertion
+	// Did adding the unguarded clauses make the formula UNSAT?
+	isUnsat      bool
+	printSuccess bool
+	// Model of the last check-sat (nil if the last check was not SAT or the assertions have changed)
+	model        map[string]bool
+	// Unsat core of the last check-sat (nil if the last check was not UNSAT)
+	unsatCore    []string
+}
+
+/**
+ * Execute the SMT-LIB script from the file or from the standard input if the path is "-".
+ * The standard input is executed command by command, so the session can be used interactively.
+ */
+func RunSMTLibSessionOnFilePath(filePath string, output io.Writer, context *sat_solver.SATContext) error {
+	var r io.Reader
+	if filePath == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		err, input := solver2.DecompressInput(f)
+		if err != nil {
+			return err
+		}
+		defer input.Close()
+		r = input
+	}
+	return RunSMTLibSession(r, output, context)
+}
+
+/**
+ * Execute the SMT-LIB script and write the responses to the output.
+ *
+ * The assertions are encoded into the clauses of the incremental solver with the Tseytin transformation.
+ * The global assertions without names are added directly. The other ones are guarded by the selector literals
+ * that are passed to the solver as the assumptions, so pop disables them by adding the negated selectors.
+ * The unsat core consists of the names of the assertions and the check-sat-assuming literals whose
+ * assumptions were used to prove UNSAT.
+ *
+ * Errors in the commands are reported as (error "...") and the execution continues. Syntax errors stop
+ * the session, because the rest of the input cannot be read reliably.
+ */
+func RunSMTLibSession(input io.Reader, output io.Writer, context *sat_solver.SATContext) error {
+	session := &smtSession{
+		context: context,
+		output:  output,
+		table:   smtlib.NewSymbolTable(),
+	}
+	if err := session.resetAssertions(); err != nil {
+		return err
+	}
+
+	reader := smtlib.NewSExprReader(input)
+	for {
+		err, command := reader.Read()
+		if err != nil {
+			session.printError(err)
+			return nil
+		}
+		if command == nil {
+			return nil
+		}
+		err, isExit := session.execute(command)
+		if err != nil {
+			session.printError(err)
+		}
+		if isExit {
+			return nil
+		}
+	}
+}
+
+func (session *smtSession) printError(err error) {
+	fmt.Fprintf(session.output, "(error \"%s\")\n", strings.Replace(err.Error(), "\"", "\"\"", -1))
+}
+
+func (session *smtSession) printSuccessResponse() {
+	if session.printSuccess {
+		fmt.Fprintln(session.output, "success")
+	}
+}
+
+/*
+ * Create a new solver without any assertions. The declarations are not changed.
+ */
+func (session *smtSession) resetAssertions() error {
+	err, s := createEmptyIncrementalSolver(session.context)
+	if err != nil {
+		return err
+	}
+	session.solver = s
+	session.trueLiteral = s.NewVariable()
+	session.encoded = map[*sat_solver.Formula]sat_solver.CNFLiteral{}
+	session.levels = [][]smtAssertion{ {} }
+	session.isUnsat = false
+	session.model = nil
+	session.unsatCore = nil
+	session.addClause(sat_solver.CNFClause{ session.trueLiteral })
+	return nil
+}
+
+func (session *smtSession) addClause(clause sat_solver.CNFClause) {
+	if !session.solver.AddClause(clause) {
+		session.isUnsat = true
+	}
+}
+
+/*
+ * Execute the command. Returns true if the session should be finished.
+ */
+func (session *smtSession) execute(command *smtlib.SExpr) (error, bool) {
+	name := command.Head()
+	if len(name) == 0 {
+		return fmt.Errorf("SMT-LIB line %d, column %d: expected command, got %s", command.Line, command.Column, command.String()), false
+	}
+	if err, ok := session.table.ExecuteDeclaration(command); ok {
+		if err == nil {
+			session.printSuccessResponse()
+		}
+		return err, false
+	}
+
+	args := command.List[1:]
+	switch name {
+	case "exit":
+		session.printSuccessResponse()
+		return nil, true
+	case "set-option":
+		if len(args) == 2 && args[0].IsSymbol(":print-success") {
+			session.printSuccess = args[1].IsSymbol("true")
+		}
+		session.printSuccessResponse()
+	case "set-logic", "set-info":
+		session.printSuccessResponse()
+	case "echo":
+		if len(args) != 1 || !args[0].IsString {
+			return fmt.Errorf("expected (echo <string>)"), false
+		}
+		fmt.Fprintln(session.output, args[0].String())
+	case "get-info":
+		if len(args) == 1 && args[0].IsSymbol(":name") {
+			fmt.Fprintln(session.output, "(:name \"go-sat-solver\")")
+		} else if len(args) == 1 && args[0].IsSymbol(":error-behavior") {
+			fmt.Fprintln(session.output, "(:error-behavior continued-execution)")
+		} else {
+			fmt.Fprintln(session.output, "unsupported")
+		}
+	case "assert":
+		if len(args) != 1 {
+			return fmt.Errorf("expected (assert <term>)"), false
+		}
+		return session.assert(args[0]), false
+	case "push", "pop":
+		err, levels := smtlib.LevelsArgument(command)
+		if err != nil {
+			return err, false
+		}
+		if name == "pop" && levels > session.table.Depth() {
+			return fmt.Errorf("cannot pop %d levels, only %d were pushed", levels, session.table.Depth()), false
+		}
+		for i := 0; i < levels; i++ {
+			if name == "push" {
+				session.push()
+			} else {
+				session.pop()
+			}
+		}
+		session.printSuccessResponse()
+	case "reset-assertions":
+		for session.table.Depth() > 0 {
+			session.table.Pop()
+		}
+		if err := session.resetAssertions(); err != nil {
+			return err, false
+		}
+		session.printSuccessResponse()
+	case "reset":
+		session.table = smtlib.NewSymbolTable()
+		session.printSuccess = false
+		if err := session.resetAssertions(); err != nil {
+			return err, false
+		}
+		session.printSuccessResponse()
+	case "check-sat":
+		return session.checkSat(nil), false
+	case "check-sat-assuming":
+		if len(args) != 1 || !args[0].IsList {
+			return fmt.Errorf("expected (check-sat-assuming (<literal>*))"), false
+		}
+		return session.checkSat(args[0].List), false
+	case "get-model":
+		return session.printModel(), false
+	case "get-value":
+		if len(args) != 1 || !args[0].IsList || len(args[0].List) == 0 {
+			return fmt.Errorf("expected (get-value (<term>+))"), false
+		}
+		return session.printValues(args[0].List), false
+	case "get-unsat-core":
+		if session.unsatCore == nil {
+			return fmt.Errorf("unsat core is available only after check-sat returned unsat"), false
+		}
+		fmt.Fprintf(session.output, "(%s)\n", strings.Join(session.unsatCore, " "))
+	default:
+		fmt.Fprintln(session.output, "unsupported")
+	}
+	return nil, false
+}
+
+func (session *smtSession) invalidateResult() {
+	session.model = nil
+	session.unsatCore = nil
+}
+
+func (session *smtSession) assert(term *smtlib.SExpr) error {
+	err, formula, name := session.table.TranslateAssertion(term)
+	if err != nil {
+		return err
+	}
+	session.invalidateResult()
+	root := session.encode(formula)
+	last := len(session.levels) - 1
+	if last == 0 && len(name) == 0 {
+		// Global assertions without names are never removed
+		session.addClause(sat_solver.CNFClause{ root })
+	} else {
+		selector := session.solver.NewVariable()
+		session.addClause(sat_solver.CNFClause{ -selector, root })
+		if len(name) > 0 {
+			name = smtlib.QuoteSymbol(name)
+		}
+		session.levels[last] = append(session.levels[last], smtAssertion{
+			selector: selector,
+			name:     name,
+		})
+	}
+	session.printSuccessResponse()
+	return nil
+}
+
+func (session *smtSession) push() {
+	session.table.Push()
+	session.levels = append(session.levels, []smtAssertion{})
+	session.invalidateResult()
+}
+
+/*
+ * Remove the last level. Its assertions are disabled forever by the negated selectors.
+ */
+func (session *smtSession) pop() {
+	session.table.Pop()
+	last := len(session.levels) - 1
+	for _, assertion := range session.levels[last] {
+		session.addClause(sat_solver.CNFClause{ -assertion.selector })
+	}
+	session.levels = session.levels[:last]
+	session.invalidateResult()
+}
+
+/*
+ * Encode the node of the AST with the Tseytin transformation. Returns the literal equivalent to the node.
+ * The AST can be a DAG (let-bindings and definitions), so the literals of the nodes are cached.
+ */
+func (session *smtSession) encode(formula *sat_solver.Formula) sat_solver.CNFLiteral {
+	if literal, ok := session.encoded[formula]; ok {
+		return literal
+	}
+	var literal sat_solver.CNFLiteral
+	if formula.Constant != nil {
+		literal = session.trueLiteral
+		if formula.Constant.Bool != "T" {
+			literal = -literal
+		}
+	} else if formula.Variable != nil {
+		literal = session.solver.NewNamedVariable(formula.Variable.Name)
+	} else if formula.Not != nil {
+		literal = -session.encode(formula.Not.Formula)
+	} else if formula.And != nil {
+		literal = session.encodeBinary(session.encode(formula.And.Arg1), session.encode(formula.And.Arg2), false)
+	} else if formula.Or != nil {
+		literal = -session.encodeBinary(-session.encode(formula.Or.Arg1), -session.encode(formula.Or.Arg2), false)
+	} else if formula.Implies != nil {
+		literal = -session.encodeBinary(session.encode(formula.Implies.Arg1), -session.encode(formula.Implies.Arg2), false)
+	} else if formula.Iff != nil {
+		literal = -session.encodeBinary(session.encode(formula.Iff.Arg1), session.encode(formula.Iff.Arg2), true)
+	} else if formula.Xor != nil {
+		literal = session.encodeBinary(session.encode(formula.Xor.Arg1), session.encode(formula.Xor.Arg2), true)
+	} else {
+		panic(fmt.Sprintf("Unsupported node of the SMT-LIB formula: %s", formula.String()))
+	}
+	session.encoded[formula] = literal
+	return literal
+}
+
+/*
+ * Create the literal x equivalent to (a and b) or to (a xor b).
+ */
+func (session *smtSession) encodeBinary(a sat_solver.CNFLiteral, b sat_solver.CNFLiteral, isXor bool) sat_solver.CNFLiteral {
+	x := session.solver.NewVariable()
+	if isXor {
+		session.addClause(sat_solver.CNFClause{ -x, a, b })
+		session.addClause(sat_solver.CNFClause{ -x, -a, -b })
+		session.addClause(sat_solver.CNFClause{ x, -a, b })
+		session.addClause(sat_solver.CNFClause{ x, a, -b })
+	} else {
+		session.addClause(sat_solver.CNFClause{ -x, a })
+		session.addClause(sat_solver.CNFClause{ -x, b })
+		session.addClause(sat_solver.CNFClause{ x, -a, -b })
+	}
+	return x
+}
+
+func (session *smtSession) checkSat(literals []*smtlib.SExpr) error {
+	assumptions := []smtAssumption{}
+	for _, term := range literals {
+		err, formula := session.table.TranslateTerm(term)
+		if err != nil {
+			return err
+		}
+		assumptions = append(assumptions, smtAssumption{
+			literal: session.encode(formula),
+			text:    term.String(),
+		})
+	}
+	session.invalidateResult()
+	if session.isUnsat {
+		session.unsatCore = []string{}
+		fmt.Fprintln(session.output, "unsat")
+		return nil
+	}
+
+	// Names of the assumptions (empty for the unnamed assertions)
+	names := map[sat_solver.CNFLiteral]string{}
+	solverAssumptions := []sat_solver.CNFLiteral{}
+	for _, level := range session.levels {
+		for _, assertion := range level {
+			names[assertion.selector] = assertion.name
+			solverAssumptions = append(solverAssumptions, assertion.selector)
+		}
+	}
+	for _, assumption := range assumptions {
+		if _, ok := names[assumption.literal]; !ok {
+			names[assumption.literal] = assumption.text
+		}
+		solverAssumptions = append(solverAssumptions, assumption.literal)
+	}
+
+	err, result, failed := session.solver.SolveWithAssumptions(solverAssumptions)
+	if err != nil {
+		return err
+	}
+	if result.IsSAT() {
+		session.model = result.GetSatisfyingAssignment()
+		fmt.Fprintln(session.output, "sat")
+	} else if result.IsUNSAT() {
+		isFailed := map[sat_solver.CNFLiteral]bool{}
+		for _, literal := range failed {
+			isFailed[literal] = true
+		}
+		session.unsatCore = []string{}
+		isReported := map[string]bool{}
+		for _, literal := range solverAssumptions {
+			if name := names[literal]; isFailed[literal] && len(name) > 0 && !isReported[name] {
+				isReported[name] = true
+				session.unsatCore = append(session.unsatCore, name)
+			}
+		}
+		fmt.Fprintln(session.output, "unsat")
+	} else {
+		fmt.Fprintln(session.output, "unknown")
+	}
+	return nil
+}
+
+func formatModelValue(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+/*
+ * Print the values of the declared constants. Constants missing from the model can have any value.
+ */
+func (session *smtSession) printModel() error {
+	if session.model == nil {
+		return fmt.Errorf("model is available only after check-sat returned sat")
+	}
+	fmt.Fprintln(session.output, "(")
+	for _, name := range session.table.DeclaredNames() {
+		fmt.Fprintf(session.output, "  (define-fun %s () Bool %s)\n", smtlib.QuoteSymbol(name), formatModelValue(session.model[name]))
+	}
+	fmt.Fprintln(session.output, ")")
+	return nil
+}
+
+func (session *smtSession) printValues(terms []*smtlib.SExpr) error {
+	if session.model == nil {
+		return fmt.Errorf("values are available only after check-sat returned sat")
+	}
+	// Constants missing from the model are false, the same as in get-model
+	model := map[string]bool{}
+	for _, name := range session.table.DeclaredNames() {
+		model[name] = session.model[name]
+	}
+	values := make([]string, len(terms))
+	for i, term := range terms {
+		err, formula := session.table.TranslateTerm(term)
+		if err != nil {
+			return err
+		}
+		value := formula.EvaluatePartial(model) == sat_solver.MODEL_VALUE_TRUE
+		values[i] = fmt.Sprintf("(%s %s)", term.String(), formatModelValue(value))
+	}
+	fmt.Fprintf(session.output, "(%s)\n", strings.Join(values, " "))
+	return nil
+}
@@ -40,4 +40,27 @@ func WrapError(err error, fmtString string, vars... interface{}) error {
 		}
 	}
 	return err
-}
\ No newline at end of file
+}
+/**
+ * Error returned when the assignment found by the solver does not satisfy the formula as it was loaded.
+ */
+type ModelCheckError struct {
+	// Value of the loaded formula for the assignment (undefined if the assignment misses some variables)
+	Value     ModelValue
+	// Constraint that is not satisfied by the assignment or an empty string if it's not known
+	Violation string
+}
+
+func NewModelCheckError(value ModelValue, violation string) error {
+	return &ModelCheckError{
+		Value:     value,
+		Violation: violation,
+	}
+}
+
+func (err *ModelCheckError) Error() string {
+	if len(err.Violation) > 0 {
+		return fmt.Sprintf("The assignment found by the solver is not a model of the input formula (the formula is %s): %s", err.Value.String(), err.Violation)
+	}
+	return fmt.Sprintf("The assignment found by the solver is not a model of the input formula (the formula is %s).", err.Value.String())
+}
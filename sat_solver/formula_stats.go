@@ -8,6 +8,9 @@ import (
 type SATFormulaStatistics struct {
 	variableCount int64
 	clauseCount int64
+	xorCount int64
+	cardinalityCount int64
+	softClauseCount int64
 	clauseLenSum int64
 	clauseDepth int64
 	clauseComplexity int64
@@ -25,6 +28,16 @@ func (stats SATFormulaStatistics) String() string {
 	if !stats.isCNF {
 		return fmt.Sprintf("scoreNWF=%.0f, depth=%d, complexity=%d", stats.Score(), stats.clauseDepth, stats.clauseComplexity)
 	}
-	return fmt.Sprintf("scoreCNF=%.0f, #clauses=%d, #vars=%d, avg(|clause|)=%.2f",
-		stats.Score(), stats.clauseCount, stats.variableCount, float64(stats.clauseLenSum) / float64(stats.clauseCount) )
+	constraints := ""
+	if stats.xorCount > 0 {
+		constraints += fmt.Sprintf(", #xors=%d", stats.xorCount)
+	}
+	if stats.cardinalityCount > 0 {
+		constraints += fmt.Sprintf(", #cardinalities=%d", stats.cardinalityCount)
+	}
+	if stats.softClauseCount > 0 {
+		constraints += fmt.Sprintf(", #soft=%d", stats.softClauseCount)
+	}
+	return fmt.Sprintf("scoreCNF=%.0f, #clauses=%d%s, #vars=%d, avg(|clause|)=%.2f",
+		stats.Score(), stats.clauseCount, constraints, stats.variableCount, float64(stats.clauseLenSum) / float64(stats.clauseCount) )
 }
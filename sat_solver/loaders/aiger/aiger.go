@@ -0,0 +1,86 @@
+package aiger
+
+/**
+ * Loader of the circuits in the AIGER format (ASCII "aag" and binary "aig" files).
+ *
+ * The loader handles the combinational circuits (without latches). The formula is satisfiable if some property
+ * (bad state or output if there are no bad states) can be true while all the invariant constraints hold.
+ * The AND gates are translated with the Tseitin transformation (see Encoder) and the inputs are the founder
+ * variables, named by the symbol table or "i<index>" if the input has no name.
+ *
+ * The sequential circuits are checked with the bounded model checking (see core.RunBMC).
+ * For the format description please see: http://fmv.jku.at/aiger/FORMAT.aiger
+ */
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+)
+
+type AIGERLoaderFactory struct {}
+
+type AIGERLoader struct {}
+
+func (hlf *AIGERLoaderFactory) CreateLoader(context *sat_solver.SATContext) solver.Loader {
+	return AIGERLoader{}
+}
+
+func (hlf *AIGERLoaderFactory) GetName() string {
+	return "aiger"
+}
+
+/**
+ * Name of the input used for the variables of the formulas.
+ */
+func (aig *AIG) InputName(index int) string {
+	if len(aig.InputNames[index]) > 0 {
+		return aig.InputNames[index]
+	}
+	return fmt.Sprintf("i%d", index)
+}
+
+func (loader AIGERLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
+	err, aig := ParseAIG(inputFormula)
+	if err != nil {
+		return err, nil
+	}
+	if !aig.IsCombinational() {
+		return fmt.Errorf("The circuit has %d latches. Sequential circuits can be checked only with the bounded model checking (--bmc).", len(aig.Latches)), nil
+	}
+	if len(aig.Properties()) == 0 {
+		return fmt.Errorf("The circuit does not have any outputs or bad state properties."), nil
+	}
+
+	vars := sat_solver.NewSATVariableMapping()
+	cnf := &sat_solver.CNFFormula{
+		Variables: []sat_solver.CNFClause{},
+	}
+	encoder := NewEncoder(func() sat_solver.CNFLiteral {
+		_, v := vars.Fresh()
+		return v
+	}, func(clause sat_solver.CNFClause) {
+		cnf.Variables = append(cnf.Variables, clause)
+	})
+
+	inputs := make([]sat_solver.CNFLiteral, len(aig.Inputs))
+	for i := range aig.Inputs {
+		inputs[i] = vars.Get(aig.InputName(i))
+	}
+	frame := encoder.EncodeFrame(aig, inputs, nil)
+	for _, constraint := range aig.Constraints {
+		cnf.Variables = append(cnf.Variables, sat_solver.CNFClause{ frame.Literal(constraint) })
+	}
+	properties := sat_solver.CNFClause{}
+	for _, property := range aig.Properties() {
+		properties = append(properties, frame.Literal(property))
+	}
+	cnf.Variables = append(cnf.Variables, properties)
+	return nil, sat_solver.NewSATFormula(cnf, vars, nil)
+}
+
+func init() {
+	solver.RegisterLoaderFactory(&AIGERLoaderFactory{})
+}
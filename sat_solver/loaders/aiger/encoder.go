@@ -0,0 +1,147 @@
+package aiger
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+/**
+ * Tseitin encoding of the AND gates into CNF.
+ *
+ * Gates are hashed structurally: the gates with the same (encoded) inputs get the same variable, also when
+ * they come from different time frames of the unrolled circuit. The constant inputs are propagated,
+ * so the gates with the constant values do not create any clauses.
+ */
+type Encoder struct {
+	newVariable func() sat_solver.CNFLiteral
+	addClause   func(clause sat_solver.CNFClause)
+	// Literal that is always true (0 until it's needed)
+	trueLiteral sat_solver.CNFLiteral
+	gates       map[[2]sat_solver.CNFLiteral]sat_solver.CNFLiteral
+}
+
+func NewEncoder(newVariable func() sat_solver.CNFLiteral, addClause func(clause sat_solver.CNFClause)) *Encoder {
+	return &Encoder{
+		newVariable: newVariable,
+		addClause:   addClause,
+		gates:       map[[2]sat_solver.CNFLiteral]sat_solver.CNFLiteral{},
+	}
+}
+
+/**
+ * Get the literal that is always true. It's created with the unit clause when it's used for the first time.
+ */
+func (encoder *Encoder) True() sat_solver.CNFLiteral {
+	if encoder.trueLiteral == 0 {
+		encoder.trueLiteral = encoder.newVariable()
+		encoder.addClause(sat_solver.CNFClause{ encoder.trueLiteral })
+	}
+	return encoder.trueLiteral
+}
+
+/**
+ * Get the literal equivalent to (a and b).
+ */
+func (encoder *Encoder) And(a sat_solver.CNFLiteral, b sat_solver.CNFLiteral) sat_solver.CNFLiteral {
+	if a == b {
+		return a
+	} else if a == -b {
+		return -encoder.True()
+	}
+	if encoder.trueLiteral != 0 {
+		switch {
+		case a == encoder.trueLiteral:
+			return b
+		case b == encoder.trueLiteral:
+			return a
+		case a == -encoder.trueLiteral || b == -encoder.trueLiteral:
+			return -encoder.trueLiteral
+		}
+	}
+	if a > b {
+		a, b = b, a
+	}
+	key := [2]sat_solver.CNFLiteral{ a, b }
+	if x, ok := encoder.gates[key]; ok {
+		return x
+	}
+	x := encoder.newVariable()
+	encoder.addClause(sat_solver.CNFClause{ -x, a })
+	encoder.addClause(sat_solver.CNFClause{ -x, b })
+	encoder.addClause(sat_solver.CNFClause{ x, -a, -b })
+	encoder.gates[key] = x
+	return x
+}
+
+/**
+ * Single time frame of the circuit: the literals of all the AIG variables in the frame.
+ */
+type Frame struct {
+	literals []sat_solver.CNFLiteral
+}
+
+/**
+ * Encode the AND gates of the circuit, given the literals of the inputs and latches in this frame.
+ */
+func (encoder *Encoder) EncodeFrame(aig *AIG, inputs []sat_solver.CNFLiteral, latches []sat_solver.CNFLiteral) *Frame {
+	frame := &Frame{
+		literals: make([]sat_solver.CNFLiteral, aig.MaxVar + 1),
+	}
+	frame.literals[0] = -encoder.True()
+	for i, input := range aig.Inputs {
+		frame.literals[input / 2] = inputs[i]
+	}
+	for i, latch := range aig.Latches {
+		frame.literals[latch.Literal / 2] = latches[i]
+	}
+	for _, gate := range aig.Ands {
+		frame.literals[gate.LHS / 2] = encoder.And(frame.Literal(gate.RHS0), frame.Literal(gate.RHS1))
+	}
+	return frame
+}
+
+/**
+ * Get the CNF literal of the AIGER literal.
+ */
+func (frame *Frame) Literal(literal uint) sat_solver.CNFLiteral {
+	if literal & 1 == 1 {
+		return -frame.literals[literal / 2]
+	}
+	return frame.literals[literal / 2]
+}
+
+/**
+ * Simulate the circuit from the initial values of the latches with the values of the inputs in each step.
+ * Returns the values of the properties (see Properties()) in the last step and whether the invariant
+ * constraints were satisfied in all the steps.
+ */
+func (aig *AIG) Simulate(latches []bool, inputs [][]bool) ([]bool, bool) {
+	values := make([]bool, aig.MaxVar + 1)
+	valueOf := func(literal uint) bool {
+		return values[literal / 2] != (literal & 1 == 1)
+	}
+	state := append([]bool{}, latches...)
+	constraintsHold := true
+	properties := aig.Properties()
+	result := make([]bool, len(properties))
+	for _, step := range inputs {
+		for i, input := range aig.Inputs {
+			values[input / 2] = step[i]
+		}
+		for i, latch := range aig.Latches {
+			values[latch.Literal / 2] = state[i]
+		}
+		for _, gate := range aig.Ands {
+			values[gate.LHS / 2] = valueOf(gate.RHS0) && valueOf(gate.RHS1)
+		}
+		for _, constraint := range aig.Constraints {
+			constraintsHold = constraintsHold && valueOf(constraint)
+		}
+		for i, property := range properties {
+			result[i] = valueOf(property)
+		}
+		for i, latch := range aig.Latches {
+			state[i] = valueOf(latch.Next)
+		}
+	}
+	return result, constraintsHold
+}
@@ -0,0 +1,408 @@
+package aiger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/**
+ * Latch of the sequential circuit. Reset is 0, 1 or the literal of the latch itself if the initial value is not defined.
+ */
+type Latch struct {
+	Literal uint
+	Next    uint
+	Reset   uint
+}
+
+type AndGate struct {
+	LHS  uint
+	RHS0 uint
+	RHS1 uint
+}
+
+/**
+ * And-inverter graph loaded from the AIGER file.
+ * Literals are encoded in the AIGER way: 2 * variable + sign, where the variable 0 is the constant false.
+ */
+type AIG struct {
+	MaxVar      uint
+	Inputs      []uint
+	Latches     []Latch
+	Outputs     []uint
+	Bad         []uint
+	Constraints []uint
+	Justice     [][]uint
+	Fairness    []uint
+	// AND gates sorted topologically (the inputs of the gate are defined before the gate)
+	Ands        []AndGate
+	// Names of the inputs from the symbol table (empty if the input has no name)
+	InputNames  []string
+}
+
+/**
+ * Get the checked properties: the bad state properties or the outputs if there are none (as in AIGER 1.0).
+ */
+func (aig *AIG) Properties() []uint {
+	if len(aig.Bad) > 0 {
+		return aig.Bad
+	}
+	return aig.Outputs
+}
+
+func (aig *AIG) IsCombinational() bool {
+	return len(aig.Latches) == 0
+}
+
+/*
+ * Line-based reader of the AIGER sections. The AND gates of the binary format are read byte by byte.
+ */
+type aigerReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (r *aigerReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("AIGER line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
+
+/*
+ * Read the next line without the new line character. Returns io.EOF if there are no more lines.
+ */
+func (r *aigerReader) readLine() (error, string) {
+	line, err := r.reader.ReadString('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return err, ""
+	}
+	r.line++
+	return nil, strings.TrimRight(line, "\r\n")
+}
+
+/*
+ * Read the line with the numbers. The number of the numbers must be between minCount and maxCount.
+ */
+func (r *aigerReader) readNumbers(section string, minCount int, maxCount int) (error, []uint) {
+	err, line := r.readLine()
+	if err == io.EOF {
+		return fmt.Errorf("AIGER: unexpected end of the input in the %s section", section), nil
+	} else if err != nil {
+		return err, nil
+	}
+	fields := strings.Fields(line)
+	if len(fields) < minCount || len(fields) > maxCount {
+		return r.errorf("invalid %s line '%s'", section, line), nil
+	}
+	numbers := make([]uint, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return r.errorf("invalid number '%s' in the %s section", field, section), nil
+		}
+		numbers[i] = uint(value)
+	}
+	return nil, numbers
+}
+
+/*
+ * Read the unsigned number encoded in 7-bit groups (the deltas of the binary AND gates).
+ */
+func (r *aigerReader) readVarint() (uint, error) {
+	value, shift := uint(0), uint(0)
+	for {
+		c, err := r.reader.ReadByte()
+		if err == io.EOF {
+			return 0, fmt.Errorf("AIGER: unexpected end of the input in the binary AND gates")
+		} else if err != nil {
+			return 0, err
+		}
+		if shift > 28 {
+			return 0, fmt.Errorf("AIGER: invalid delta in the binary AND gates")
+		}
+		value |= uint(c & 0x7f) << shift
+		if c & 0x80 == 0 {
+			return value, nil
+		}
+		shift += 7
+	}
+}
+
+/**
+ * Parse the AIGER file in the ASCII ("aag") or the binary ("aig") format, including the AIGER 1.9 sections
+ * (bad states, invariant constraints, justice and fairness properties) and the symbol table.
+ */
+func ParseAIG(input io.Reader) (error, *AIG) {
+	r := &aigerReader{
+		reader: bufio.NewReader(input),
+	}
+	err, header := r.readLine()
+	if err == io.EOF {
+		return fmt.Errorf("AIGER input is empty."), nil
+	} else if err != nil {
+		return err, nil
+	}
+	fields := strings.Fields(header)
+	if len(fields) < 6 || len(fields) > 10 || (fields[0] != "aag" && fields[0] != "aig") {
+		return r.errorf("expected the 'aag M I L O A' or 'aig M I L O A' header, found '%s'", header), nil
+	}
+	isBinary := fields[0] == "aig"
+	counts := make([]uint, 9)
+	for i, field := range fields[1:] {
+		value, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return r.errorf("invalid number '%s' in the header", field), nil
+		}
+		counts[i] = uint(value)
+	}
+	maxVar, inputCount, latchCount, outputCount, andCount := counts[0], counts[1], counts[2], counts[3], counts[4]
+	if isBinary && maxVar != inputCount + latchCount + andCount {
+		return r.errorf("M must be equal to I + L + A in the binary format"), nil
+	}
+	aig := &AIG{
+		MaxVar:     maxVar,
+		InputNames: make([]string, inputCount),
+	}
+
+	readLiterals := func(section string, count uint) (error, []uint) {
+		literals := make([]uint, 0, count)
+		for i := uint(0); i < count; i++ {
+			err, numbers := r.readNumbers(section, 1, 1)
+			if err != nil {
+				return err, nil
+			}
+			literals = append(literals, numbers[0])
+		}
+		return nil, literals
+	}
+
+	if isBinary {
+		for i := uint(0); i < inputCount; i++ {
+			aig.Inputs = append(aig.Inputs, 2 * (i + 1))
+		}
+	} else {
+		err, aig.Inputs = readLiterals("input", inputCount)
+		if err != nil {
+			return err, nil
+		}
+	}
+	for i := uint(0); i < latchCount; i++ {
+		latch := Latch{}
+		if isBinary {
+			err, numbers := r.readNumbers("latch", 1, 2)
+			if err != nil {
+				return err, nil
+			}
+			latch.Literal = 2 * (inputCount + i + 1)
+			latch.Next = numbers[0]
+			if len(numbers) > 1 {
+				latch.Reset = numbers[1]
+			}
+		} else {
+			err, numbers := r.readNumbers("latch", 2, 3)
+			if err != nil {
+				return err, nil
+			}
+			latch.Literal, latch.Next = numbers[0], numbers[1]
+			if len(numbers) > 2 {
+				latch.Reset = numbers[2]
+			}
+		}
+		if latch.Reset != 0 && latch.Reset != 1 && latch.Reset != latch.Literal {
+			return r.errorf("reset value of the latch must be 0, 1 or the literal of the latch"), nil
+		}
+		aig.Latches = append(aig.Latches, latch)
+	}
+	if err, aig.Outputs = readLiterals("output", outputCount); err != nil {
+		return err, nil
+	}
+	if err, aig.Bad = readLiterals("bad state", counts[5]); err != nil {
+		return err, nil
+	}
+	if err, aig.Constraints = readLiterals("constraint", counts[6]); err != nil {
+		return err, nil
+	}
+	err, justiceSizes := readLiterals("justice", counts[7])
+	if err != nil {
+		return err, nil
+	}
+	for _, size := range justiceSizes {
+		err, literals := readLiterals("justice", size)
+		if err != nil {
+			return err, nil
+		}
+		aig.Justice = append(aig.Justice, literals)
+	}
+	if err, aig.Fairness = readLiterals("fairness", counts[8]); err != nil {
+		return err, nil
+	}
+
+	for i := uint(0); i < andCount; i++ {
+		gate := AndGate{}
+		if isBinary {
+			gate.LHS = 2 * (inputCount + latchCount + i + 1)
+			delta0, err := r.readVarint()
+			if err != nil {
+				return err, nil
+			}
+			delta1, err := r.readVarint()
+			if err != nil {
+				return err, nil
+			}
+			if delta0 > gate.LHS || delta1 > gate.LHS - delta0 {
+				return fmt.Errorf("AIGER: invalid delta of the binary AND gate %d", gate.LHS), nil
+			}
+			gate.RHS0 = gate.LHS - delta0
+			gate.RHS1 = gate.RHS0 - delta1
+		} else {
+			err, numbers := r.readNumbers("AND gate", 3, 3)
+			if err != nil {
+				return err, nil
+			}
+			gate.LHS, gate.RHS0, gate.RHS1 = numbers[0], numbers[1], numbers[2]
+		}
+		aig.Ands = append(aig.Ands, gate)
+	}
+
+	if err := r.readSymbols(aig); err != nil {
+		return err, nil
+	}
+	if err := aig.validate(); err != nil {
+		return err, nil
+	}
+	return nil, aig
+}
+
+/*
+ * Read the symbol table. It ends at the end of the input or with the comment section ("c" line).
+ */
+func (r *aigerReader) readSymbols(aig *AIG) error {
+	for {
+		err, line := r.readLine()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if line == "c" {
+			return nil
+		}
+		if len(line) == 0 {
+			continue
+		}
+		separator := strings.IndexByte(line, ' ')
+		if separator < 2 || strings.IndexByte("ilobcjf", line[0]) < 0 {
+			return r.errorf("invalid symbol '%s'", line)
+		}
+		position, err := strconv.Atoi(line[1:separator])
+		if err != nil {
+			return r.errorf("invalid symbol '%s'", line)
+		}
+		if line[0] == 'i' {
+			if position < 0 || position >= len(aig.InputNames) {
+				return r.errorf("symbol of the input %d that does not exist", position)
+			}
+			aig.InputNames[position] = line[separator + 1:]
+		}
+	}
+}
+
+/*
+ * Check the literals and sort the AND gates topologically. Returns an error if the gates contain a cycle.
+ */
+func (aig *AIG) validate() error {
+	// Gates defining the variables (-1 for the inputs and latches)
+	definitions := make([]int, aig.MaxVar + 1)
+	for i := range definitions {
+		definitions[i] = -2
+	}
+	define := func(literal uint, definition int) error {
+		if literal & 1 == 1 || literal < 2 || literal / 2 > aig.MaxVar {
+			return fmt.Errorf("AIGER: invalid literal %d of the input, latch or AND gate", literal)
+		}
+		if definitions[literal / 2] != -2 {
+			return fmt.Errorf("AIGER: variable %d is defined twice", literal / 2)
+		}
+		definitions[literal / 2] = definition
+		return nil
+	}
+	for _, input := range aig.Inputs {
+		if err := define(input, -1); err != nil {
+			return err
+		}
+	}
+	for _, latch := range aig.Latches {
+		if err := define(latch.Literal, -1); err != nil {
+			return err
+		}
+	}
+	for i, gate := range aig.Ands {
+		if err := define(gate.LHS, i); err != nil {
+			return err
+		}
+	}
+
+	used := []uint{}
+	for _, latch := range aig.Latches {
+		used = append(used, latch.Next)
+	}
+	used = append(used, aig.Outputs...)
+	used = append(used, aig.Bad...)
+	used = append(used, aig.Constraints...)
+	used = append(used, aig.Fairness...)
+	for _, justice := range aig.Justice {
+		used = append(used, justice...)
+	}
+	for _, gate := range aig.Ands {
+		used = append(used, gate.RHS0, gate.RHS1)
+	}
+	for _, literal := range used {
+		if literal / 2 > aig.MaxVar || (literal / 2 > 0 && definitions[literal / 2] == -2) {
+			return fmt.Errorf("AIGER: literal %d is not defined", literal)
+		}
+	}
+
+	// Iterative depth-first search, so long chains of gates do not cause deep recursion
+	const (
+		UNVISITED = 0
+		VISITING  = 1
+		VISITED   = 2
+	)
+	state := make([]int8, len(aig.Ands))
+	sorted := make([]AndGate, 0, len(aig.Ands))
+	for root := range aig.Ands {
+		if state[root] != UNVISITED {
+			continue
+		}
+		stack := []int{ root }
+		state[root] = VISITING
+		for len(stack) > 0 {
+			top := stack[len(stack) - 1]
+			gate := aig.Ands[top]
+			isReady := true
+			for _, rhs := range []uint{ gate.RHS0, gate.RHS1 } {
+				child := definitions[rhs / 2]
+				if rhs < 2 || child < 0 || state[child] == VISITED {
+					continue
+				}
+				if state[child] == VISITING {
+					return fmt.Errorf("AIGER: AND gate %d is on a combinational cycle", gate.LHS)
+				}
+				state[child] = VISITING
+				stack = append(stack, child)
+				isReady = false
+				break
+			}
+			if isReady {
+				state[top] = VISITED
+				sorted = append(sorted, gate)
+				stack = stack[:len(stack) - 1]
+			}
+		}
+	}
+	aig.Ands = sorted
+	return nil
+}
@@ -0,0 +1,422 @@
+package bench
+
+/**
+ * Loader of the ISCAS-85/89 netlists in the BENCH format, for example:
+ *
+ *   # comments start with #
+ *   INPUT(G1)
+ *   INPUT(G2)
+ *   OUTPUT(G5)
+ *   G3 = NAND(G1, G2)
+ *   G4 = DFF(G3)
+ *   G5 = XOR(G3, G4)
+ *
+ * Supported gates are AND, NAND, OR, NOR, XOR, XNOR, NOT, BUFF (or BUF) and DFF. Gates can be defined in any order.
+ * The outputs of the flip-flops (DFF) are treated as free variables, so the sequential circuits are cut into
+ * the combinational ones. The loaded formula is satisfiable if some output can be true.
+ *
+ * The gates are translated into the AST with the structural hashing (see GateTable). The signals used by many
+ * gates are shared nodes, so they are encoded only once by the Tseytin transformation.
+ */
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+)
+
+type BenchLoaderFactory struct {}
+
+type BenchLoader struct {}
+
+func (hlf *BenchLoaderFactory) CreateLoader(context *sat_solver.SATContext) solver.Loader {
+	return BenchLoader{}
+}
+
+func (hlf *BenchLoaderFactory) GetName() string {
+	return "bench"
+}
+
+// Number of the arguments of the gates (-1 if any positive number is allowed)
+var GATE_ARITY = map[string]int{
+	"AND": -1, "NAND": -1, "OR": -1, "NOR": -1, "XOR": -1, "XNOR": -1, "NOT": 1, "BUFF": 1, "BUF": 1, "DFF": 1,
+}
+
+type Gate struct {
+	Output string
+	Type   string
+	Args   []string
+	// Line of the definition in the input
+	Line   int
+}
+
+/**
+ * Netlist loaded from the BENCH file.
+ */
+type Netlist struct {
+	Inputs    []string
+	Outputs   []string
+	// Outputs of the flip-flops in the order of the definitions
+	FlipFlops []string
+	// Gates by the names of their outputs
+	Gates     map[string]*Gate
+}
+
+func lineError(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("BENCH line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+/*
+ * Parse "NAME(arg, ...)" and return the name and the arguments.
+ */
+func parseCall(text string, line int) (error, string, []string) {
+	open := strings.IndexByte(text, '(')
+	if open < 0 || !strings.HasSuffix(text, ")") {
+		return lineError(line, "expected NAME(arguments), found '%s'", text), "", nil
+	}
+	name := strings.TrimSpace(text[:open])
+	args := []string{}
+	for _, arg := range strings.Split(text[open + 1:len(text) - 1], ",") {
+		arg = strings.TrimSpace(arg)
+		if len(arg) == 0 || strings.ContainsAny(arg, "()= \t") {
+			return lineError(line, "invalid argument '%s'", arg), "", nil
+		}
+		args = append(args, arg)
+	}
+	return nil, name, args
+}
+
+/**
+ * Parse the BENCH netlist and check that all the used signals are defined exactly once.
+ */
+func ParseNetlist(input io.Reader) (error, *Netlist) {
+	netlist := &Netlist{
+		Gates: map[string]*Gate{},
+	}
+	// Lines of the definitions of the signals (inputs and gates)
+	definitions := map[string]int{}
+	define := func(name string, line int) error {
+		if previous, ok := definitions[name]; ok {
+			return lineError(line, "signal %s is already defined in the line %d", name, previous)
+		}
+		definitions[name] = line
+		return nil
+	}
+	outputLines := map[string]int{}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if comment := strings.IndexByte(line, '#'); comment >= 0 {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		if assignment := strings.IndexByte(line, '='); assignment >= 0 {
+			output := strings.TrimSpace(line[:assignment])
+			if len(output) == 0 || strings.ContainsAny(output, "(), \t") {
+				return lineError(lineNumber, "invalid signal name '%s'", output), nil
+			}
+			err, gateType, args := parseCall(strings.TrimSpace(line[assignment + 1:]), lineNumber)
+			if err != nil {
+				return err, nil
+			}
+			gateType = strings.ToUpper(gateType)
+			arity, ok := GATE_ARITY[gateType]
+			if !ok {
+				return lineError(lineNumber, "unsupported gate type '%s'", gateType), nil
+			}
+			if arity >= 0 && len(args) != arity {
+				return lineError(lineNumber, "gate %s expects %d arguments, got %d", gateType, arity, len(args)), nil
+			}
+			if err := define(output, lineNumber); err != nil {
+				return err, nil
+			}
+			netlist.Gates[output] = &Gate{
+				Output: output,
+				Type:   gateType,
+				Args:   args,
+				Line:   lineNumber,
+			}
+			if gateType == "DFF" {
+				netlist.FlipFlops = append(netlist.FlipFlops, output)
+			}
+			continue
+		}
+
+		err, keyword, args := parseCall(line, lineNumber)
+		if err != nil {
+			return err, nil
+		}
+		if len(args) != 1 {
+			return lineError(lineNumber, "%s expects exactly one signal", keyword), nil
+		}
+		switch strings.ToUpper(keyword) {
+		case "INPUT":
+			if err := define(args[0], lineNumber); err != nil {
+				return err, nil
+			}
+			netlist.Inputs = append(netlist.Inputs, args[0])
+		case "OUTPUT":
+			netlist.Outputs = append(netlist.Outputs, args[0])
+			outputLines[args[0]] = lineNumber
+		default:
+			return lineError(lineNumber, "expected INPUT, OUTPUT or a gate, found '%s'", keyword), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err, nil
+	}
+
+	for _, output := range netlist.Outputs {
+		if _, ok := definitions[output]; !ok {
+			return lineError(outputLines[output], "output %s is not defined", output), nil
+		}
+	}
+	for _, gate := range netlist.Gates {
+		for _, arg := range gate.Args {
+			if _, ok := definitions[arg]; !ok {
+				return lineError(gate.Line, "signal %s is not defined", arg), nil
+			}
+		}
+	}
+	return nil, netlist
+}
+
+/**
+ * Structural hashing of the gates. The gates are built from the AND, NOT and XOR nodes (OR uses De Morgan's law)
+ * and each gate with the same inputs (in any order) is created only once. So the same logic gets the same node,
+ * also when it comes from two different netlists, which makes the equivalence checking much easier.
+ */
+type GateTable struct {
+	// Unique numbers of the nodes, used to order the inputs of the gates
+	ids       map[*sat_solver.Formula]int
+	variables map[string]*sat_solver.Formula
+	nots      map[*sat_solver.Formula]*sat_solver.Formula
+	gates     map[gateKey]*sat_solver.Formula
+	falseNode *sat_solver.Formula
+}
+
+type gateKey struct {
+	isXor bool
+	first int
+	second int
+}
+
+func NewGateTable() *GateTable {
+	return &GateTable{
+		ids:       map[*sat_solver.Formula]int{},
+		variables: map[string]*sat_solver.Formula{},
+		nots:      map[*sat_solver.Formula]*sat_solver.Formula{},
+		gates:     map[gateKey]*sat_solver.Formula{},
+		falseNode: sat_solver.MakeBoolConstant(false),
+	}
+}
+
+func (table *GateTable) id(formula *sat_solver.Formula) int {
+	if id, ok := table.ids[formula]; ok {
+		return id
+	}
+	id := len(table.ids)
+	table.ids[formula] = id
+	return id
+}
+
+/**
+ * Get the variable of the signal. Names are quoted in the same way as in the haskell-like format.
+ */
+func (table *GateTable) Var(signal string) *sat_solver.Formula {
+	if formula, ok := table.variables[signal]; ok {
+		return formula
+	}
+	formula := sat_solver.MakeVar(fmt.Sprintf("\"%s\"", signal))
+	table.variables[signal] = formula
+	return formula
+}
+
+func (table *GateTable) Not(formula *sat_solver.Formula) *sat_solver.Formula {
+	if formula.Not != nil {
+		return formula.Not.Formula
+	}
+	if negation, ok := table.nots[formula]; ok {
+		return negation
+	}
+	negation := sat_solver.MakeNot(formula)
+	table.nots[formula] = negation
+	return negation
+}
+
+func (table *GateTable) And(a *sat_solver.Formula, b *sat_solver.Formula) *sat_solver.Formula {
+	if a == b {
+		return a
+	} else if a == table.Not(b) {
+		return table.falseNode
+	}
+	return table.gate(false, a, b)
+}
+
+func (table *GateTable) Or(a *sat_solver.Formula, b *sat_solver.Formula) *sat_solver.Formula {
+	return table.Not(table.And(table.Not(a), table.Not(b)))
+}
+
+/**
+ * Get the (a xor b) gate. The negations of the inputs are moved outside, so (not a) xor b is the same as not (a xor b).
+ */
+func (table *GateTable) Xor(a *sat_solver.Formula, b *sat_solver.Formula) *sat_solver.Formula {
+	isNegated := false
+	if a.Not != nil {
+		a, isNegated = a.Not.Formula, !isNegated
+	}
+	if b.Not != nil {
+		b, isNegated = b.Not.Formula, !isNegated
+	}
+	result := table.falseNode
+	if a != b {
+		result = table.gate(true, a, b)
+	}
+	if isNegated {
+		return table.Not(result)
+	}
+	return result
+}
+
+func (table *GateTable) gate(isXor bool, a *sat_solver.Formula, b *sat_solver.Formula) *sat_solver.Formula {
+	if table.id(a) > table.id(b) {
+		a, b = b, a
+	}
+	key := gateKey{ isXor: isXor, first: table.id(a), second: table.id(b) }
+	if formula, ok := table.gates[key]; ok {
+		return formula
+	}
+	formula := sat_solver.MakeAnd(a, b)
+	if isXor {
+		formula = sat_solver.MakeXor(a, b)
+	}
+	table.gates[key] = formula
+	return formula
+}
+
+/*
+ * Join the arguments into the balanced tree. The arguments are sorted, so their order does not matter.
+ */
+func (table *GateTable) join(args []*sat_solver.Formula, join func(*sat_solver.Formula, *sat_solver.Formula) *sat_solver.Formula) *sat_solver.Formula {
+	sort.Slice(args, func(i, j int) bool {
+		return table.id(args[i]) < table.id(args[j])
+	})
+	return sat_solver.MakeBalancedTree(args, join, false)
+}
+
+/**
+ * Translation of the netlist into the AST. The inputs and the outputs of the flip-flops are the variables
+ * named by the signals. Builders of many netlists can share the gate table, so the same logic is shared.
+ */
+type Builder struct {
+	netlist  *Netlist
+	table    *GateTable
+	formulas map[string]*sat_solver.Formula
+	// Gates that are being translated (used to detect the combinational cycles)
+	visiting map[string]bool
+}
+
+func NewBuilder(netlist *Netlist, table *GateTable) *Builder {
+	builder := &Builder{
+		netlist:  netlist,
+		table:    table,
+		formulas: map[string]*sat_solver.Formula{},
+		visiting: map[string]bool{},
+	}
+	for _, name := range append(append([]string{}, netlist.Inputs...), netlist.FlipFlops...) {
+		builder.formulas[name] = table.Var(name)
+	}
+	return builder
+}
+
+/**
+ * Get the formula of the signal.
+ */
+func (builder *Builder) Signal(name string) (error, *sat_solver.Formula) {
+	if formula, ok := builder.formulas[name]; ok {
+		return nil, formula
+	}
+	gate := builder.netlist.Gates[name]
+	if builder.visiting[name] {
+		return lineError(gate.Line, "signal %s is on a combinational cycle", name), nil
+	}
+	builder.visiting[name] = true
+	args := make([]*sat_solver.Formula, len(gate.Args))
+	for i, arg := range gate.Args {
+		err, formula := builder.Signal(arg)
+		if err != nil {
+			return err, nil
+		}
+		args[i] = formula
+	}
+	delete(builder.visiting, name)
+
+	table := builder.table
+	var formula *sat_solver.Formula
+	switch gate.Type {
+	case "AND":
+		formula = table.join(args, table.And)
+	case "NAND":
+		formula = table.Not(table.join(args, table.And))
+	case "OR":
+		formula = table.join(args, table.Or)
+	case "NOR":
+		formula = table.Not(table.join(args, table.Or))
+	case "XOR":
+		formula = table.join(args, table.Xor)
+	case "XNOR":
+		formula = table.Not(table.join(args, table.Xor))
+	case "NOT":
+		formula = table.Not(args[0])
+	default:
+		// Buffers
+		formula = args[0]
+	}
+	builder.formulas[name] = formula
+	return nil, formula
+}
+
+/**
+ * Get the formula of the next state of the flip-flop (the input of the DFF gate).
+ */
+func (builder *Builder) NextState(flipFlop string) (error, *sat_solver.Formula) {
+	return builder.Signal(builder.netlist.Gates[flipFlop].Args[0])
+}
+
+func (loader BenchLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
+	err, netlist := ParseNetlist(inputFormula)
+	if err != nil {
+		return err, nil
+	}
+	if len(netlist.Outputs) == 0 {
+		return fmt.Errorf("The netlist does not have any outputs."), nil
+	}
+	builder := NewBuilder(netlist, NewGateTable())
+	outputs := make([]*sat_solver.Formula, len(netlist.Outputs))
+	for i, output := range netlist.Outputs {
+		err, outputs[i] = builder.Signal(output)
+		if err != nil {
+			return err, nil
+		}
+	}
+	return nil, &sat_solver.Entry{
+		Formula: sat_solver.MakeBalancedTree(outputs, sat_solver.MakeOr, false),
+	}
+}
+
+func init() {
+	solver.RegisterLoaderFactory(&BenchLoaderFactory{})
+}
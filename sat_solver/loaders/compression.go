@@ -0,0 +1,59 @@
+package solver
+
+/**
+ * Transparent decompression of the loaded files.
+ *
+ * The compression format is detected by the magic bytes at the beginning of the input, so the file extension
+ * does not matter and compressed data can also be piped to the standard input:
+ *   - gzip (1f 8b) and bzip2 ("BZh") are decompressed with the standard library
+ *   - xz (fd 37 7a 58 5a 00) is decompressed with github.com/ulikunitz/xz (the standard library has no xz reader)
+ * Any other input is passed without changes.
+ */
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ulikunitz/xz"
+)
+
+var (
+	GZIP_MAGIC  = []byte{ 0x1f, 0x8b }
+	BZIP2_MAGIC = []byte("BZh")
+	XZ_MAGIC    = []byte{ 0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00 }
+)
+
+/**
+ * Wrap the input in a decompressor if it starts with the magic bytes of a supported compression format.
+ * The returned reader must be closed after the loading.
+ */
+func DecompressInput(input io.Reader) (error, io.ReadCloser) {
+	reader := bufio.NewReader(input)
+	// Peek returns the available bytes together with io.EOF for inputs shorter than the magic
+	magic, err := reader.Peek(len(XZ_MAGIC))
+	if err != nil && err != io.EOF {
+		return err, nil
+	}
+	switch {
+	case bytes.HasPrefix(magic, GZIP_MAGIC):
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("Invalid gzip input: %v", err), nil
+		}
+		return nil, gzipReader
+	case bytes.HasPrefix(magic, BZIP2_MAGIC):
+		return nil, ioutil.NopCloser(bzip2.NewReader(reader))
+	case bytes.HasPrefix(magic, XZ_MAGIC):
+		xzReader, err := xz.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("Invalid xz input: %v", err), nil
+		}
+		return nil, ioutil.NopCloser(xzReader)
+	}
+	return nil, ioutil.NopCloser(reader)
+}
@@ -1,16 +1,34 @@
 package dimacs_cnf
 
+/**
+ * Loader of the DIMACS CNF files.
+ *
+ * The file starts with the "p cnf <variables> <clauses>" header followed by the clauses. Each clause is a list
+ * of non-zero literals terminated with 0. Literals are separated by any whitespace, so a clause can span many
+ * lines and many clauses can be written in one line. Lines starting with "c" are comments.
+ * The "%" token (used by the SATLIB benchmarks) ends the formula and everything after it is ignored.
+ * XOR constraints are written in the CryptoMiniSat style: "x1 -2 3 0" means that 1 xor -2 xor 3 is true.
+ *
+ * The header is checked: variables must not exceed the declared number of variables. Many benchmark files
+ * declare a wrong number of clauses (including XORs), so a different number of clauses is only reported
+ * as a warning, unless the strict headers are enabled (EnableStrictHeaders).
+ * Errors contain the line and column of the problem.
+ * The input is read as a stream, compressed files are handled by the loader registry (see DecompressInput).
+ */
+
 import (
-	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
-	"strings"
 
 	"github.com/styczynski/go-sat-solver/sat_solver"
 	solver "github.com/styczynski/go-sat-solver/sat_solver/loaders"
 )
 
+// Clause capacity reserved up front is limited, so a broken header does not allocate huge amounts of memory
+const MAX_PREALLOCATED_CLAUSES = 1024*1024
+
 type CNFLoaderFactory struct {}
 
 type CNFLoader struct {}
@@ -23,69 +41,193 @@ func (hlf *CNFLoaderFactory) GetName() string {
 	return "cnf"
 }
 
+/*
+ * Parse the integer token. The second value is false if the token is not a number or it's out of the int32 range.
+ */
+func parseInteger(token []byte) (int, bool) {
+	sign := 1
+	if len(token) > 0 && (token[0] == '-' || token[0] == '+') {
+		if token[0] == '-' {
+			sign = -1
+		}
+		token = token[1:]
+	}
+	if len(token) == 0 {
+		return 0, false
+	}
+	value := 0
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		value = value * 10 + int(c - '0')
+		if value > math.MaxInt32 {
+			return 0, false
+		}
+	}
+	return sign * value, true
+}
+
+type dimacsHeader struct {
+	varCount    int
+	clauseCount int
+	// Position of the header in the input
+	line        int
+	column      int
+}
+
+/*
+ * Read the "p cnf <variables> <clauses>" header. It must be the first line that is not a comment.
+ */
+func readHeader(tokenizer *dimacsTokenizer) (error, dimacsHeader) {
+	err, ok := tokenizer.next()
+	if err != nil {
+		return err, dimacsHeader{}
+	}
+	if !ok {
+		return fmt.Errorf("CNF input does not contain the 'p cnf <variables> <clauses>' header."), dimacsHeader{}
+	}
+	if string(tokenizer.token) != "p" {
+		return tokenizer.errorf("expected the 'p cnf <variables> <clauses>' header, found '%s'", tokenizer.token), dimacsHeader{}
+	}
+	headerLine, headerColumn := tokenizer.tokenLine, tokenizer.tokenColumn
+
+	fields := [3]int{}
+	for i := range fields {
+		err, ok := tokenizer.next()
+		if err != nil {
+			return err, dimacsHeader{}
+		}
+		if !ok || tokenizer.tokenLine != headerLine {
+			return tokenizer.errorAtf(headerLine, headerColumn, "incomplete header, expected 'p cnf <variables> <clauses>'"), dimacsHeader{}
+		}
+		if i == 0 {
+			if string(tokenizer.token) != "cnf" {
+				return tokenizer.errorf("unsupported format '%s' in the header, expected 'cnf'", tokenizer.token), dimacsHeader{}
+			}
+			continue
+		}
+		value, ok := parseInteger(tokenizer.token)
+		if !ok || value < 0 {
+			return tokenizer.errorf("invalid number '%s' in the header", tokenizer.token), dimacsHeader{}
+		}
+		fields[i] = value
+	}
+	return nil, dimacsHeader{
+		varCount:    fields[1],
+		clauseCount: fields[2],
+		line:        headerLine,
+		column:      headerColumn,
+	}
+}
+
 func (loader CNFLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
 	vars := sat_solver.NewSATVariableMapping()
 	cnf := &sat_solver.CNFFormula{
 		Variables: []sat_solver.CNFClause{},
 	}
 
-	scanner := bufio.NewScanner(inputFormula)
-	varCount := 0
-	clauseCount := 0
-	firstLineOk := false
-	clauseLineNo := 0
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) > 0 {
-			if line[0] == 'c' {
-				// Ignore
-			} else {
-				if !firstLineOk {
-					_, err := fmt.Sscanf(line, "p cnf %d %d\n", &varCount, &clauseCount)
-					if err != nil {
-						return err, nil
-					}
-					firstLineOk = true
-					cnf.Variables = make([]sat_solver.CNFClause, clauseCount)
-				} else {
-					varTokens := strings.Split(line, " ")
-					if len(varTokens) == 0 {
-						return fmt.Errorf("Clause line does not contain any numbers."), nil
-					}
-					if varTokens[len(varTokens)-1] != "0" {
-						return fmt.Errorf("Clause line does not end with 0."), nil
-					}
-					clause := make(sat_solver.CNFClause, len(varTokens)-1)
-					for i, token := range varTokens[:1] {
-						literal, err := strconv.Atoi(token)
-						if err != nil {
-							return err, nil
-						}
-						varID := literal
-						if varID < 0 {
-							varID = -varID
-						}
-						varName := fmt.Sprintf("%d", varID)
-						newID := vars.Get(varName)
-						if literal < 0 {
-							newID = -newID
-						}
-						clause[i] = newID
-					}
-					cnf.Variables[clauseLineNo] = clause
-
-					clauseLineNo++
-					if clauseLineNo >= clauseCount {
-						break
-					}
+	tokenizer := newDimacsTokenizer(inputFormula)
+	err, header := readHeader(tokenizer)
+	if err != nil {
+		return err, nil
+	}
+	varCount, clauseCount := header.varCount, header.clauseCount
+	if clauseCount < MAX_PREALLOCATED_CLAUSES {
+		cnf.Variables = make([]sat_solver.CNFClause, 0, clauseCount)
+	}
+
+	// IDs of the loaded variables indexed by their DIMACS numbers (0 if the variable was not seen yet)
+	ids := []sat_solver.CNFLiteral{}
+	clause := sat_solver.CNFClause{}
+	isClauseOpen := false
+	isXor := false
+	clauseLine, clauseColumn := 0, 0
+	loadedClauses := 0
+	isStrict := context.GetConfiguration().EnableStrictHeaders
+	for {
+		err, ok := tokenizer.next()
+		if err != nil {
+			return err, nil
+		}
+		if !ok {
+			break
+		}
+		token := tokenizer.token
+		if string(token) == "%" {
+			break
+		}
+		if string(token) == "p" {
+			return tokenizer.errorf("duplicate header"), nil
+		}
+
+		if !isClauseOpen {
+			if loadedClauses >= clauseCount && isStrict {
+				return tokenizer.errorf("more clauses than %d declared in the header", clauseCount), nil
+			}
+			isClauseOpen = true
+			clauseLine, clauseColumn = tokenizer.tokenLine, tokenizer.tokenColumn
+			isXor = token[0] == 'x'
+			if isXor {
+				token = token[1:]
+				if len(token) == 0 {
+					continue
 				}
 			}
 		}
+
+		literal, ok := parseInteger(token)
+		if !ok {
+			return tokenizer.errorf("invalid literal '%s'", tokenizer.token), nil
+		}
+		if literal == 0 {
+			newClause := make(sat_solver.CNFClause, len(clause))
+			copy(newClause, clause)
+			if isXor {
+				cnf.Xors = append(cnf.Xors, sat_solver.NewXORClause(newClause, true))
+			} else {
+				cnf.Variables = append(cnf.Variables, newClause)
+			}
+			clause = clause[:0]
+			isClauseOpen = false
+			loadedClauses++
+			continue
+		}
+
+		varID := literal
+		if varID < 0 {
+			varID = -varID
+		}
+		if varID > varCount {
+			return tokenizer.errorf("variable %d exceeds the number of variables %d declared in the header", varID, varCount), nil
+		}
+		for varID >= len(ids) {
+			ids = append(ids, 0)
+		}
+		if ids[varID] == 0 {
+			ids[varID] = vars.Get(strconv.Itoa(varID))
+		}
+		if literal < 0 {
+			clause = append(clause, -ids[varID])
+		} else {
+			clause = append(clause, ids[varID])
+		}
 	}
 
+	if isClauseOpen {
+		return tokenizer.errorAtf(clauseLine, clauseColumn, "clause is not terminated with 0"), nil
+	}
+	if loadedClauses != clauseCount {
+		err := tokenizer.errorAtf(header.line, header.column, "found %d clauses, but %d were declared in the header",
+			loadedClauses, clauseCount)
+		if isStrict {
+			return err, nil
+		}
+		context.Trace("cnf", "Warning: %s.", err.Error())
+	}
 	return nil, sat_solver.NewSATFormula(cnf, vars, nil)
 }
 
 func init() {
 	solver.RegisterLoaderFactory(&CNFLoaderFactory{})
-}
\ No newline at end of file
+}
@@ -0,0 +1,128 @@
+package dimacs_cnf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+/*
+ * Streaming tokenizer of the DIMACS files.
+ * Tokens are separated by any whitespace (including new lines), so clauses can span many lines.
+ * Lines starting with "c" (after optional whitespace) are comments and are skipped.
+ * The positions of the tokens (line and column, starting from 1) are tracked for the error messages.
+ */
+type dimacsTokenizer struct {
+	reader      *bufio.Reader
+	line        int
+	column      int
+	// Was only whitespace read since the beginning of the line?
+	isLineStart bool
+	// Text of the last token (valid until the next call to next())
+	token       []byte
+	tokenLine   int
+	tokenColumn int
+}
+
+func newDimacsTokenizer(input io.Reader) *dimacsTokenizer {
+	return &dimacsTokenizer{
+		reader:      bufio.NewReaderSize(input, 1024*1024),
+		line:        1,
+		column:      0,
+		isLineStart: true,
+		token:       make([]byte, 0, 32),
+	}
+}
+
+func isDimacsWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+/*
+ * Read the next byte and update the position.
+ */
+func (tokenizer *dimacsTokenizer) readByte() (byte, error) {
+	c, err := tokenizer.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if c == '\n' {
+		tokenizer.line++
+		tokenizer.column = 0
+		tokenizer.isLineStart = true
+	} else {
+		tokenizer.column++
+	}
+	return c, nil
+}
+
+/*
+ * Skip the rest of the current line.
+ */
+func (tokenizer *dimacsTokenizer) skipLine() error {
+	for {
+		c, err := tokenizer.readByte()
+		if err != nil {
+			return err
+		}
+		if c == '\n' {
+			return nil
+		}
+	}
+}
+
+/**
+ * Read the next token. Returns false when the end of the input was reached.
+ */
+func (tokenizer *dimacsTokenizer) next() (error, bool) {
+	for {
+		c, err := tokenizer.readByte()
+		if err == io.EOF {
+			return nil, false
+		} else if err != nil {
+			return tokenizer.errorAtf(tokenizer.line, tokenizer.column, "%v", err), false
+		}
+		if isDimacsWhitespace(c) {
+			continue
+		}
+		if c == 'c' && tokenizer.isLineStart {
+			if err := tokenizer.skipLine(); err == io.EOF {
+				return nil, false
+			} else if err != nil {
+				return tokenizer.errorAtf(tokenizer.line, tokenizer.column, "%v", err), false
+			}
+			continue
+		}
+
+		tokenizer.isLineStart = false
+		tokenizer.tokenLine = tokenizer.line
+		tokenizer.tokenColumn = tokenizer.column
+		tokenizer.token = append(tokenizer.token[:0], c)
+		for {
+			c, err := tokenizer.reader.ReadByte()
+			if err == io.EOF {
+				return nil, true
+			} else if err != nil {
+				return tokenizer.errorAtf(tokenizer.line, tokenizer.column, "%v", err), false
+			}
+			if isDimacsWhitespace(c) {
+				// The whitespace is read again, so the new lines update the position
+				tokenizer.reader.UnreadByte()
+				return nil, true
+			}
+			tokenizer.column++
+			tokenizer.token = append(tokenizer.token, c)
+		}
+	}
+}
+
+/*
+ * Create error at the position of the last token.
+ */
+func (tokenizer *dimacsTokenizer) errorf(format string, args ...interface{}) error {
+	return tokenizer.errorAtf(tokenizer.tokenLine, tokenizer.tokenColumn, format, args...)
+}
+
+func (tokenizer *dimacsTokenizer) errorAtf(line int, column int, format string, args ...interface{}) error {
+	return fmt.Errorf("CNF line %d, column %d: %s", line, column, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,282 @@
+package expr
+
+/**
+ * Loader of the boolean formulas written in the conventional infix syntax, for example:
+ *
+ *   # comments start with # or //
+ *   a & (b | !c) -> d <-> e
+ *
+ * Operators from the lowest to the highest precedence:
+ *   <->        equivalence (left associative)
+ *   ->         implication (right associative)
+ *   |   ||     disjunction
+ *   ^          exclusive or
+ *   &   &&     conjunction
+ *   !   ~      negation
+ * Variables are identifiers made of letters, digits and underscores (not starting with a digit),
+ * "true" and "false" are the constants. The whole input is a single formula that can span many lines.
+ *
+ * The formula is loaded into the same AST as the haskell-like format, so both are processed the same way.
+ * Chains of the same associative operator (a & b & c ...) are built as balanced trees.
+ */
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+)
+
+type ExprLoaderFactory struct {}
+
+type ExprLoader struct {}
+
+func (hlf *ExprLoaderFactory) CreateLoader(context *sat_solver.SATContext) solver.Loader {
+	return ExprLoader{}
+}
+
+func (hlf *ExprLoaderFactory) GetName() string {
+	return "expr"
+}
+
+const (
+	TOKEN_EOF        = 0
+	TOKEN_IDENTIFIER = 1
+	TOKEN_OPERATOR   = 2
+)
+
+type exprToken struct {
+	kind   int
+	text   string
+	line   int
+	column int
+}
+
+// Operators sorted so the longer ones are matched first, mapped to their canonical form
+var OPERATORS = []struct{ text string; canonical string }{
+	{ "<->", "<->" }, { "->", "->" }, { "&&", "&" }, { "||", "|" },
+	{ "&", "&" }, { "|", "|" }, { "^", "^" }, { "!", "!" }, { "~", "!" }, { "(", "(" }, { ")", ")" },
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || (c >= '0' && c <= '9')
+}
+
+/*
+ * Split the input into tokens. The last token is always TOKEN_EOF.
+ */
+func tokenizeExpr(input string) (error, []exprToken) {
+	tokens := []exprToken{}
+	line, lineStart := 1, 0
+	for i := 0; i < len(input); {
+		c := input[i]
+		column := i - lineStart + 1
+		if c == '\n' {
+			line++
+			i++
+			lineStart = i
+			continue
+		} else if c == ' ' || c == '\t' || c == '\r' {
+			i++
+			continue
+		} else if c == '#' || strings.HasPrefix(input[i:], "//") {
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			continue
+		} else if isIdentifierStart(c) {
+			start := i
+			for i < len(input) && isIdentifierPart(input[i]) {
+				i++
+			}
+			tokens = append(tokens, exprToken{ kind: TOKEN_IDENTIFIER, text: input[start:i], line: line, column: column })
+			continue
+		}
+
+		isOperator := false
+		for _, operator := range OPERATORS {
+			if strings.HasPrefix(input[i:], operator.text) {
+				tokens = append(tokens, exprToken{ kind: TOKEN_OPERATOR, text: operator.canonical, line: line, column: column })
+				i += len(operator.text)
+				isOperator = true
+				break
+			}
+		}
+		if !isOperator {
+			return fmt.Errorf("Expression line %d, column %d: Unexpected character '%c'.", line, column, c), nil
+		}
+	}
+	tokens = append(tokens, exprToken{ kind: TOKEN_EOF, line: line, column: len(input) - lineStart + 1 })
+	return nil, tokens
+}
+
+/*
+ * Recursive descent parser with one function per precedence level.
+ */
+type exprParser struct {
+	tokens   []exprToken
+	position int
+}
+
+func (parser *exprParser) peek() exprToken {
+	return parser.tokens[parser.position]
+}
+
+func (parser *exprParser) isOperator(text string) bool {
+	token := parser.peek()
+	return token.kind == TOKEN_OPERATOR && token.text == text
+}
+
+func (parser *exprParser) unexpected() error {
+	token := parser.peek()
+	if token.kind == TOKEN_EOF {
+		return fmt.Errorf("Expression line %d, column %d: Unexpected end of the input.", token.line, token.column)
+	}
+	return fmt.Errorf("Expression line %d, column %d: Unexpected '%s'.", token.line, token.column, token.text)
+}
+
+func (parser *exprParser) parseIff() (error, *sat_solver.Formula) {
+	err, left := parser.parseImplies()
+	if err != nil {
+		return err, nil
+	}
+	for parser.isOperator("<->") {
+		parser.position++
+		err, right := parser.parseImplies()
+		if err != nil {
+			return err, nil
+		}
+		left = sat_solver.MakeIff(left, right)
+	}
+	return nil, left
+}
+
+func (parser *exprParser) parseImplies() (error, *sat_solver.Formula) {
+	err, left := parser.parseOr()
+	if err != nil {
+		return err, nil
+	}
+	if !parser.isOperator("->") {
+		return nil, left
+	}
+	parser.position++
+	err, right := parser.parseImplies()
+	if err != nil {
+		return err, nil
+	}
+	return nil, sat_solver.MakeImplies(left, right)
+}
+
+func (parser *exprParser) parseOr() (error, *sat_solver.Formula) {
+	return parser.parseChain("|", parser.parseXor, sat_solver.MakeOr)
+}
+
+func (parser *exprParser) parseXor() (error, *sat_solver.Formula) {
+	return parser.parseChain("^", parser.parseAnd, sat_solver.MakeXor)
+}
+
+func (parser *exprParser) parseAnd() (error, *sat_solver.Formula) {
+	return parser.parseChain("&", parser.parseUnary, sat_solver.MakeAnd)
+}
+
+/*
+ * Parse operands separated by the associative operator and join them into a balanced tree.
+ */
+func (parser *exprParser) parseChain(operator string, parseOperand func() (error, *sat_solver.Formula), join func(*sat_solver.Formula, *sat_solver.Formula) *sat_solver.Formula) (error, *sat_solver.Formula) {
+	err, operand := parseOperand()
+	if err != nil {
+		return err, nil
+	}
+	operands := []*sat_solver.Formula{ operand }
+	for parser.isOperator(operator) {
+		parser.position++
+		err, operand := parseOperand()
+		if err != nil {
+			return err, nil
+		}
+		operands = append(operands, operand)
+	}
+	return nil, sat_solver.MakeBalancedTree(operands, join, false)
+}
+
+func (parser *exprParser) parseUnary() (error, *sat_solver.Formula) {
+	if parser.isOperator("!") {
+		parser.position++
+		err, arg := parser.parseUnary()
+		if err != nil {
+			return err, nil
+		}
+		return nil, &sat_solver.Formula{
+			Not: &sat_solver.Not{
+				Formula: arg,
+			},
+		}
+	}
+	return parser.parsePrimary()
+}
+
+func (parser *exprParser) parsePrimary() (error, *sat_solver.Formula) {
+	token := parser.peek()
+	if token.kind == TOKEN_IDENTIFIER {
+		parser.position++
+		switch token.text {
+		case "true":
+			return nil, sat_solver.MakeBoolConstant(true)
+		case "false":
+			return nil, sat_solver.MakeBoolConstant(false)
+		}
+		// Names are quoted in the same way as in the haskell-like format
+		return nil, sat_solver.MakeVar(fmt.Sprintf("\"%s\"", token.text))
+	} else if parser.isOperator("(") {
+		parser.position++
+		err, inner := parser.parseIff()
+		if err != nil {
+			return err, nil
+		}
+		if !parser.isOperator(")") {
+			return parser.unexpected(), nil
+		}
+		parser.position++
+		return nil, inner
+	}
+	return parser.unexpected(), nil
+}
+
+func (loader ExprLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
+	input, err := ioutil.ReadAll(inputFormula)
+	if err != nil {
+		return err, nil
+	}
+	err, tokens := tokenizeExpr(string(input))
+	if err != nil {
+		return err, nil
+	}
+	if len(tokens) == 1 {
+		return fmt.Errorf("The input does not contain any formula."), nil
+	}
+
+	parser := &exprParser{
+		tokens: tokens,
+	}
+	err, formula := parser.parseIff()
+	if err != nil {
+		return err, nil
+	}
+	if parser.peek().kind != TOKEN_EOF {
+		return parser.unexpected(), nil
+	}
+	return nil, &sat_solver.Entry{
+		Formula: formula,
+	}
+}
+
+func init() {
+	solver.RegisterLoaderFactory(&ExprLoaderFactory{})
+}
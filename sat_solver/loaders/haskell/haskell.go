@@ -36,19 +36,25 @@ var (
     digit = "0"…"9" .
 `))
 
-	parser = participle.MustBuild(&sat_solver.Entry{},
+	parser = participle.MustBuild(&program{},
 		participle.Lexer(graphQLLexer),
 		participle.Elide("Comment", "Whitespace"),
 	)
 )
 
 func (loader HaskellLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
-	ast := &sat_solver.Entry{}
+	ast := &program{}
 	err := parser.Parse(inputFormula, ast)
 	if err != nil {
 		return err, nil
 	}
-	return nil, ast
+	err, formula := ast.desugar()
+	if err != nil {
+		return err, nil
+	}
+	return nil, &sat_solver.Entry{
+		Formula: formula,
+	}
 }
 
 func init() {
@@ -0,0 +1,250 @@
+package haskell
+
+/**
+ * Surface syntax of the haskell-like input format.
+ *
+ * On top of the core AST (see sat_solver.Formula) the format supports:
+ *   - n-ary connectives: And [X, ...], Or [X, ...] and Xor [X, ...] (the binary forms like And (X) (X) still work)
+ *   - if-then-else: Ite (C) (X) (Y)
+ *   - let-bindings: let name = X in Y, where the name can be used in Y instead of X
+ *   - several top-level statements: "assert X" adds the formula to the asserted conjunction and
+ *     "let name = X" (without "in") defines the name for all the following statements.
+ *     A bare formula is asserted as well, so the files with a single formula are still valid.
+ *
+ * The program is desugared into the core AST. Let-bound formulas are not copied, all their uses point
+ * to the same node, so the Tseytin transformation encodes them only once. The AST optimization
+ * (EnableASTOptimization) rewrites the formula as a tree, so it expands the shared formulas again.
+ * N-ary connectives are translated into balanced trees, so long lists do not create deep recursion.
+ */
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/participle/lexer"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+// Words of the grammar that cannot be used as the names of the let-bindings
+var RESERVED_NAMES = map[string]bool{
+	"T": true, "F": true, "Var": true, "Not": true, "And": true, "Or": true, "Xor": true, "Implies": true,
+	"Iff": true, "Ite": true, "AtLeast": true, "AtMost": true, "Exactly": true, "let": true, "in": true, "assert": true,
+}
+
+type program struct {
+	Statements []*statement `{ @@ }`
+}
+
+type statement struct {
+	Pos     lexer.Position
+	Assert  *expression      `  "assert" @@`
+	Let     *letExpression   `| @@`
+	Formula *expression      `| @@`
+}
+
+/**
+ * Let-binding. Without the "in" part it's allowed only as a top-level statement.
+ */
+type letExpression struct {
+	Pos   lexer.Position
+	Name  string      `"let" @Ident "="`
+	Value *expression `@@`
+	Body  *expression `( "in" @@ )?`
+}
+
+type expression struct {
+	Pos         lexer.Position
+	Group       *expression             `  "(" @@ ")"`
+	Constant    *string                 `| @( "T" | "F" )`
+	Variable    *string                 `| "Var" @Name`
+	Not         *expression             `| "Not" @@`
+	Connective  *connectiveExpression   `| @@`
+	Ite         *iteExpression          `| @@`
+	Cardinality *cardinalityExpression  `| @@`
+	Let         *letExpression          `| @@`
+	Reference   *string                 `| @Ident`
+}
+
+/**
+ * Binary connective (And (X) (Y)) or the n-ary one with the list of arguments (And [X, Y, Z]).
+ */
+type connectiveExpression struct {
+	Operator string        `@( "And" | "Or" | "Xor" | "Implies" | "Iff" )`
+	IsList   bool          `( @"["`
+	Args     []*expression `  ( @@ { "," @@ } )? "]" | @@ @@ )`
+}
+
+type iteExpression struct {
+	Condition *expression `"Ite" @@`
+	Then      *expression `@@`
+	Else      *expression `@@`
+}
+
+type cardinalityExpression struct {
+	Kind  string        `@( "AtLeast" | "AtMost" | "Exactly" )`
+	Bound int           `@Number`
+	Args  []*expression `"[" ( @@ { "," @@ } )? "]"`
+}
+
+/*
+ * Names bound by the let-bindings. Scopes are chained, so the inner bindings shadow the outer ones.
+ */
+type scope struct {
+	name    string
+	formula *sat_solver.Formula
+	parent  *scope
+}
+
+func (s *scope) lookup(name string) *sat_solver.Formula {
+	for ; s != nil; s = s.parent {
+		if s.name == name {
+			return s.formula
+		}
+	}
+	return nil
+}
+
+func positionError(pos lexer.Position, format string, args ...interface{}) error {
+	return fmt.Errorf("%d:%d: %s", pos.Line, pos.Column, fmt.Sprintf(format, args...))
+}
+
+/**
+ * Translate the program into the conjunction of all the asserted formulas.
+ */
+func (p *program) desugar() (error, *sat_solver.Formula) {
+	var names *scope = nil
+	assertions := []*sat_solver.Formula{}
+	for _, stmt := range p.Statements {
+		var asserted *expression = nil
+		if stmt.Assert != nil {
+			asserted = stmt.Assert
+		} else if stmt.Formula != nil {
+			asserted = stmt.Formula
+		} else if stmt.Let.Body != nil {
+			asserted = &expression{ Pos: stmt.Pos, Let: stmt.Let }
+		} else {
+			// Top-level definition
+			err, value := stmt.Let.bind(names)
+			if err != nil {
+				return err, nil
+			}
+			names = value
+			continue
+		}
+		err, formula := asserted.desugar(names)
+		if err != nil {
+			return err, nil
+		}
+		assertions = append(assertions, formula)
+	}
+	if len(assertions) == 0 {
+		return fmt.Errorf("The input does not contain any formula."), nil
+	}
+	return nil, sat_solver.MakeBalancedTree(assertions, sat_solver.MakeAnd, true)
+}
+
+/*
+ * Desugar the value of the binding and add it to the scope.
+ */
+func (let *letExpression) bind(names *scope) (error, *scope) {
+	if RESERVED_NAMES[let.Name] {
+		return positionError(let.Pos, "'%s' is a reserved word and cannot be bound by let", let.Name), nil
+	}
+	err, value := let.Value.desugar(names)
+	if err != nil {
+		return err, nil
+	}
+	return nil, &scope{
+		name:    let.Name,
+		formula: value,
+		parent:  names,
+	}
+}
+
+func (e *expression) desugar(names *scope) (error, *sat_solver.Formula) {
+	if e.Group != nil {
+		return e.Group.desugar(names)
+	} else if e.Constant != nil {
+		return nil, sat_solver.MakeBoolConstant(*e.Constant == "T")
+	} else if e.Variable != nil {
+		return nil, sat_solver.MakeVar(*e.Variable)
+	} else if e.Not != nil {
+		err, arg := e.Not.desugar(names)
+		if err != nil {
+			return err, nil
+		}
+		return nil, &sat_solver.Formula{
+			Not: &sat_solver.Not{
+				Formula: arg,
+			},
+		}
+	} else if e.Connective != nil {
+		return e.Connective.desugar(e.Pos, names)
+	} else if e.Ite != nil {
+		err, args := desugarList([]*expression{ e.Ite.Condition, e.Ite.Then, e.Ite.Else }, names)
+		if err != nil {
+			return err, nil
+		}
+		// The condition node is shared by both branches
+		return nil, sat_solver.MakeOr(
+			sat_solver.MakeAnd(args[0], args[1]),
+			sat_solver.MakeAnd(sat_solver.MakeNot(args[0]), args[2]))
+	} else if e.Cardinality != nil {
+		err, args := desugarList(e.Cardinality.Args, names)
+		if err != nil {
+			return err, nil
+		}
+		return nil, sat_solver.MakeCardinality(e.Cardinality.Kind, e.Cardinality.Bound, args)
+	} else if e.Let != nil {
+		if e.Let.Body == nil {
+			return positionError(e.Pos, "let without 'in' is allowed only at the top level"), nil
+		}
+		err, inner := e.Let.bind(names)
+		if err != nil {
+			return err, nil
+		}
+		return e.Let.Body.desugar(inner)
+	} else if e.Reference != nil {
+		if formula := names.lookup(*e.Reference); formula != nil {
+			return nil, formula
+		}
+		return positionError(e.Pos, "unknown name '%s'", *e.Reference), nil
+	}
+	return positionError(e.Pos, "invalid expression"), nil
+}
+
+func (c *connectiveExpression) desugar(pos lexer.Position, names *scope) (error, *sat_solver.Formula) {
+	err, args := desugarList(c.Args, names)
+	if err != nil {
+		return err, nil
+	}
+	switch c.Operator {
+	case "And":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeAnd, true)
+	case "Or":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeOr, false)
+	case "Xor":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeXor, false)
+	}
+	if c.IsList {
+		if len(args) != 2 {
+			return positionError(pos, "%s takes exactly 2 arguments, got %d", c.Operator, len(args)), nil
+		}
+	}
+	if c.Operator == "Implies" {
+		return nil, sat_solver.MakeImplies(args[0], args[1])
+	}
+	return nil, sat_solver.MakeIff(args[0], args[1])
+}
+
+func desugarList(exprs []*expression, names *scope) (error, []*sat_solver.Formula) {
+	formulas := make([]*sat_solver.Formula, len(exprs))
+	for i, e := range exprs {
+		err, formula := e.desugar(names)
+		if err != nil {
+			return err, nil
+		}
+		formulas[i] = formula
+	}
+	return nil, formulas
+}
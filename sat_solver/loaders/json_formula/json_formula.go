@@ -0,0 +1,294 @@
+package json_formula
+
+/**
+ * Loader and writer of the formulas in the JSON format. The document is an object with the keys:
+ *
+ *   "formula"      formula tree (see below)
+ *   "clauses"      list of clauses, each clause is a list of literals
+ *   "assumptions"  optional list of literals that are assumed to be true
+ *
+ * At least one of "formula" and "clauses" must be given, if both are given then both must be satisfied.
+ * The literal is the name of the variable, optionally prefixed with "-" for the negation ("x" or "-x").
+ * The formula tree is made of the nodes:
+ *
+ *   "x", "-x"                                  literal
+ *   true, false                                constant
+ *   {"op": "not", "args": [X]}
+ *   {"op": "and" | "or" | "xor", "args": [X, ...]}   any number of arguments (and [] is true, or [] is false)
+ *   {"op": "implies" | "iff", "args": [X, Y]}
+ *   {"op": "ite", "args": [C, X, Y]}           if C then X else Y
+ *   {"op": "atleast" | "atmost" | "exactly", "k": 2, "args": [X, ...]}
+ *
+ * For example:
+ *
+ *   {
+ *     "formula": {"op": "or", "args": ["a", {"op": "and", "args": ["-b", "c"]}]},
+ *     "clauses": [["a", "-c"], ["b", "c"]],
+ *     "assumptions": ["-a"]
+ *   }
+ *
+ * The clause lists without the formula are loaded directly into CNF. If the assumptions are given, then
+ * the formula is solved under them and the UNSAT result reports the assumptions that caused it (the core).
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+)
+
+type JSONLoaderFactory struct {}
+
+type JSONLoader struct {}
+
+func (hlf *JSONLoaderFactory) CreateLoader(context *sat_solver.SATContext) solver.Loader {
+	return JSONLoader{}
+}
+
+func (hlf *JSONLoaderFactory) GetName() string {
+	return "json"
+}
+
+/**
+ * JSON document with the formula. The formula tree is decoded as the generic JSON value.
+ */
+type Document struct {
+	Formula     interface{} `json:"formula,omitempty"`
+	Clauses     [][]string  `json:"clauses,omitempty"`
+	Assumptions []string    `json:"assumptions,omitempty"`
+}
+
+// Number of the arguments of the operators (-1 if any number is allowed)
+var OPERATOR_ARITY = map[string]int{
+	"not": 1, "and": -1, "or": -1, "xor": -1, "implies": 2, "iff": 2, "ite": 3,
+	"atleast": -1, "atmost": -1, "exactly": -1,
+}
+
+var CARDINALITY_KINDS = map[string]string{
+	"atleast": sat_solver.CARDINALITY_AT_LEAST,
+	"atmost":  sat_solver.CARDINALITY_AT_MOST,
+	"exactly": sat_solver.CARDINALITY_EXACTLY,
+}
+
+/**
+ * Loaded formula with the assumptions
+ */
+type LoadedFormulaWithAssumptions struct {
+	solver.LoadedFormula
+	Assumptions []solver.Assumption
+}
+
+func (formula LoadedFormulaWithAssumptions) GetAssumptions() []solver.Assumption {
+	return formula.Assumptions
+}
+
+/**
+ * Name of the variable used by the formulas. Names are quoted in the same way as in the haskell-like format.
+ */
+func VariableName(name string) string {
+	return fmt.Sprintf("\"%s\"", name)
+}
+
+/*
+ * Split the literal into the name of the variable and its value.
+ */
+func parseLiteral(literal string, path string) (error, string, bool) {
+	name := strings.TrimPrefix(literal, "-")
+	if len(name) == 0 || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("%s: invalid literal '%s'", path, literal), "", false
+	}
+	return nil, name, len(name) == len(literal)
+}
+
+/**
+ * Decode the node of the formula tree. The path of the node is used in the error messages.
+ */
+func DecodeFormula(node interface{}, path string) (error, *sat_solver.Formula) {
+	switch value := node.(type) {
+	case bool:
+		return nil, sat_solver.MakeBoolConstant(value)
+	case string:
+		err, name, isPositive := parseLiteral(value, path)
+		if err != nil {
+			return err, nil
+		}
+		if isPositive {
+			return nil, sat_solver.MakeVar(VariableName(name))
+		}
+		return nil, sat_solver.MakeNot(sat_solver.MakeVar(VariableName(name)))
+	case map[string]interface{}:
+		return decodeOperator(value, path)
+	}
+	return fmt.Errorf("%s: expected a literal, a boolean constant or an object with the operator", path), nil
+}
+
+func decodeOperator(node map[string]interface{}, path string) (error, *sat_solver.Formula) {
+	op, ok := node["op"].(string)
+	if !ok {
+		return fmt.Errorf("%s: missing operator (\"op\")", path), nil
+	}
+	arity, ok := OPERATOR_ARITY[op]
+	if !ok {
+		return fmt.Errorf("%s: unknown operator '%s'", path, op), nil
+	}
+	kind, isCardinality := CARDINALITY_KINDS[op]
+	for key := range node {
+		if key != "op" && key != "args" && !(isCardinality && key == "k") {
+			return fmt.Errorf("%s: unexpected key '%s' of the operator %s", path, key, op), nil
+		}
+	}
+	nodes, ok := node["args"].([]interface{})
+	if !ok {
+		return fmt.Errorf("%s: the operator %s expects the list of arguments (\"args\")", path, op), nil
+	}
+	if arity >= 0 && len(nodes) != arity {
+		return fmt.Errorf("%s: the operator %s expects %d arguments, got %d", path, op, arity, len(nodes)), nil
+	}
+	args := make([]*sat_solver.Formula, len(nodes))
+	for i, argNode := range nodes {
+		err, arg := DecodeFormula(argNode, fmt.Sprintf("%s.args[%d]", path, i))
+		if err != nil {
+			return err, nil
+		}
+		args[i] = arg
+	}
+
+	switch op {
+	case "not":
+		return nil, sat_solver.MakeNot(args[0])
+	case "and":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeAnd, true)
+	case "or":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeOr, false)
+	case "xor":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeXor, false)
+	case "implies":
+		return nil, sat_solver.MakeImplies(args[0], args[1])
+	case "iff":
+		return nil, sat_solver.MakeIff(args[0], args[1])
+	case "ite":
+		// The condition node is shared by both branches
+		return nil, sat_solver.MakeOr(
+			sat_solver.MakeAnd(args[0], args[1]),
+			sat_solver.MakeAnd(sat_solver.MakeNot(args[0]), args[2]))
+	}
+	number, ok := node["k"].(json.Number)
+	if !ok {
+		return fmt.Errorf("%s: the operator %s expects the integer bound (\"k\")", path, op), nil
+	}
+	bound, err := number.Int64()
+	if err != nil {
+		return fmt.Errorf("%s: the operator %s expects the integer bound (\"k\"), got %s", path, op, number), nil
+	}
+	return nil, sat_solver.MakeCardinality(kind, int(bound), args)
+}
+
+/**
+ * Read the JSON document. Unknown keys of the document are reported as errors.
+ */
+func ReadDocument(input io.Reader) (error, *Document) {
+	decoder := json.NewDecoder(input)
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	document := &Document{}
+	if err := decoder.Decode(document); err != nil {
+		return fmt.Errorf("Invalid JSON formula: %v", err), nil
+	}
+	if decoder.More() {
+		return fmt.Errorf("Invalid JSON formula: unexpected data after the document"), nil
+	}
+	if document.Formula == nil && document.Clauses == nil {
+		return fmt.Errorf("Invalid JSON formula: the document has neither \"formula\" nor \"clauses\""), nil
+	}
+	return nil, document
+}
+
+func (loader JSONLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
+	err, document := ReadDocument(inputFormula)
+	if err != nil {
+		return err, nil
+	}
+	assumptions := make([]solver.Assumption, len(document.Assumptions))
+	for i, literal := range document.Assumptions {
+		err, name, value := parseLiteral(literal, fmt.Sprintf("assumptions[%d]", i))
+		if err != nil {
+			return err, nil
+		}
+		assumptions[i] = solver.Assumption{ Name: VariableName(name), Value: value }
+	}
+
+	var formula solver.LoadedFormula
+	if document.Formula == nil {
+		err, formula = loadClauses(document.Clauses)
+	} else {
+		err, formula = loadFormula(document)
+	}
+	if err != nil {
+		return err, nil
+	}
+	if len(assumptions) > 0 {
+		return nil, LoadedFormulaWithAssumptions{
+			LoadedFormula: formula,
+			Assumptions:   assumptions,
+		}
+	}
+	return nil, formula
+}
+
+/*
+ * Load the clause list directly into CNF.
+ */
+func loadClauses(clauses [][]string) (error, solver.LoadedFormula) {
+	vars := sat_solver.NewSATVariableMapping()
+	cnf := &sat_solver.CNFFormula{
+		Variables: make([]sat_solver.CNFClause, len(clauses)),
+	}
+	for i, literals := range clauses {
+		clause := make(sat_solver.CNFClause, len(literals))
+		for j, literal := range literals {
+			err, name, isPositive := parseLiteral(literal, fmt.Sprintf("clauses[%d][%d]", i, j))
+			if err != nil {
+				return err, nil
+			}
+			clause[j] = vars.Get(VariableName(name))
+			if !isPositive {
+				clause[j] = -clause[j]
+			}
+		}
+		cnf.Variables[i] = clause
+	}
+	return nil, sat_solver.NewSATFormula(cnf, vars, nil)
+}
+
+/*
+ * Load the formula tree together with the clauses into the AST.
+ */
+func loadFormula(document *Document) (error, solver.LoadedFormula) {
+	err, formula := DecodeFormula(document.Formula, "formula")
+	if err != nil {
+		return err, nil
+	}
+	conjunction := []*sat_solver.Formula{ formula }
+	for i, literals := range document.Clauses {
+		clause := make([]*sat_solver.Formula, len(literals))
+		for j, literal := range literals {
+			err, arg := DecodeFormula(literal, fmt.Sprintf("clauses[%d][%d]", i, j))
+			if err != nil {
+				return err, nil
+			}
+			clause[j] = arg
+		}
+		conjunction = append(conjunction, sat_solver.MakeBalancedTree(clause, sat_solver.MakeOr, false))
+	}
+	return nil, &sat_solver.Entry{
+		Formula: sat_solver.MakeBalancedTree(conjunction, sat_solver.MakeAnd, true),
+	}
+}
+
+func init() {
+	solver.RegisterLoaderFactory(&JSONLoaderFactory{})
+}
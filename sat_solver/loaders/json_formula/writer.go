@@ -0,0 +1,168 @@
+package json_formula
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+/*
+ * Encode the name of the variable as the literal. Names starting with "-" would be read as the negations.
+ */
+func encodeLiteral(name string, isPositive bool) (error, string) {
+	if len(name) == 0 || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("The variable '%s' cannot be written in the JSON format.", name), ""
+	}
+	if isPositive {
+		return nil, name
+	}
+	return nil, "-" + name
+}
+
+func encodeOperator(op string, args ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"op":   op,
+		"args": args,
+	}
+}
+
+/*
+ * Collect the arguments of the chain of the same associative connective, so (a and (b and c)) is written
+ * as one operator with three arguments.
+ */
+func collectChainArguments(formula *sat_solver.Formula, getArgs func(*sat_solver.Formula) []*sat_solver.Formula, args []*sat_solver.Formula) []*sat_solver.Formula {
+	if chain := getArgs(formula); chain != nil {
+		for _, arg := range chain {
+			args = collectChainArguments(arg, getArgs, args)
+		}
+		return args
+	}
+	return append(args, formula)
+}
+
+/**
+ * Encode the formula as the node of the JSON formula tree (see DecodeFormula).
+ * The tree has no sharing, so the subformulas used many times are written many times.
+ */
+func EncodeFormula(formula *sat_solver.Formula) (error, interface{}) {
+	var op string
+	var args []*sat_solver.Formula
+	switch {
+	case formula.Constant != nil:
+		return nil, formula.Constant.Bool == "T"
+	case formula.Variable != nil:
+		return encodeLiteral(formula.Variable.GetName(), true)
+	case formula.Not != nil:
+		if formula.Not.Formula.Variable != nil {
+			err, node := EncodeFormula(formula.Not.Formula)
+			if err != nil {
+				return err, nil
+			}
+			return nil, "-" + node.(string)
+		}
+		op, args = "not", []*sat_solver.Formula{ formula.Not.Formula }
+	case formula.And != nil:
+		op, args = "and", collectChainArguments(formula, func(f *sat_solver.Formula) []*sat_solver.Formula {
+			if f.And != nil {
+				return []*sat_solver.Formula{ f.And.Arg1, f.And.Arg2 }
+			}
+			return nil
+		}, nil)
+	case formula.Or != nil:
+		op, args = "or", collectChainArguments(formula, func(f *sat_solver.Formula) []*sat_solver.Formula {
+			if f.Or != nil {
+				return []*sat_solver.Formula{ f.Or.Arg1, f.Or.Arg2 }
+			}
+			return nil
+		}, nil)
+	case formula.Xor != nil:
+		op, args = "xor", collectChainArguments(formula, func(f *sat_solver.Formula) []*sat_solver.Formula {
+			if f.Xor != nil {
+				return []*sat_solver.Formula{ f.Xor.Arg1, f.Xor.Arg2 }
+			}
+			return nil
+		}, nil)
+	case formula.Implies != nil:
+		op, args = "implies", []*sat_solver.Formula{ formula.Implies.Arg1, formula.Implies.Arg2 }
+	case formula.Iff != nil:
+		op, args = "iff", []*sat_solver.Formula{ formula.Iff.Arg1, formula.Iff.Arg2 }
+	case formula.Cardinality != nil:
+		op, args = strings.ToLower(formula.Cardinality.Kind), formula.Cardinality.Args
+	default:
+		return fmt.Errorf("Unknown AST node given to EncodeFormula."), nil
+	}
+
+	nodes := make([]interface{}, len(args))
+	for i, arg := range args {
+		err, node := EncodeFormula(arg)
+		if err != nil {
+			return err, nil
+		}
+		nodes[i] = node
+	}
+	node := encodeOperator(op, nodes...)
+	if formula.Cardinality != nil {
+		node["k"] = formula.Cardinality.Bound
+	}
+	return nil, node
+}
+
+/**
+ * Encode the clauses as the list of the lists of literals. The constant literals are removed
+ * (and the clauses with the true literal are skipped), the native XOR and cardinality constraints are not supported.
+ */
+func EncodeClauses(cnf *sat_solver.CNFFormula, vars *sat_solver.SATVariableMapping) (error, [][]string) {
+	if len(cnf.Xors) > 0 || len(cnf.Cardinalities) > 0 {
+		return fmt.Errorf("The XOR and cardinality constraints cannot be written as the JSON clauses."), nil
+	}
+	clauses := [][]string{}
+	for _, clause := range cnf.Variables {
+		literals := []string{}
+		isSatisfied := false
+		for _, literal := range clause {
+			if literal == 1 {
+				isSatisfied = true
+				break
+			} else if literal == -1 {
+				continue
+			}
+			err, encoded := encodeLiteral(vars.Reverse(literal.Var()), literal > 0)
+			if err != nil {
+				return err, nil
+			}
+			literals = append(literals, encoded)
+		}
+		if !isSatisfied {
+			clauses = append(clauses, literals)
+		}
+	}
+	return nil, clauses
+}
+
+/**
+ * Write the document with the formula tree.
+ */
+func WriteFormula(output io.Writer, formula *sat_solver.Formula) error {
+	err, node := EncodeFormula(formula)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(output).Encode(Document{ Formula: node })
+}
+
+/**
+ * Write the document with the clause list.
+ */
+func WriteClauses(output io.Writer, cnf *sat_solver.CNFFormula, vars *sat_solver.SATVariableMapping) error {
+	err, clauses := EncodeClauses(cnf, vars)
+	if err != nil {
+		return err
+	}
+	// The empty list is written as well, so the document is not empty
+	return json.NewEncoder(output).Encode(struct{
+		Clauses [][]string `json:"clauses"`
+	}{ Clauses: clauses })
+}
@@ -15,6 +15,69 @@ type LoadedFormula interface {
 	IsCNF() bool
 }
 
+/**
+ * Loaded formula with the objective function that should be minimized.
+ * The optimum is found by solving the formula repeatedly with tighter bounds on the objective.
+ */
+type OptimizationProblem interface {
+	LoadedFormula
+	HasObjective() bool
+	// Formula with the additional constraint: objective <= bound
+	ConvertToFormulaWithObjectiveBound(bound int64) (error, *sat_solver.SATFormula)
+	// Value of the objective for the assignment (variables missing from the assignment are false)
+	GetObjectiveValue(assignment map[string]bool) int64
+	// Value of the objective that cannot be improved
+	GetObjectiveLowerBound() int64
+}
+
+/**
+ * Get the checker that evaluates assignments against the formula as it was loaded.
+ * Loaded formulas can provide their own checker by implementing sat_solver.ModelChecker.
+ * It must be called before the formula is processed, because the solvers may modify the loaded CNF.
+ * Returns nil if the formula cannot be checked.
+ */
+func GetModelChecker(formula LoadedFormula) sat_solver.ModelChecker {
+	if checker, ok := formula.(sat_solver.ModelChecker); ok {
+		return checker
+	}
+	if formula.CanBeConvertedToFormula() {
+		satFormula := formula.ConvertToFormula()
+		var cnf *sat_solver.CNFFormula = nil
+		switch f := satFormula.Formula().(type) {
+		case *sat_solver.CNFFormula:
+			cnf = f
+		case *sat_solver.WeightedCNFFormula:
+			cnf = f.Hard
+		}
+		if cnf != nil {
+			if checker := sat_solver.NewCNFModelChecker(cnf, satFormula.Variables()); checker != nil {
+				return checker
+			}
+		}
+		return nil
+	} else if formula.CanBeConvertedToAST() {
+		return formula.ConvertToAST().Formula
+	}
+	return nil
+}
+
+/**
+ * Assumed value of the variable. The name is the name of the variable used by the formula.
+ */
+type Assumption struct {
+	Name  string
+	Value bool
+}
+
+/**
+ * Loaded formula that should be solved under the assumptions.
+ * If the result is UNSAT, then the subset of the assumptions that caused it (the core) is reported.
+ */
+type AssumptionsProblem interface {
+	LoadedFormula
+	GetAssumptions() []Assumption
+}
+
 type Loader interface {
 	Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, LoadedFormula)
 }
@@ -31,6 +94,10 @@ func RegisterLoaderFactory(factory LoaderFactory) {
 	LOADER_FACTORIES[factory.GetName()] = factory
 }
 
+/**
+ * Load the formula with the loader of the given name (or the default one if the name is empty).
+ * Compressed inputs are decompressed before loading (see DecompressInput).
+ */
 func LoadFormula(name string, inputFormula io.Reader, context *sat_solver.SATContext) (error, LoadedFormula) {
 	if len(name) == 0 {
 		if defaultFactory, ok := LOADER_FACTORIES[DEFAULT_LOADER_NAME]; ok {
@@ -43,8 +110,13 @@ func LoadFormula(name string, inputFormula io.Reader, context *sat_solver.SATCon
 		}
 	}
 	if loaderFactory, ok := LOADER_FACTORIES[name]; ok {
+		err, input := DecompressInput(inputFormula)
+		if err != nil {
+			return err, nil
+		}
+		defer input.Close()
 		loader := loaderFactory.CreateLoader(context)
-		return loader.Load(inputFormula, context)
+		return loader.Load(input, context)
 	} else {
 		return fmt.Errorf("Loader with name '%s' not found.", name), nil
 	}
@@ -0,0 +1,441 @@
+package opb
+
+/**
+ * Loader of the pseudo-Boolean problems in the OPB format used by the PB competitions, for example:
+ *
+ *   * #variable= 3 #constraint= 2
+ *   min: +2 x1 -1 x2 +3 x3 ;
+ *   +1 x1 +1 x2 +1 ~x3 >= 2 ;
+ *   +2 x1 -3 x2 = -1 ;
+ *
+ * Lines starting with "*" are comments. Each constraint compares the linear combination of the literals
+ * (~x is the negation of x) with the integer using ">=", "<=" or "=".
+ * The optional "min:" line specifies the objective to minimize.
+ *
+ * Constraints are translated into CNF using the encoding selected in the configuration (see the pb package).
+ */
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+	"github.com/styczynski/go-sat-solver/sat_solver/pb"
+)
+
+type OPBLoaderFactory struct {}
+
+type OPBLoader struct {}
+
+func (hlf *OPBLoaderFactory) CreateLoader(context *sat_solver.SATContext) solver.Loader {
+	return OPBLoader{}
+}
+
+func (hlf *OPBLoaderFactory) GetName() string {
+	return "opb"
+}
+
+type opbTerm struct {
+	coefficient int64
+	name        string
+	negated     bool
+}
+
+type opbConstraint struct {
+	terms      []opbTerm
+	comparator string
+	bound      int64
+}
+
+/**
+ * Loaded pseudo-Boolean problem.
+ * The CNF formula is built again for each objective bound, because the solver and the preprocessor
+ * take the ownership of the formula.
+ */
+type OPBFormula struct {
+	varNames     []string
+	constraints  []opbConstraint
+	objective    []opbTerm
+	hasObjective bool
+	encoder      pb.Encoder
+	formula      *sat_solver.SATFormula
+}
+
+func (loader OPBLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
+	err, encoder := pb.GetEncoder(context.GetConfiguration().PBEncoding)
+	if err != nil {
+		return err, nil
+	}
+	formula := &OPBFormula{
+		varNames:    []string{},
+		constraints: []opbConstraint{},
+		encoder:     encoder,
+	}
+
+	err, statements := tokenizeOPB(inputFormula)
+	if err != nil {
+		return err, nil
+	}
+	knownVars := map[string]bool{}
+	for _, statement := range statements {
+		err = formula.parseStatement(statement, knownVars)
+		if err != nil {
+			return err, nil
+		}
+	}
+
+	err, formula.formula = formula.buildFormula(nil)
+	if err != nil {
+		return err, nil
+	}
+	return nil, formula
+}
+
+type opbStatement struct {
+	line   int
+	tokens []string
+}
+
+/*
+ * Split the input into statements terminated with ";". Relational operators are separate tokens.
+ */
+func tokenizeOPB(input io.Reader) (error, []opbStatement) {
+	statements := []opbStatement{}
+	current := opbStatement{ line: 1, tokens: []string{} }
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if strings.HasPrefix(line, "*") {
+			continue
+		}
+		line = strings.Replace(line, ";", " ; ", -1)
+		line = strings.Replace(line, ">=", " >= ", -1)
+		line = strings.Replace(line, "<=", " <= ", -1)
+		for _, token := range strings.Fields(line) {
+			if len(current.tokens) == 0 {
+				current.line = lineNo
+			}
+			if token == ";" {
+				statements = append(statements, current)
+				current = opbStatement{ tokens: []string{} }
+				continue
+			}
+			// Plain "=" is separated only when it's not a part of ">=" or "<="
+			if token != ">=" && token != "<=" && strings.Contains(token, "=") {
+				parts := strings.Split(token, "=")
+				for i, part := range parts {
+					if i > 0 {
+						current.tokens = append(current.tokens, "=")
+					}
+					if len(part) > 0 {
+						current.tokens = append(current.tokens, part)
+					}
+				}
+				continue
+			}
+			current.tokens = append(current.tokens, token)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err, nil
+	}
+	if len(current.tokens) > 0 {
+		return fmt.Errorf("OPB line %d: Statement is not terminated with ';'.", current.line), nil
+	}
+	return nil, statements
+}
+
+func (formula *OPBFormula) parseStatement(statement opbStatement, knownVars map[string]bool) error {
+	tokens := statement.tokens
+	if len(tokens) == 0 {
+		// Empty objective or constraint
+		return nil
+	}
+	if tokens[0] == "min:" || (tokens[0] == "min" && len(tokens) > 1 && tokens[1] == ":") {
+		if formula.hasObjective {
+			return fmt.Errorf("OPB line %d: Multiple objectives are not supported.", statement.line)
+		}
+		if tokens[0] == "min" {
+			tokens = tokens[2:]
+		} else {
+			tokens = tokens[1:]
+		}
+		err, terms := parseTerms(tokens, statement.line, knownVars, formula)
+		if err != nil {
+			return err
+		}
+		formula.objective = terms
+		formula.hasObjective = true
+		return nil
+	} else if tokens[0] == "max:" || tokens[0] == "max" {
+		return fmt.Errorf("OPB line %d: Only minimization objectives (\"min:\") are supported.", statement.line)
+	}
+
+	comparatorIndex := -1
+	for i, token := range tokens {
+		if token == pb.PB_GREATER_EQUAL || token == pb.PB_LESS_EQUAL || token == pb.PB_EQUAL {
+			comparatorIndex = i
+			break
+		}
+	}
+	if comparatorIndex == -1 {
+		return fmt.Errorf("OPB line %d: Constraint does not contain a relational operator (>=, <= or =).", statement.line)
+	}
+	if comparatorIndex != len(tokens) - 2 {
+		return fmt.Errorf("OPB line %d: Expected a single integer after the relational operator.", statement.line)
+	}
+	err, terms := parseTerms(tokens[:comparatorIndex], statement.line, knownVars, formula)
+	if err != nil {
+		return err
+	}
+	bound, err := strconv.ParseInt(tokens[len(tokens)-1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("OPB line %d: Invalid right hand side of the constraint '%s'.", statement.line, tokens[len(tokens)-1])
+	}
+	formula.constraints = append(formula.constraints, opbConstraint{
+		terms:      terms,
+		comparator: tokens[comparatorIndex],
+		bound:      bound,
+	})
+	return nil
+}
+
+/*
+ * Parse the sequence of terms: [coefficient] literal
+ * Products of the literals (non-linear constraints) are not supported.
+ */
+func parseTerms(tokens []string, line int, knownVars map[string]bool, formula *OPBFormula) (error, []opbTerm) {
+	terms := []opbTerm{}
+	for i := 0; i < len(tokens); i++ {
+		coefficient := int64(1)
+		if isCoefficient(tokens[i]) {
+			value, err := strconv.ParseInt(strings.TrimPrefix(tokens[i], "+"), 10, 64)
+			if err != nil {
+				return fmt.Errorf("OPB line %d: Invalid coefficient '%s'.", line, tokens[i]), nil
+			}
+			coefficient = value
+			i++
+			if i >= len(tokens) {
+				return fmt.Errorf("OPB line %d: Coefficient '%s' is not followed by a variable.", line, tokens[i-1]), nil
+			}
+		}
+		name := tokens[i]
+		negated := false
+		if strings.HasPrefix(name, "~") {
+			negated = true
+			name = name[1:]
+		}
+		if !isVariableName(name) {
+			return fmt.Errorf("OPB line %d: Invalid variable '%s'.", line, tokens[i]), nil
+		}
+		if i + 1 < len(tokens) && !isCoefficient(tokens[i+1]) {
+			return fmt.Errorf("OPB line %d: Non-linear terms are not supported ('%s %s').", line, tokens[i], tokens[i+1]), nil
+		}
+		if !knownVars[name] {
+			knownVars[name] = true
+			formula.varNames = append(formula.varNames, name)
+		}
+		terms = append(terms, opbTerm{
+			coefficient: coefficient,
+			name:        name,
+			negated:     negated,
+		})
+	}
+	return nil, terms
+}
+
+func isCoefficient(token string) bool {
+	if len(token) > 0 && (token[0] == '+' || token[0] == '-') {
+		token = token[1:]
+	}
+	if len(token) == 0 {
+		return false
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isVariableName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	first := name[0]
+	return (first >= 'a' && first <= 'z') || (first >= 'A' && first <= 'Z') || first == '_'
+}
+
+/*
+ * Build the CNF formula for the constraints. If the bound is given then the objective must not exceed it.
+ */
+func (formula *OPBFormula) buildFormula(objectiveBound *int64) (error, *sat_solver.SATFormula) {
+	vars := sat_solver.NewSATVariableMapping()
+	for _, name := range formula.varNames {
+		vars.Get(name)
+	}
+	fresh := func() sat_solver.CNFLiteral {
+		_, id := vars.Fresh()
+		return id
+	}
+	cnf := &sat_solver.CNFFormula{
+		Variables: []sat_solver.CNFClause{},
+	}
+	for _, c := range formula.constraints {
+		err := pb.AddConstraint(cnf, pb.Constraint{
+			Terms:      toPBTerms(c.terms, vars),
+			Comparator: c.comparator,
+			Bound:      c.bound,
+		}, formula.encoder, fresh)
+		if err != nil {
+			return err, nil
+		}
+	}
+	if objectiveBound != nil {
+		err := pb.AddConstraint(cnf, pb.Constraint{
+			Terms:      toPBTerms(formula.objective, vars),
+			Comparator: pb.PB_LESS_EQUAL,
+			Bound:      *objectiveBound,
+		}, formula.encoder, fresh)
+		if err != nil {
+			return err, nil
+		}
+	}
+	return nil, sat_solver.NewSATFormula(cnf, vars, nil)
+}
+
+func toPBTerms(terms []opbTerm, vars *sat_solver.SATVariableMapping) []pb.Term {
+	result := make([]pb.Term, len(terms))
+	for i, term := range terms {
+		literal := vars.Get(term.name)
+		if term.negated {
+			literal = -literal
+		}
+		result[i] = pb.Term{
+			Coefficient: term.coefficient,
+			Literal:     literal,
+		}
+	}
+	return result
+}
+
+func (formula *OPBFormula) CanBeConvertedToFormula() bool {
+	return true
+}
+
+func (formula *OPBFormula) CanBeConvertedToAST() bool {
+	return false
+}
+
+func (formula *OPBFormula) ConvertToFormula() *sat_solver.SATFormula {
+	return formula.formula
+}
+
+func (formula *OPBFormula) ConvertToAST() *sat_solver.Entry {
+	return nil
+}
+
+func (formula *OPBFormula) IsCNF() bool {
+	return true
+}
+
+func (formula *OPBFormula) HasObjective() bool {
+	return formula.hasObjective
+}
+
+func (formula *OPBFormula) ConvertToFormulaWithObjectiveBound(bound int64) (error, *sat_solver.SATFormula) {
+	return formula.buildFormula(&bound)
+}
+
+func (formula *OPBFormula) GetObjectiveValue(assignment map[string]bool) int64 {
+	value := int64(0)
+	for _, term := range formula.objective {
+		if assignment[term.name] != term.negated {
+			value += term.coefficient
+		}
+	}
+	return value
+}
+
+func (formula *OPBFormula) GetObjectiveLowerBound() int64 {
+	bound := int64(0)
+	for _, term := range formula.objective {
+		if term.coefficient < 0 {
+			bound += term.coefficient
+		}
+	}
+	return bound
+}
+
+/**
+ * Names of all the variables of the constraints and the objective.
+ */
+func (formula *OPBFormula) GetVariableNames() []string {
+	return formula.varNames
+}
+
+/**
+ * Evaluate the constraints (without the objective) under the partial assignment.
+ * The sum of each constraint is bounded by the smallest and the largest value possible for the undefined variables.
+ */
+func (formula *OPBFormula) EvaluatePartial(assignment map[string]bool) sat_solver.ModelValue {
+	result := sat_solver.MODEL_VALUE_TRUE
+	for _, c := range formula.constraints {
+		minSum, maxSum := int64(0), int64(0)
+		for _, term := range c.terms {
+			if value, ok := assignment[term.name]; ok {
+				if value != term.negated {
+					minSum += term.coefficient
+					maxSum += term.coefficient
+				}
+			} else if term.coefficient < 0 {
+				minSum += term.coefficient
+			} else {
+				maxSum += term.coefficient
+			}
+		}
+		value := sat_solver.MODEL_VALUE_UNDEFINED
+		switch c.comparator {
+		case pb.PB_GREATER_EQUAL:
+			value = compareSumRange(minSum, maxSum, c.bound)
+		case pb.PB_LESS_EQUAL:
+			value = compareSumRange(-maxSum, -minSum, -c.bound)
+		case pb.PB_EQUAL:
+			if minSum == c.bound && maxSum == c.bound {
+				value = sat_solver.MODEL_VALUE_TRUE
+			} else if minSum > c.bound || maxSum < c.bound {
+				value = sat_solver.MODEL_VALUE_FALSE
+			}
+		}
+		if value == sat_solver.MODEL_VALUE_FALSE {
+			return value
+		} else if value == sat_solver.MODEL_VALUE_UNDEFINED {
+			result = value
+		}
+	}
+	return result
+}
+
+/*
+ * Value of sum >= bound when the sum is between minSum and maxSum.
+ */
+func compareSumRange(minSum int64, maxSum int64, bound int64) sat_solver.ModelValue {
+	if minSum >= bound {
+		return sat_solver.MODEL_VALUE_TRUE
+	} else if maxSum < bound {
+		return sat_solver.MODEL_VALUE_FALSE
+	}
+	return sat_solver.MODEL_VALUE_UNDEFINED
+}
+
+func init() {
+	solver.RegisterLoaderFactory(&OPBLoaderFactory{})
+}
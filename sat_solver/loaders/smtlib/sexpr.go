@@ -0,0 +1,240 @@
+package smtlib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/**
+ * S-expression of the SMT-LIB script: an atom (symbol, keyword, numeral or string literal) or a list.
+ */
+type SExpr struct {
+	// Text of the atom. Quoted symbols (|...|) are stored without the bars, string literals without the quotes.
+	Atom     string
+	IsString bool
+	IsList   bool
+	List     []*SExpr
+	// Position of the expression in the input
+	Line     int
+	Column   int
+}
+
+func (e *SExpr) IsSymbol(name string) bool {
+	return !e.IsList && !e.IsString && e.Atom == name
+}
+
+/**
+ * Get the name of the command or operator if the expression is a list starting with a symbol.
+ */
+func (e *SExpr) Head() string {
+	if !e.IsList || len(e.List) == 0 || e.List[0].IsList || e.List[0].IsString {
+		return ""
+	}
+	return e.List[0].Atom
+}
+
+func isSimpleSymbolChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+		strings.IndexByte("~!@$%^&*_-+=<>.?/:", c) >= 0
+}
+
+/**
+ * Print the expression in the SMT-LIB syntax.
+ */
+func (e *SExpr) String() string {
+	if e.IsList {
+		items := make([]string, len(e.List))
+		for i, item := range e.List {
+			items[i] = item.String()
+		}
+		return "(" + strings.Join(items, " ") + ")"
+	} else if e.IsString {
+		return "\"" + strings.Replace(e.Atom, "\"", "\"\"", -1) + "\""
+	}
+	return QuoteSymbol(e.Atom)
+}
+
+/**
+ * Quote the symbol with bars if it's not a valid simple symbol.
+ */
+func QuoteSymbol(name string) string {
+	isSimple := len(name) > 0 && !(name[0] >= '0' && name[0] <= '9')
+	for i := 0; i < len(name) && isSimple; i++ {
+		isSimple = isSimpleSymbolChar(name[i])
+	}
+	if isSimple {
+		return name
+	}
+	return "|" + name + "|"
+}
+
+/**
+ * Streaming reader of the s-expressions. It never reads past the end of the returned expression,
+ * so it can be used for the interactive sessions.
+ */
+type SExprReader struct {
+	reader *bufio.Reader
+	line   int
+	column int
+}
+
+func NewSExprReader(input io.Reader) *SExprReader {
+	return &SExprReader{
+		reader: bufio.NewReader(input),
+		line:   1,
+		column: 0,
+	}
+}
+
+func (r *SExprReader) errorAtf(line int, column int, format string, args ...interface{}) error {
+	return fmt.Errorf("SMT-LIB line %d, column %d: %s", line, column, fmt.Sprintf(format, args...))
+}
+
+func (r *SExprReader) readByte() (byte, error) {
+	c, err := r.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if c == '\n' {
+		r.line++
+		r.column = 0
+	} else {
+		r.column++
+	}
+	return c, nil
+}
+
+/*
+ * Skip the whitespace and comments. Returns the first significant byte.
+ */
+func (r *SExprReader) skipWhitespace() (byte, error) {
+	for {
+		c, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		if c == ';' {
+			for c != '\n' {
+				c, err = r.readByte()
+				if err != nil {
+					return 0, err
+				}
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			return c, nil
+		}
+	}
+}
+
+/**
+ * Read the next top-level expression. Returns nil expression at the end of the input.
+ */
+func (r *SExprReader) Read() (error, *SExpr) {
+	c, err := r.skipWhitespace()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return r.errorAtf(r.line, r.column, "%v", err), nil
+	}
+	return r.readExpr(c)
+}
+
+/*
+ * Read the expression starting with the already consumed byte.
+ */
+func (r *SExprReader) readExpr(c byte) (error, *SExpr) {
+	line, column := r.line, r.column
+	expr := &SExpr{
+		Line:   line,
+		Column: column,
+	}
+	unexpectedEnd := func(err error) error {
+		if err == io.EOF {
+			return r.errorAtf(line, column, "unexpected end of the input")
+		}
+		return r.errorAtf(r.line, r.column, "%v", err)
+	}
+
+	switch c {
+	case '(':
+		expr.IsList = true
+		expr.List = []*SExpr{}
+		for {
+			c, err := r.skipWhitespace()
+			if err != nil {
+				return unexpectedEnd(err), nil
+			}
+			if c == ')' {
+				return nil, expr
+			}
+			err, item := r.readExpr(c)
+			if err != nil {
+				return err, nil
+			}
+			expr.List = append(expr.List, item)
+		}
+	case ')':
+		return r.errorAtf(line, column, "unexpected ')'"), nil
+	case '"':
+		expr.IsString = true
+		text := []byte{}
+		for {
+			c, err := r.readByte()
+			if err != nil {
+				return unexpectedEnd(err), nil
+			}
+			if c == '"' {
+				// Double quote is the escaped quote
+				next, err := r.reader.Peek(1)
+				if err != nil || next[0] != '"' {
+					break
+				}
+				r.readByte()
+			}
+			text = append(text, c)
+		}
+		expr.Atom = string(text)
+		return nil, expr
+	case '|':
+		text := []byte{}
+		for {
+			c, err := r.readByte()
+			if err != nil {
+				return unexpectedEnd(err), nil
+			}
+			if c == '|' {
+				break
+			}
+			if c == '\\' {
+				return r.errorAtf(r.line, r.column, "quoted symbols cannot contain '\\'"), nil
+			}
+			text = append(text, c)
+		}
+		expr.Atom = string(text)
+		return nil, expr
+	}
+
+	if !isSimpleSymbolChar(c) && c != '#' {
+		return r.errorAtf(line, column, "unexpected character '%c'", c), nil
+	}
+	text := []byte{ c }
+	for {
+		next, err := r.reader.Peek(1)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return r.errorAtf(r.line, r.column, "%v", err), nil
+		}
+		if !isSimpleSymbolChar(next[0]) && next[0] != '#' {
+			break
+		}
+		r.readByte()
+		text = append(text, next[0])
+	}
+	expr.Atom = string(text)
+	return nil, expr
+}
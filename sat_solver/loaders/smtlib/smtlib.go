@@ -0,0 +1,125 @@
+package smtlib
+
+/**
+ * Loader of the Boolean fragment of SMT-LIB 2 scripts.
+ *
+ * Supported are the constants of sort Bool (declare-const and declare-fun without arguments), definitions
+ * without arguments (define-fun) and the Bool terms of the Core theory (see TranslateTerm).
+ * The loader returns the conjunction of the assertions that are active at the first check-sat command
+ * (or at the end of the script), taking push and pop into account. Other commands are ignored.
+ *
+ * Scripts are usually executed command by command, which is done by the SMT-LIB session (see core.RunSMTLibSession).
+ */
+
+import (
+	"io"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+)
+
+type SMTLibLoaderFactory struct {}
+
+type SMTLibLoader struct {}
+
+func (hlf *SMTLibLoaderFactory) CreateLoader(context *sat_solver.SATContext) solver.Loader {
+	return SMTLibLoader{}
+}
+
+func (hlf *SMTLibLoaderFactory) GetName() string {
+	return "smt2"
+}
+
+/**
+ * Parse the numeral argument of push and pop. The argument is optional and defaults to 1.
+ */
+func LevelsArgument(command *SExpr) (error, int) {
+	if len(command.List) == 1 {
+		return nil, 1
+	}
+	arg := command.List[1]
+	levels := 0
+	if len(command.List) > 2 || arg.IsList || arg.IsString || len(arg.Atom) == 0 {
+		return termError(command, "expected (%s <numeral>)", command.Head()), 0
+	}
+	for _, c := range arg.Atom {
+		if c < '0' || c > '9' || levels > 1000000 {
+			return termError(arg, "expected numeral, got %s", arg.String()), 0
+		}
+		levels = levels * 10 + int(c - '0')
+	}
+	return nil, levels
+}
+
+func (loader SMTLibLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
+	reader := NewSExprReader(inputFormula)
+	table := NewSymbolTable()
+	// Assertions on each level, the first level is the global one
+	assertions := [][]*sat_solver.Formula{ {} }
+	for {
+		err, command := reader.Read()
+		if err != nil {
+			return err, nil
+		}
+		if command == nil {
+			break
+		}
+		name := command.Head()
+		if len(name) == 0 {
+			return termError(command, "expected command, got %s", command.String()), nil
+		}
+		if err, ok := table.ExecuteDeclaration(command); ok {
+			if err != nil {
+				return err, nil
+			}
+			continue
+		}
+
+		isFinished := false
+		switch name {
+		case "assert":
+			if len(command.List) != 2 {
+				return termError(command, "expected (assert <term>)"), nil
+			}
+			err, formula, _ := table.TranslateAssertion(command.List[1])
+			if err != nil {
+				return err, nil
+			}
+			last := len(assertions) - 1
+			assertions[last] = append(assertions[last], formula)
+		case "push", "pop":
+			err, levels := LevelsArgument(command)
+			if err != nil {
+				return err, nil
+			}
+			for i := 0; i < levels; i++ {
+				if name == "push" {
+					table.Push()
+					assertions = append(assertions, []*sat_solver.Formula{})
+				} else if table.Depth() == 0 {
+					return termError(command, "not enough levels to pop"), nil
+				} else {
+					table.Pop()
+					assertions = assertions[:len(assertions) - 1]
+				}
+			}
+		case "check-sat", "check-sat-assuming", "exit":
+			isFinished = true
+		}
+		if isFinished {
+			break
+		}
+	}
+
+	active := []*sat_solver.Formula{}
+	for _, level := range assertions {
+		active = append(active, level...)
+	}
+	return nil, &sat_solver.Entry{
+		Formula: sat_solver.MakeBalancedTree(active, sat_solver.MakeAnd, true),
+	}
+}
+
+func init() {
+	solver.RegisterLoaderFactory(&SMTLibLoaderFactory{})
+}
@@ -0,0 +1,365 @@
+package smtlib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type symbolEntry struct {
+	formula    *sat_solver.Formula
+	// Is it a constant declared with declare-const or declare-fun (and not a defined name)?
+	isDeclared bool
+}
+
+/**
+ * Names visible in the script. The names are added to the current assertion level
+ * and they are removed when the level is popped.
+ */
+type SymbolTable struct {
+	symbols map[string]symbolEntry
+	// Names added on each level, the first level is the global one
+	levels  [][]string
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		symbols: map[string]symbolEntry{},
+		levels:  [][]string{ {} },
+	}
+}
+
+func (table *SymbolTable) Push() {
+	table.levels = append(table.levels, []string{})
+}
+
+/**
+ * Remove the names added on the current level. The global level cannot be popped.
+ */
+func (table *SymbolTable) Pop() {
+	last := len(table.levels) - 1
+	for _, name := range table.levels[last] {
+		delete(table.symbols, name)
+	}
+	table.levels = table.levels[:last]
+}
+
+/**
+ * Number of the pushed levels.
+ */
+func (table *SymbolTable) Depth() int {
+	return len(table.levels) - 1
+}
+
+func (table *SymbolTable) add(name string, entry symbolEntry) error {
+	if _, ok := table.symbols[name]; ok || name == "true" || name == "false" {
+		return fmt.Errorf("symbol %s is already declared", QuoteSymbol(name))
+	}
+	table.symbols[name] = entry
+	last := len(table.levels) - 1
+	table.levels[last] = append(table.levels[last], name)
+	return nil
+}
+
+/**
+ * Names of the visible declared constants in the order of the declarations.
+ */
+func (table *SymbolTable) DeclaredNames() []string {
+	names := []string{}
+	for _, level := range table.levels {
+		for _, name := range level {
+			if table.symbols[name].isDeclared {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+/**
+ * Name of the AST variable of the declared constant. Names are quoted in the same way as in the haskell-like format.
+ */
+func VariableName(name string) string {
+	return fmt.Sprintf("\"%s\"", name)
+}
+
+func termError(term *SExpr, format string, args ...interface{}) error {
+	return fmt.Errorf("SMT-LIB line %d, column %d: %s", term.Line, term.Column, fmt.Sprintf(format, args...))
+}
+
+func checkBoolSort(sort *SExpr) error {
+	if !sort.IsSymbol("Bool") {
+		return termError(sort, "unsupported sort %s, only Bool is supported", sort.String())
+	}
+	return nil
+}
+
+/**
+ * Handle the commands that change only the names: declare-const, declare-fun and define-fun.
+ * Returns false if the command is not one of them.
+ */
+func (table *SymbolTable) ExecuteDeclaration(command *SExpr) (error, bool) {
+	args := command.List[1:]
+	switch command.Head() {
+	case "declare-const":
+		if len(args) != 2 || args[0].IsList {
+			return termError(command, "expected (declare-const <symbol> <sort>)"), true
+		}
+		if err := checkBoolSort(args[1]); err != nil {
+			return err, true
+		}
+		return table.declare(args[0]), true
+	case "declare-fun":
+		if len(args) != 3 || args[0].IsList || !args[1].IsList {
+			return termError(command, "expected (declare-fun <symbol> (<sort>*) <sort>)"), true
+		}
+		if len(args[1].List) > 0 {
+			return termError(args[1], "functions with arguments are not supported"), true
+		}
+		if err := checkBoolSort(args[2]); err != nil {
+			return err, true
+		}
+		return table.declare(args[0]), true
+	case "define-fun":
+		if len(args) != 4 || args[0].IsList || !args[1].IsList {
+			return termError(command, "expected (define-fun <symbol> (<sorted var>*) <sort> <term>)"), true
+		}
+		if len(args[1].List) > 0 {
+			return termError(args[1], "functions with arguments are not supported"), true
+		}
+		if err := checkBoolSort(args[2]); err != nil {
+			return err, true
+		}
+		err, formula := table.TranslateTerm(args[3])
+		if err != nil {
+			return err, true
+		}
+		if err := table.add(args[0].Atom, symbolEntry{ formula: formula }); err != nil {
+			return termError(args[0], "%v", err), true
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+func (table *SymbolTable) declare(name *SExpr) error {
+	err := table.add(name.Atom, symbolEntry{
+		formula:    sat_solver.MakeVar(VariableName(name.Atom)),
+		isDeclared: true,
+	})
+	if err != nil {
+		return termError(name, "%v", err)
+	}
+	return nil
+}
+
+/*
+ * Names bound by the let-terms. Scopes are chained, so the inner bindings shadow the outer ones.
+ */
+type letScope struct {
+	names  map[string]*sat_solver.Formula
+	parent *letScope
+}
+
+func (s *letScope) lookup(name string) *sat_solver.Formula {
+	for ; s != nil; s = s.parent {
+		if formula, ok := s.names[name]; ok {
+			return formula
+		}
+	}
+	return nil
+}
+
+/**
+ * Translate the Bool term into the AST.
+ * Supported are the constants, names, let-terms, annotations and the operators of the Core theory:
+ * not, and, or, xor, =>, =, distinct and ite.
+ */
+func (table *SymbolTable) TranslateTerm(term *SExpr) (error, *sat_solver.Formula) {
+	return table.translate(term, nil)
+}
+
+/**
+ * Translate the asserted term. If it's annotated with :named, then the name is returned as well.
+ */
+func (table *SymbolTable) TranslateAssertion(term *SExpr) (error, *sat_solver.Formula, string) {
+	err, formula := table.translate(term, nil)
+	if err != nil {
+		return err, nil, ""
+	}
+	name := ""
+	if term.Head() == "!" {
+		for i := 2; i + 1 < len(term.List); i++ {
+			if term.List[i].IsSymbol(":named") {
+				name = term.List[i + 1].Atom
+			}
+		}
+	}
+	return nil, formula, name
+}
+
+func (table *SymbolTable) translate(term *SExpr, locals *letScope) (error, *sat_solver.Formula) {
+	if term.IsString {
+		return termError(term, "unexpected string literal"), nil
+	}
+	if !term.IsList {
+		switch term.Atom {
+		case "true":
+			return nil, sat_solver.MakeBoolConstant(true)
+		case "false":
+			return nil, sat_solver.MakeBoolConstant(false)
+		}
+		if formula := locals.lookup(term.Atom); formula != nil {
+			return nil, formula
+		}
+		if entry, ok := table.symbols[term.Atom]; ok {
+			return nil, entry.formula
+		}
+		return termError(term, "unknown constant %s", term.String()), nil
+	}
+
+	operator := term.Head()
+	if len(operator) == 0 {
+		return termError(term, "invalid term %s", term.String()), nil
+	}
+	switch operator {
+	case "let":
+		return table.translateLet(term, locals)
+	case "!":
+		return table.translateAnnotation(term, locals)
+	}
+
+	args := make([]*sat_solver.Formula, len(term.List) - 1)
+	for i, arg := range term.List[1:] {
+		err, formula := table.translate(arg, locals)
+		if err != nil {
+			return err, nil
+		}
+		args[i] = formula
+	}
+	arityError := func(expected string) error {
+		return termError(term, "%s expects %s arguments, got %d", operator, expected, len(args))
+	}
+
+	switch operator {
+	case "not":
+		if len(args) != 1 {
+			return arityError("1"), nil
+		}
+		return nil, &sat_solver.Formula{
+			Not: &sat_solver.Not{
+				Formula: args[0],
+			},
+		}
+	case "and":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeAnd, true)
+	case "or":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeOr, false)
+	case "xor":
+		return nil, sat_solver.MakeBalancedTree(args, sat_solver.MakeXor, false)
+	case "=>":
+		// Right associative
+		if len(args) < 2 {
+			return arityError("at least 2"), nil
+		}
+		result := args[len(args) - 1]
+		for i := len(args) - 2; i >= 0; i-- {
+			result = sat_solver.MakeImplies(args[i], result)
+		}
+		return nil, result
+	case "=":
+		// Chainable: all the neighbours are equal
+		if len(args) < 2 {
+			return arityError("at least 2"), nil
+		}
+		equalities := []*sat_solver.Formula{}
+		for i := 0; i + 1 < len(args); i++ {
+			equalities = append(equalities, sat_solver.MakeIff(args[i], args[i + 1]))
+		}
+		return nil, sat_solver.MakeBalancedTree(equalities, sat_solver.MakeAnd, true)
+	case "distinct":
+		// Pairwise: all the arguments are different
+		if len(args) < 2 {
+			return arityError("at least 2"), nil
+		}
+		differences := []*sat_solver.Formula{}
+		for i := 0; i < len(args); i++ {
+			for j := i + 1; j < len(args); j++ {
+				differences = append(differences, sat_solver.MakeXor(args[i], args[j]))
+			}
+		}
+		return nil, sat_solver.MakeBalancedTree(differences, sat_solver.MakeAnd, true)
+	case "ite":
+		if len(args) != 3 {
+			return arityError("3"), nil
+		}
+		// The condition node is shared by both branches
+		return nil, sat_solver.MakeOr(
+			sat_solver.MakeAnd(args[0], args[1]),
+			sat_solver.MakeAnd(sat_solver.MakeNot(args[0]), args[2]))
+	}
+	return termError(term, "unsupported operator %s", QuoteSymbol(operator)), nil
+}
+
+/*
+ * Parallel let: all the bound terms are translated in the outer scope.
+ */
+func (table *SymbolTable) translateLet(term *SExpr, locals *letScope) (error, *sat_solver.Formula) {
+	if len(term.List) != 3 || !term.List[1].IsList || len(term.List[1].List) == 0 {
+		return termError(term, "expected (let ((<symbol> <term>)+) <term>)"), nil
+	}
+	inner := &letScope{
+		names:  map[string]*sat_solver.Formula{},
+		parent: locals,
+	}
+	for _, binding := range term.List[1].List {
+		if !binding.IsList || len(binding.List) != 2 || binding.List[0].IsList || binding.List[0].IsString {
+			return termError(binding, "expected (<symbol> <term>) binding"), nil
+		}
+		name := binding.List[0].Atom
+		if _, ok := inner.names[name]; ok {
+			return termError(binding, "symbol %s is bound twice", QuoteSymbol(name)), nil
+		}
+		err, formula := table.translate(binding.List[1], locals)
+		if err != nil {
+			return err, nil
+		}
+		inner.names[name] = formula
+	}
+	return table.translate(term.List[2], inner)
+}
+
+/*
+ * Annotated term (! t :attr value ...). The :named attribute defines a global name for the term.
+ */
+func (table *SymbolTable) translateAnnotation(term *SExpr, locals *letScope) (error, *sat_solver.Formula) {
+	if len(term.List) < 2 {
+		return termError(term, "expected (! <term> <attribute>+)"), nil
+	}
+	err, formula := table.translate(term.List[1], locals)
+	if err != nil {
+		return err, nil
+	}
+	for i := 2; i < len(term.List); i++ {
+		attribute := term.List[i]
+		if attribute.IsList || attribute.IsString || len(attribute.Atom) == 0 || attribute.Atom[0] != ':' {
+			return termError(attribute, "expected attribute keyword"), nil
+		}
+		if attribute.Atom != ":named" {
+			// Other attributes (like :pattern) do not change the meaning of the term
+			if value := term.List[i + 1:]; len(value) > 0 && (value[0].IsList || value[0].IsString || !strings.HasPrefix(value[0].Atom, ":")) {
+				i++
+			}
+			continue
+		}
+		if i + 1 >= len(term.List) || term.List[i + 1].IsList || term.List[i + 1].IsString {
+			return termError(attribute, ":named expects a symbol"), nil
+		}
+		i++
+		if err := table.add(term.List[i].Atom, symbolEntry{ formula: formula }); err != nil {
+			return termError(term.List[i], "%v", err), nil
+		}
+	}
+	return nil, formula
+}
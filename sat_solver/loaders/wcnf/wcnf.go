@@ -0,0 +1,135 @@
+package wcnf
+
+/**
+ * Loader of the weighted partial MaxSAT instances.
+ *
+ * Two formats are supported:
+ *   - the old format with the "p wcnf <vars> <clauses> [<top>]" header, where each clause starts with its weight
+ *     and clauses with the weight equal to the top (or greater) are hard. If the top is missing then all the
+ *     clauses are soft. The header "p cnf <vars> <clauses>" means that all the clauses are soft with the weight 1.
+ *   - the 2022 MaxSAT Evaluation format without the header, where hard clauses start with "h" and soft ones
+ *     start with their weight.
+ * Lines starting with "c" are comments and each clause is terminated with 0.
+ */
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	solver "github.com/styczynski/go-sat-solver/sat_solver/loaders"
+)
+
+type WCNFLoaderFactory struct {}
+
+type WCNFLoader struct {}
+
+func (hlf *WCNFLoaderFactory) CreateLoader(context *sat_solver.SATContext) solver.Loader {
+	return WCNFLoader{}
+}
+
+func (hlf *WCNFLoaderFactory) GetName() string {
+	return "wcnf"
+}
+
+func (loader WCNFLoader) Load(inputFormula io.Reader, context *sat_solver.SATContext) (error, solver.LoadedFormula) {
+	vars := sat_solver.NewSATVariableMapping()
+	formula := &sat_solver.WeightedCNFFormula{
+		Hard: &sat_solver.CNFFormula{
+			Variables: []sat_solver.CNFClause{},
+		},
+		Soft: []sat_solver.SoftClause{},
+	}
+
+	scanner := bufio.NewScanner(inputFormula)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	hasHeader := false
+	isUnweighted := false
+	top := int64(-1)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == 'c' {
+			continue
+		}
+		tokens := strings.Fields(line)
+		if tokens[0] == "p" {
+			if hasHeader {
+				return fmt.Errorf("WCNF line %d: Duplicated header.", lineNo), nil
+			}
+			hasHeader = true
+			if len(tokens) < 4 || (tokens[1] != "wcnf" && tokens[1] != "cnf") {
+				return fmt.Errorf("WCNF line %d: Invalid header, expected 'p wcnf <vars> <clauses> [<top>]'.", lineNo), nil
+			}
+			isUnweighted = tokens[1] == "cnf"
+			if len(tokens) > 4 && !isUnweighted {
+				value, err := strconv.ParseInt(tokens[4], 10, 64)
+				if err != nil {
+					return fmt.Errorf("WCNF line %d: Invalid top weight '%s'.", lineNo, tokens[4]), nil
+				}
+				top = value
+			}
+			continue
+		}
+
+		isHard := false
+		weight := int64(1)
+		if !isUnweighted {
+			if tokens[0] == "h" {
+				if hasHeader {
+					return fmt.Errorf("WCNF line %d: Hard clauses marked with 'h' cannot be used with the 'p wcnf' header.", lineNo), nil
+				}
+				isHard = true
+			} else {
+				value, err := strconv.ParseInt(tokens[0], 10, 64)
+				if err != nil || value < 0 {
+					return fmt.Errorf("WCNF line %d: Invalid clause weight '%s'.", lineNo, tokens[0]), nil
+				}
+				weight = value
+				isHard = top >= 0 && weight >= top
+			}
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 || tokens[len(tokens)-1] != "0" {
+			return fmt.Errorf("WCNF line %d: Clause does not end with 0.", lineNo), nil
+		}
+
+		clause := make(sat_solver.CNFClause, len(tokens)-1)
+		for i, token := range tokens[:len(tokens)-1] {
+			literal, err := strconv.Atoi(token)
+			if err != nil || literal == 0 {
+				return fmt.Errorf("WCNF line %d: Invalid literal '%s'.", lineNo, token), nil
+			}
+			varID := literal
+			if varID < 0 {
+				varID = -varID
+			}
+			newID := vars.Get(fmt.Sprintf("%d", varID))
+			if literal < 0 {
+				newID = -newID
+			}
+			clause[i] = newID
+		}
+		if isHard {
+			formula.Hard.Variables = append(formula.Hard.Variables, clause)
+		} else {
+			formula.Soft = append(formula.Soft, sat_solver.SoftClause{
+				Clause: clause,
+				Weight: weight,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err, nil
+	}
+
+	return nil, sat_solver.NewSATFormula(formula, vars, nil)
+}
+
+func init() {
+	solver.RegisterLoaderFactory(&WCNFLoaderFactory{})
+}
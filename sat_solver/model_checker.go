@@ -0,0 +1,551 @@
+package sat_solver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/**
+ * Model checkers evaluate assignments of the founder variables against the formula as it was loaded,
+ * before the conversion to CNF and any simplifications.
+ *
+ * The assignment may be partial. Variables missing from it are undefined and the formula is evaluated
+ * in the three-valued Kleene logic. If the value is true, then the formula is true for any values of the missing
+ * variables, but the evaluation is conservative and the converse does not hold: for example Xor (Var "a") (Var "a")
+ * is undefined when a is missing, although it's false for both values of a.
+ */
+type ModelValue int8
+
+const (
+	MODEL_VALUE_FALSE ModelValue = iota
+	MODEL_VALUE_TRUE
+	MODEL_VALUE_UNDEFINED
+)
+
+func (v ModelValue) String() string {
+	switch v {
+	case MODEL_VALUE_TRUE:
+		return "true"
+	case MODEL_VALUE_FALSE:
+		return "false"
+	}
+	return "undefined"
+}
+
+func modelValueOf(value bool) ModelValue {
+	if value {
+		return MODEL_VALUE_TRUE
+	}
+	return MODEL_VALUE_FALSE
+}
+
+func (v ModelValue) Not() ModelValue {
+	switch v {
+	case MODEL_VALUE_TRUE:
+		return MODEL_VALUE_FALSE
+	case MODEL_VALUE_FALSE:
+		return MODEL_VALUE_TRUE
+	}
+	return MODEL_VALUE_UNDEFINED
+}
+
+func modelAnd(x ModelValue, y ModelValue) ModelValue {
+	if x == MODEL_VALUE_FALSE || y == MODEL_VALUE_FALSE {
+		return MODEL_VALUE_FALSE
+	} else if x == MODEL_VALUE_TRUE && y == MODEL_VALUE_TRUE {
+		return MODEL_VALUE_TRUE
+	}
+	return MODEL_VALUE_UNDEFINED
+}
+
+func modelOr(x ModelValue, y ModelValue) ModelValue {
+	return modelAnd(x.Not(), y.Not()).Not()
+}
+
+func modelXor(x ModelValue, y ModelValue) ModelValue {
+	if x == MODEL_VALUE_UNDEFINED || y == MODEL_VALUE_UNDEFINED {
+		return MODEL_VALUE_UNDEFINED
+	}
+	return modelValueOf(x != y)
+}
+
+type ModelChecker interface {
+	// Evaluate the formula under the (possibly partial) assignment of the founder variables
+	EvaluatePartial(assignment map[string]bool) ModelValue
+	// Names of the founder variables used by the formula
+	GetVariableNames() []string
+}
+
+/*
+ * Checkers that can shrink the model faster than by evaluating the whole formula for each variable.
+ */
+type modelMinimizer interface {
+	minimizeModel(model map[string]bool) map[string]bool
+}
+
+/*
+ * Checkers that can point to the part of the formula that is not satisfied.
+ */
+type violationDescriber interface {
+	describeViolation(assignment map[string]bool) string
+}
+
+/**
+ * Check that the assignment satisfies the formula.
+ * Returns ModelCheckError describing the first unsatisfied constraint if it does not.
+ *
+ * The solvers may omit the variables that were removed by the conversion to CNF, because the formula
+ * does not depend on them (for example Xor (Var "a") (Var "a")). The missing variables are set to false,
+ * so the check does not depend on the conservative evaluation of the partial assignments.
+ */
+func CheckModel(checker ModelChecker, assignment map[string]bool) error {
+	assignment = completeAssignment(checker, assignment)
+	value := checker.EvaluatePartial(assignment)
+	if value == MODEL_VALUE_TRUE {
+		return nil
+	}
+	violation := ""
+	if describer, ok := checker.(violationDescriber); ok {
+		violation = describer.describeViolation(assignment)
+	}
+	return NewModelCheckError(value, violation)
+}
+
+/*
+ * Copy of the assignment with the missing variables of the formula set to false.
+ */
+func completeAssignment(checker ModelChecker, assignment map[string]bool) map[string]bool {
+	complete := make(map[string]bool, len(assignment))
+	for name, value := range assignment {
+		complete[name] = value
+	}
+	for _, name := range checker.GetVariableNames() {
+		if _, ok := complete[name]; !ok {
+			complete[name] = false
+		}
+	}
+	return complete
+}
+
+/**
+ * Reduce the model into a minimal partial assignment that still satisfies the formula:
+ * removing any other variable from it makes the value of the formula undefined.
+ * Variables are removed greedily in the order of their names, so the result is reproducible.
+ * If the model does not satisfy the formula, then it's returned without changes.
+ */
+func MinimizeModel(checker ModelChecker, model map[string]bool) map[string]bool {
+	if checker.EvaluatePartial(model) != MODEL_VALUE_TRUE {
+		return model
+	}
+	if minimizer, ok := checker.(modelMinimizer); ok {
+		return minimizer.minimizeModel(model)
+	}
+	partial := make(map[string]bool, len(model))
+	for name, value := range model {
+		partial[name] = value
+	}
+	for _, name := range sortedModelNames(model) {
+		value := partial[name]
+		delete(partial, name)
+		// The value of the formula is monotonic, so once a variable is needed it stays needed
+		if checker.EvaluatePartial(partial) != MODEL_VALUE_TRUE {
+			partial[name] = value
+		}
+	}
+	return partial
+}
+
+func sortedModelNames(model map[string]bool) []string {
+	names := make([]string, 0, len(model))
+	for name := range model {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/**
+ * Evaluate the AST in the three-valued logic.
+ */
+func (astNode *Formula) EvaluatePartial(assignment map[string]bool) ModelValue {
+	return astNode.evaluatePartial(assignment, map[*Formula]ModelValue{})
+}
+
+/**
+ * Names of the variables used by the AST.
+ */
+func (astNode *Formula) GetVariableNames() []string {
+	names := map[string]bool{}
+	astNode.collectVariableNames(names, map[*Formula]bool{})
+	return sortedModelNames(names)
+}
+
+func (astNode *Formula) collectVariableNames(names map[string]bool, visited map[*Formula]bool) {
+	if astNode == nil || visited[astNode] {
+		return
+	}
+	visited[astNode] = true
+	if astNode.Variable != nil {
+		names[trimVarQuotes(astNode.Variable.Name)] = true
+	} else if astNode.Not != nil {
+		astNode.Not.Formula.collectVariableNames(names, visited)
+	} else if astNode.And != nil {
+		astNode.And.Arg1.collectVariableNames(names, visited)
+		astNode.And.Arg2.collectVariableNames(names, visited)
+	} else if astNode.Or != nil {
+		astNode.Or.Arg1.collectVariableNames(names, visited)
+		astNode.Or.Arg2.collectVariableNames(names, visited)
+	} else if astNode.Implies != nil {
+		astNode.Implies.Arg1.collectVariableNames(names, visited)
+		astNode.Implies.Arg2.collectVariableNames(names, visited)
+	} else if astNode.Iff != nil {
+		astNode.Iff.Arg1.collectVariableNames(names, visited)
+		astNode.Iff.Arg2.collectVariableNames(names, visited)
+	} else if astNode.Xor != nil {
+		astNode.Xor.Arg1.collectVariableNames(names, visited)
+		astNode.Xor.Arg2.collectVariableNames(names, visited)
+	} else if astNode.Cardinality != nil {
+		for _, arg := range astNode.Cardinality.Args {
+			arg.collectVariableNames(names, visited)
+		}
+	}
+}
+
+/*
+ * The AST can be a DAG (shared subformulas of the let-bindings), so the values of the nodes are cached.
+ */
+func (astNode *Formula) evaluatePartial(assignment map[string]bool, values map[*Formula]ModelValue) ModelValue {
+	if value, ok := values[astNode]; ok {
+		return value
+	}
+	value := astNode.evaluateNode(assignment, values)
+	values[astNode] = value
+	return value
+}
+
+func (astNode *Formula) evaluateNode(assignment map[string]bool, values map[*Formula]ModelValue) ModelValue {
+	if astNode.Constant != nil {
+		return modelValueOf(astNode.Constant.Bool == "T")
+	} else if astNode.Variable != nil {
+		if value, ok := assignment[trimVarQuotes(astNode.Variable.Name)]; ok {
+			return modelValueOf(value)
+		}
+		return MODEL_VALUE_UNDEFINED
+	} else if astNode.Not != nil {
+		return astNode.Not.Formula.evaluatePartial(assignment, values).Not()
+	} else if astNode.And != nil {
+		return modelAnd(astNode.And.Arg1.evaluatePartial(assignment, values), astNode.And.Arg2.evaluatePartial(assignment, values))
+	} else if astNode.Or != nil {
+		return modelOr(astNode.Or.Arg1.evaluatePartial(assignment, values), astNode.Or.Arg2.evaluatePartial(assignment, values))
+	} else if astNode.Implies != nil {
+		return modelOr(astNode.Implies.Arg1.evaluatePartial(assignment, values).Not(), astNode.Implies.Arg2.evaluatePartial(assignment, values))
+	} else if astNode.Iff != nil {
+		return modelXor(astNode.Iff.Arg1.evaluatePartial(assignment, values), astNode.Iff.Arg2.evaluatePartial(assignment, values)).Not()
+	} else if astNode.Xor != nil {
+		return modelXor(astNode.Xor.Arg1.evaluatePartial(assignment, values), astNode.Xor.Arg2.evaluatePartial(assignment, values))
+	} else if astNode.Cardinality != nil {
+		trueCount, undefinedCount := 0, 0
+		for _, arg := range astNode.Cardinality.Args {
+			switch arg.evaluatePartial(assignment, values) {
+			case MODEL_VALUE_TRUE:
+				trueCount++
+			case MODEL_VALUE_UNDEFINED:
+				undefinedCount++
+			}
+		}
+		k := astNode.Cardinality.Bound
+		atLeast := countAtLeast(trueCount, undefinedCount, k)
+		atMost := countAtLeast(len(astNode.Cardinality.Args) - trueCount - undefinedCount, undefinedCount, len(astNode.Cardinality.Args) - k)
+		switch astNode.Cardinality.Kind {
+		case CARDINALITY_AT_LEAST:
+			return atLeast
+		case CARDINALITY_AT_MOST:
+			return atMost
+		default:
+			return modelAnd(atLeast, atMost)
+		}
+	}
+	return MODEL_VALUE_UNDEFINED
+}
+
+/*
+ * Find the first conjunct of the top-level conjunction that is not true.
+ */
+func (astNode *Formula) describeViolation(assignment map[string]bool) string {
+	if astNode.And != nil {
+		if astNode.And.Arg1.EvaluatePartial(assignment) != MODEL_VALUE_TRUE {
+			return astNode.And.Arg1.describeViolation(assignment)
+		}
+		return astNode.And.Arg2.describeViolation(assignment)
+	}
+	return astNode.String()
+}
+
+/*
+ * Value of "at least k of the arguments are true" when trueCount of them are true and undefinedCount are undefined.
+ */
+func countAtLeast(trueCount int, undefinedCount int, k int) ModelValue {
+	if trueCount >= k {
+		return MODEL_VALUE_TRUE
+	} else if trueCount + undefinedCount < k {
+		return MODEL_VALUE_FALSE
+	}
+	return MODEL_VALUE_UNDEFINED
+}
+
+/**
+ * Copy of the CNF formula that uses the names of the variables, so it can be checked after the solver
+ * or the preprocessor modified the original formula.
+ */
+type CNFModelChecker struct {
+	formula *CNFFormula
+	names   map[CNFLiteral]string
+}
+
+/**
+ * Create checker for the CNF formula. Returns nil if the formula contains variables that are not founder variables
+ * (their values are not known to the users of the checker).
+ */
+func NewCNFModelChecker(formula *CNFFormula, vars *SATVariableMapping) *CNFModelChecker {
+	checker := &CNFModelChecker{
+		formula: &CNFFormula{
+			Variables:     make([]CNFClause, len(formula.Variables)),
+			Xors:          make([]XORClause, len(formula.Xors)),
+			Cardinalities: make([]CardinalityClause, len(formula.Cardinalities)),
+		},
+		names: map[CNFLiteral]string{},
+	}
+	addNames := func(literals []CNFLiteral) bool {
+		for _, literal := range literals {
+			v := literal.Var()
+			if v == 1 {
+				// Boolean constant
+				continue
+			}
+			if _, ok := checker.names[v]; !ok {
+				if !vars.IsFounderVariable(v) {
+					return false
+				}
+				checker.names[v] = vars.Reverse(v)
+			}
+		}
+		return true
+	}
+	for i, clause := range formula.Variables {
+		if !addNames(clause) {
+			return nil
+		}
+		checker.formula.Variables[i] = clause.Copy()
+	}
+	for i, xor := range formula.Xors {
+		if !addNames(xor.Vars) {
+			return nil
+		}
+		checker.formula.Xors[i] = XORClause{
+			Vars:   CNFClause(xor.Vars).Copy(),
+			Parity: xor.Parity,
+		}
+	}
+	for i, c := range formula.Cardinalities {
+		if !addNames(c.Literals) {
+			return nil
+		}
+		checker.formula.Cardinalities[i] = CardinalityClause{
+			Literals: CNFClause(c.Literals).Copy(),
+			Bound:    c.Bound,
+		}
+	}
+	return checker
+}
+
+func (checker *CNFModelChecker) GetVariableNames() []string {
+	names := make(map[string]bool, len(checker.names))
+	for _, name := range checker.names {
+		names[name] = true
+	}
+	return sortedModelNames(names)
+}
+
+func (checker *CNFModelChecker) literalValue(literal CNFLiteral, assignment map[string]bool) ModelValue {
+	if literal.Var() == 1 {
+		return modelValueOf(literal > 0)
+	}
+	value, ok := assignment[checker.names[literal.Var()]]
+	if !ok {
+		return MODEL_VALUE_UNDEFINED
+	}
+	return modelValueOf(value == (literal > 0))
+}
+
+func (checker *CNFModelChecker) clauseValue(clause CNFClause, assignment map[string]bool) ModelValue {
+	result := MODEL_VALUE_FALSE
+	for _, literal := range clause {
+		result = modelOr(result, checker.literalValue(literal, assignment))
+		if result == MODEL_VALUE_TRUE {
+			break
+		}
+	}
+	return result
+}
+
+func (checker *CNFModelChecker) xorValue(xor XORClause, assignment map[string]bool) ModelValue {
+	result := modelValueOf(!xor.Parity)
+	for _, v := range xor.Vars {
+		result = modelXor(result, checker.literalValue(v, assignment))
+	}
+	return result
+}
+
+func (checker *CNFModelChecker) cardinalityValue(c CardinalityClause, assignment map[string]bool) ModelValue {
+	trueCount, undefinedCount := 0, 0
+	for _, literal := range c.Literals {
+		switch checker.literalValue(literal, assignment) {
+		case MODEL_VALUE_TRUE:
+			trueCount++
+		case MODEL_VALUE_UNDEFINED:
+			undefinedCount++
+		}
+	}
+	return countAtLeast(trueCount, undefinedCount, c.Bound)
+}
+
+func (checker *CNFModelChecker) EvaluatePartial(assignment map[string]bool) ModelValue {
+	result := MODEL_VALUE_TRUE
+	for _, clause := range checker.formula.Variables {
+		result = modelAnd(result, checker.clauseValue(clause, assignment))
+		if result == MODEL_VALUE_FALSE {
+			return result
+		}
+	}
+	for _, xor := range checker.formula.Xors {
+		result = modelAnd(result, checker.xorValue(xor, assignment))
+		if result == MODEL_VALUE_FALSE {
+			return result
+		}
+	}
+	for _, c := range checker.formula.Cardinalities {
+		result = modelAnd(result, checker.cardinalityValue(c, assignment))
+		if result == MODEL_VALUE_FALSE {
+			return result
+		}
+	}
+	return result
+}
+
+func (checker *CNFModelChecker) literalString(literal CNFLiteral) string {
+	if literal.Var() == 1 {
+		if literal > 0 {
+			return "T"
+		}
+		return "F"
+	} else if literal < 0 {
+		return "-" + checker.names[-literal]
+	}
+	return checker.names[literal]
+}
+
+func (checker *CNFModelChecker) literalsString(literals []CNFLiteral) string {
+	result := make([]string, len(literals))
+	for i, literal := range literals {
+		result[i] = checker.literalString(literal)
+	}
+	return strings.Join(result, " ")
+}
+
+func (checker *CNFModelChecker) describeViolation(assignment map[string]bool) string {
+	for i, clause := range checker.formula.Variables {
+		if checker.clauseValue(clause, assignment) != MODEL_VALUE_TRUE {
+			return fmt.Sprintf("clause %d (%s)", i + 1, checker.literalsString(clause))
+		}
+	}
+	for _, xor := range checker.formula.Xors {
+		if checker.xorValue(xor, assignment) != MODEL_VALUE_TRUE {
+			return fmt.Sprintf("XOR (%s) = %t", checker.literalsString(xor.Vars), xor.Parity)
+		}
+	}
+	for _, c := range checker.formula.Cardinalities {
+		if checker.cardinalityValue(c, assignment) != MODEL_VALUE_TRUE {
+			return fmt.Sprintf("at least %d of (%s)", c.Bound, checker.literalsString(c.Literals))
+		}
+	}
+	return ""
+}
+
+/*
+ * Greedy minimization that keeps the number of true literals in each clause.
+ * A variable can be removed if each clause satisfied by it has another true literal. Variables of the XORs
+ * are always needed and the cardinality constraints are evaluated again for each removed variable.
+ * The model must satisfy the formula.
+ */
+func (checker *CNFModelChecker) minimizeModel(model map[string]bool) map[string]bool {
+	partial := make(map[string]bool, len(model))
+	for name, value := range model {
+		partial[name] = value
+	}
+	trueCount := make([]int, len(checker.formula.Variables))
+	satisfiedClauses := map[string][]int{}
+	for i, clause := range checker.formula.Variables {
+		for _, literal := range clause {
+			if checker.literalValue(literal, partial) != MODEL_VALUE_TRUE {
+				continue
+			} else if literal.Var() == 1 {
+				trueCount[i]++
+				continue
+			}
+			name := checker.names[literal.Var()]
+			// Duplicated literals are counted once
+			if occurrences := satisfiedClauses[name]; len(occurrences) == 0 || occurrences[len(occurrences)-1] != i {
+				trueCount[i]++
+				satisfiedClauses[name] = append(satisfiedClauses[name], i)
+			}
+		}
+	}
+	xorVars := map[string]bool{}
+	for _, xor := range checker.formula.Xors {
+		for _, v := range xor.Vars {
+			xorVars[checker.names[v]] = true
+		}
+	}
+	cardinalities := map[string][]int{}
+	for i, c := range checker.formula.Cardinalities {
+		for _, literal := range c.Literals {
+			if literal.Var() != 1 {
+				name := checker.names[literal.Var()]
+				cardinalities[name] = append(cardinalities[name], i)
+			}
+		}
+	}
+
+	for _, name := range sortedModelNames(model) {
+		if xorVars[name] {
+			continue
+		}
+		canRemove := true
+		for _, i := range satisfiedClauses[name] {
+			if trueCount[i] < 2 {
+				canRemove = false
+				break
+			}
+		}
+		if !canRemove {
+			continue
+		}
+		value := partial[name]
+		delete(partial, name)
+		for _, i := range cardinalities[name] {
+			if checker.cardinalityValue(checker.formula.Cardinalities[i], partial) != MODEL_VALUE_TRUE {
+				canRemove = false
+				break
+			}
+		}
+		if !canRemove {
+			partial[name] = value
+			continue
+		}
+		for _, i := range satisfiedClauses[name] {
+			trueCount[i]--
+		}
+	}
+	return partial
+}
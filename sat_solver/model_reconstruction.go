@@ -0,0 +1,96 @@
+package sat_solver
+
+/**
+ * Simplifications like variable elimination, blocked clause elimination or pure literal removal
+ * preserve only the satisfiability of the formula and not all of its models.
+ * Each such change pushes the removed clause together with its pivot literal onto the reconstruction stack.
+ * The model found for the simplified formula is later extended by going through the stack backwards
+ * and flipping the pivot of each clause that is not satisfied.
+ */
+type ReconstructionStack struct {
+	steps []reconstructionStep
+}
+
+type reconstructionStep struct {
+	pivot  CNFLiteral
+	clause CNFClause
+}
+
+func NewReconstructionStack() *ReconstructionStack {
+	return &ReconstructionStack{
+		steps: []reconstructionStep{},
+	}
+}
+
+/**
+ * Remember that the clause was removed and the pivot literal can be flipped to satisfy it.
+ */
+func (stack *ReconstructionStack) Push(pivot CNFLiteral, clause CNFClause) {
+	stack.steps = append(stack.steps, reconstructionStep{
+		pivot:  pivot,
+		clause: clause.Copy(),
+	})
+}
+
+func (stack *ReconstructionStack) Len() int {
+	return len(stack.steps)
+}
+
+/**
+ * Create a new stack with steps of this stack followed by the steps of the other one.
+ */
+func (stack *ReconstructionStack) Concat(other *ReconstructionStack) *ReconstructionStack {
+	result := NewReconstructionStack()
+	if stack != nil {
+		result.steps = append(result.steps, stack.steps...)
+	}
+	if other != nil {
+		result.steps = append(result.steps, other.steps...)
+	}
+	return result
+}
+
+/**
+ * Extend the model of the simplified formula into the model of the original one.
+ * Variables missing from the model are treated as false.
+ */
+func (stack *ReconstructionStack) Extend(model map[CNFLiteral]bool) {
+	for i := len(stack.steps) - 1; i >= 0; i-- {
+		step := stack.steps[i]
+		isSatisfied := false
+		for _, literal := range step.clause {
+			if model[literal.Var()] == (literal > 0) {
+				isSatisfied = true
+				break
+			}
+		}
+		if !isSatisfied {
+			model[step.pivot.Var()] = step.pivot > 0
+		}
+	}
+}
+
+/**
+ * Translate the stack literals after variables of the formula were renumbered.
+ */
+func (stack *ReconstructionStack) remap(vars *SATVariableMapping, newVars *SATVariableMapping) *ReconstructionStack {
+	translate := func(literal CNFLiteral) CNFLiteral {
+		newID := newVars.Get(vars.reverse[literal.Var()])
+		if literal < 0 {
+			return -newID
+		}
+		return newID
+	}
+	result := NewReconstructionStack()
+	for _, step := range stack.steps {
+		clause := make(CNFClause, len(step.clause))
+		for i, literal := range step.clause {
+			clause[i] = translate(literal)
+		}
+		result.steps = append(result.steps, reconstructionStep{
+			pivot:  translate(step.pivot),
+			clause: clause,
+		})
+	}
+	return result
+}
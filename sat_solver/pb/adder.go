@@ -0,0 +1,97 @@
+package pb
+
+/**
+ * This file implements the encoding of the pseudo-Boolean constraints with the adder networks.
+ *
+ * Every coefficient is split into the powers of two and each literal is put into the buckets of the bits that are
+ * set in its coefficient. Buckets are then reduced with the full adders (three bits of the bucket into the sum
+ * bit of the same bucket and the carry bit of the next bucket) and the half adders, until each bucket has
+ * a single bit. These bits form the binary representation of the sum, which is compared with the bound.
+ * The size of the encoding is O(n * log(max coefficient)).
+ *
+ * For more details please see the paper by Een and Sorensson:
+ *   "Translating Pseudo-Boolean Constraints into SAT" (JSAT 2006)
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type AdderEncoder struct {}
+
+func (encoder *AdderEncoder) GetName() string {
+	return "adder"
+}
+
+func (encoder *AdderEncoder) Encode(c NormalizedConstraint, fresh func() sat_solver.CNFLiteral) (error, []sat_solver.CNFClause) {
+	builder := &clauseBuilder{
+		clauses: []sat_solver.CNFClause{},
+		fresh:   fresh,
+	}
+	buckets := [][]node{}
+	for _, term := range c.Terms {
+		for bit := 0; term.Coefficient >> uint(bit) > 0; bit++ {
+			if (term.Coefficient >> uint(bit)) & 1 == 1 {
+				for len(buckets) <= bit {
+					buckets = append(buckets, []node{})
+				}
+				buckets[bit] = append(buckets[bit], literalNode(term.Literal))
+			}
+		}
+	}
+
+	// Bits of the sum from the least significant one
+	sum := []node{}
+	for bit := 0; bit < len(buckets); bit++ {
+		bucket := buckets[bit]
+		for len(bucket) >= 2 {
+			var carry node
+			if len(bucket) >= 3 {
+				x, y, z := bucket[0], bucket[1], bucket[2]
+				bucket = append(bucket[3:], builder.xor(builder.xor(x, y), z))
+				carry = builder.majority(x, y, z)
+			} else {
+				x, y := bucket[0], bucket[1]
+				bucket = append(bucket[2:], builder.xor(x, y))
+				carry = builder.andEquivalent(x, y)
+			}
+			if bit + 1 == len(buckets) {
+				buckets = append(buckets, []node{})
+			}
+			buckets[bit+1] = append(buckets[bit+1], carry)
+		}
+		if len(bucket) == 0 {
+			sum = append(sum, nodeFalse)
+		} else {
+			sum = append(sum, bucket[0])
+		}
+	}
+
+	builder.assert(greaterOrEqual(builder, sum, c.Bound))
+	return nil, builder.clauses
+}
+
+/*
+ * Node implying that the binary number (least significant bit first) is at least equal to the bound.
+ * Bits are compared starting from the least significant one:
+ *   if the bound has 1 at the position i, then the number must also have 1 there and the lower bits must be >=
+ *   otherwise the number may have 1 there or the lower bits must be >=
+ */
+func greaterOrEqual(builder *clauseBuilder, bits []node, bound int64) node {
+	if bound >> uint(len(bits)) > 0 {
+		return nodeFalse
+	}
+	result := nodeTrue
+	for i, bit := range bits {
+		if (bound >> uint(i)) & 1 == 1 {
+			result = builder.and(bit, result)
+		} else {
+			result = builder.or(bit, result)
+		}
+	}
+	return result
+}
+
+func init() {
+	RegisterEncoder(&AdderEncoder{})
+}
@@ -0,0 +1,69 @@
+package pb
+
+/**
+ * This file implements the BDD based encoding of the pseudo-Boolean constraints.
+ *
+ * The constraint sum(a_i * l_i) >= K is represented by the decision diagram. The node (i, K) is true if
+ * the terms i, i+1, ... can reach K. It's defined by the case split on the literal l_i:
+ *   (i, K) = (l_i and (i+1, K - a_i)) or (-l_i and (i+1, K))
+ * Because (i+1, K) implies (i+1, K - a_i), it's simplified to:
+ *   (i, K) = (i+1, K - a_i) and (l_i or (i+1, K))
+ * The nodes are shared between the paths with the same remaining bound, so the size of the encoding
+ * is at most n*K (and usually much smaller).
+ *
+ * For more details please see the paper by Een and Sorensson:
+ *   "Translating Pseudo-Boolean Constraints into SAT" (JSAT 2006)
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type BDDEncoder struct {}
+
+func (encoder *BDDEncoder) GetName() string {
+	return "bdd"
+}
+
+func (encoder *BDDEncoder) Encode(c NormalizedConstraint, fresh func() sat_solver.CNFLiteral) (error, []sat_solver.CNFClause) {
+	builder := &clauseBuilder{
+		clauses: []sat_solver.CNFClause{},
+		fresh:   fresh,
+	}
+	// suffixSums[i] is the maximal value of the terms i, i+1, ...
+	suffixSums := make([]int64, len(c.Terms) + 1)
+	for i := len(c.Terms) - 1; i >= 0; i-- {
+		suffixSums[i] = suffixSums[i+1] + c.Terms[i].Coefficient
+	}
+	memo := map[bddNodeKey]node{}
+
+	var build func(i int, bound int64) node
+	build = func(i int, bound int64) node {
+		if bound <= 0 {
+			return nodeTrue
+		} else if suffixSums[i] < bound {
+			return nodeFalse
+		}
+		key := bddNodeKey{ index: i, bound: bound }
+		if result, ok := memo[key]; ok {
+			return result
+		}
+		high := build(i+1, bound - c.Terms[i].Coefficient)
+		low := build(i+1, bound)
+		result := builder.and(high, builder.or(literalNode(c.Terms[i].Literal), low))
+		memo[key] = result
+		return result
+	}
+
+	builder.assert(build(0, c.Bound))
+	return nil, builder.clauses
+}
+
+type bddNodeKey struct {
+	index int
+	bound int64
+}
+
+func init() {
+	RegisterEncoder(&BDDEncoder{})
+}
@@ -0,0 +1,186 @@
+package pb
+
+import (
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+/**
+ * Encoder translates normalized pseudo-Boolean constraints into clauses.
+ * The constraint passed to the encoder is neither trivial nor unsatisfiable.
+ */
+type Encoder interface {
+	Encode(c NormalizedConstraint, fresh func() sat_solver.CNFLiteral) (error, []sat_solver.CNFClause)
+	GetName() string
+}
+
+var DEFAULT_PB_ENCODER_NAME = "bdd"
+var PB_ENCODERS = map[string]Encoder{}
+
+func RegisterEncoder(encoder Encoder) {
+	PB_ENCODERS[encoder.GetName()] = encoder
+}
+
+func GetEncoder(name string) (error, Encoder) {
+	if len(name) == 0 {
+		name = DEFAULT_PB_ENCODER_NAME
+	}
+	if encoder, ok := PB_ENCODERS[name]; ok {
+		return nil, encoder
+	}
+	return fmt.Errorf("Pseudo-Boolean encoding with name '%s' not found.", name), nil
+}
+
+/*
+ * Literal or boolean constant produced while building the encodings.
+ * Constants are folded, so they never reach the clauses.
+ */
+type node struct {
+	literal    sat_solver.CNFLiteral
+	isConstant bool
+	value      bool
+}
+
+var nodeTrue = node{ isConstant: true, value: true }
+var nodeFalse = node{ isConstant: true, value: false }
+
+func literalNode(literal sat_solver.CNFLiteral) node {
+	return node{ literal: literal }
+}
+
+func (n node) isTrue() bool {
+	return n.isConstant && n.value
+}
+
+func (n node) isFalse() bool {
+	return n.isConstant && !n.value
+}
+
+func (n node) negate() node {
+	if n.isConstant {
+		return node{ isConstant: true, value: !n.value }
+	}
+	return literalNode(-n.literal)
+}
+
+/*
+ * Collects clauses of the encoding and creates auxiliary variables.
+ */
+type clauseBuilder struct {
+	clauses []sat_solver.CNFClause
+	fresh   func() sat_solver.CNFLiteral
+}
+
+/*
+ * Add clause built from the nodes. True nodes satisfy the clause and false nodes are skipped.
+ */
+func (b *clauseBuilder) addClause(nodes ...node) {
+	clause := make(sat_solver.CNFClause, 0, len(nodes))
+	for _, n := range nodes {
+		if n.isTrue() {
+			return
+		} else if !n.isConstant {
+			clause = append(clause, n.literal)
+		}
+	}
+	b.clauses = append(b.clauses, clause)
+}
+
+/*
+ * Node that implies x and y.
+ * Only one direction of the definition is encoded, so the result may be used only positively.
+ */
+func (b *clauseBuilder) and(x node, y node) node {
+	if x.isFalse() || y.isFalse() {
+		return nodeFalse
+	} else if x.isTrue() {
+		return y
+	} else if y.isTrue() {
+		return x
+	}
+	v := literalNode(b.fresh())
+	b.addClause(v.negate(), x)
+	b.addClause(v.negate(), y)
+	return v
+}
+
+/*
+ * Node that implies x or y.
+ * Only one direction of the definition is encoded, so the result may be used only positively.
+ */
+func (b *clauseBuilder) or(x node, y node) node {
+	if x.isTrue() || y.isTrue() {
+		return nodeTrue
+	} else if x.isFalse() {
+		return y
+	} else if y.isFalse() {
+		return x
+	}
+	v := literalNode(b.fresh())
+	b.addClause(v.negate(), x, y)
+	return v
+}
+
+/*
+ * Node equivalent to x xor y.
+ */
+func (b *clauseBuilder) xor(x node, y node) node {
+	if x.isConstant {
+		if x.value {
+			return y.negate()
+		}
+		return y
+	} else if y.isConstant {
+		return b.xor(y, x)
+	}
+	v := literalNode(b.fresh())
+	b.addClause(v.negate(), x, y)
+	b.addClause(v.negate(), x.negate(), y.negate())
+	b.addClause(v, x.negate(), y)
+	b.addClause(v, x, y.negate())
+	return v
+}
+
+/*
+ * Node equivalent to x and y (both directions of the definition are encoded).
+ */
+func (b *clauseBuilder) andEquivalent(x node, y node) node {
+	if x.isConstant || y.isConstant {
+		return b.and(x, y)
+	}
+	v := b.and(x, y)
+	b.addClause(v, x.negate(), y.negate())
+	return v
+}
+
+/*
+ * Node equivalent to at least two of x, y and z being true (both directions of the definition are encoded).
+ */
+func (b *clauseBuilder) majority(x node, y node, z node) node {
+	if x.isConstant {
+		if x.value {
+			return b.andEquivalent(y.negate(), z.negate()).negate()
+		}
+		return b.andEquivalent(y, z)
+	} else if y.isConstant {
+		return b.majority(y, x, z)
+	} else if z.isConstant {
+		return b.majority(z, x, y)
+	}
+	v := literalNode(b.fresh())
+	b.addClause(v.negate(), x, y)
+	b.addClause(v.negate(), x, z)
+	b.addClause(v.negate(), y, z)
+	b.addClause(v, x.negate(), y.negate())
+	b.addClause(v, x.negate(), z.negate())
+	b.addClause(v, y.negate(), z.negate())
+	return v
+}
+
+/*
+ * Require the node to be true.
+ */
+func (b *clauseBuilder) assert(x node) {
+	b.addClause(x)
+}
@@ -0,0 +1,165 @@
+package pb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+const PB_GREATER_EQUAL = ">="
+const PB_LESS_EQUAL = "<="
+const PB_EQUAL = "="
+
+/**
+ * Single term of the linear constraint: Coefficient * Literal
+ * The literal has value 1 when it's true and 0 otherwise.
+ */
+type Term struct {
+	Coefficient int64
+	Literal     sat_solver.CNFLiteral
+}
+
+/**
+ * Linear pseudo-Boolean constraint: sum of the Terms compared with Bound using the Comparator (">=", "<=" or "=").
+ */
+type Constraint struct {
+	Terms      []Term
+	Comparator string
+	Bound      int64
+}
+
+/**
+ * Constraint in the normal form: sum of the Terms >= Bound
+ * All the coefficients are positive and not greater than the Bound, each variable occurs at most once
+ * and the terms are sorted by the coefficients in descending order.
+ */
+type NormalizedConstraint struct {
+	Terms []Term
+	Bound int64
+}
+
+/**
+ * Convert the constraint into the normal form. The equality is converted into two constraints.
+ */
+func (c Constraint) Normalize() (error, []NormalizedConstraint) {
+	switch c.Comparator {
+	case PB_GREATER_EQUAL:
+		return nil, []NormalizedConstraint{ normalizeGreaterEqual(c.Terms, c.Bound, 1) }
+	case PB_LESS_EQUAL:
+		return nil, []NormalizedConstraint{ normalizeGreaterEqual(c.Terms, c.Bound, -1) }
+	case PB_EQUAL:
+		return nil, []NormalizedConstraint{
+			normalizeGreaterEqual(c.Terms, c.Bound, 1),
+			normalizeGreaterEqual(c.Terms, c.Bound, -1),
+		}
+	}
+	return fmt.Errorf("Unknown pseudo-Boolean comparator '%s'.", c.Comparator), nil
+}
+
+/*
+ * Normalize (sign * sum of terms) >= (sign * bound)
+ */
+func normalizeGreaterEqual(terms []Term, bound int64, sign int64) NormalizedConstraint {
+	bound *= sign
+	// Coefficient of the positive literal for each variable
+	coefficients := map[sat_solver.CNFLiteral]int64{}
+	for _, term := range terms {
+		a := term.Coefficient * sign
+		if term.Literal < 0 {
+			// a * -x = a - a * x
+			bound -= a
+			coefficients[-term.Literal] -= a
+		} else {
+			coefficients[term.Literal] += a
+		}
+	}
+	result := NormalizedConstraint{
+		Terms: make([]Term, 0, len(coefficients)),
+	}
+	for v, a := range coefficients {
+		if a > 0 {
+			result.Terms = append(result.Terms, Term{ Coefficient: a, Literal: v })
+		} else if a < 0 {
+			// a * x = a - a * -x
+			bound -= a
+			result.Terms = append(result.Terms, Term{ Coefficient: -a, Literal: -v })
+		}
+	}
+	result.Bound = bound
+	for i := range result.Terms {
+		// Coefficients larger than the bound satisfy the constraint on their own
+		if result.Terms[i].Coefficient > bound && bound > 0 {
+			result.Terms[i].Coefficient = bound
+		}
+	}
+	sort.Slice(result.Terms, func(i, j int) bool {
+		if result.Terms[i].Coefficient != result.Terms[j].Coefficient {
+			return result.Terms[i].Coefficient > result.Terms[j].Coefficient
+		}
+		return result.Terms[i].Literal < result.Terms[j].Literal
+	})
+	return result
+}
+
+// Constraint is satisfied by any assignment
+func (c NormalizedConstraint) IsTrivial() bool {
+	return c.Bound <= 0
+}
+
+// Constraint cannot be satisfied
+func (c NormalizedConstraint) IsUnsatisfiable() bool {
+	return c.coefficientsSum() < c.Bound
+}
+
+func (c NormalizedConstraint) coefficientsSum() int64 {
+	sum := int64(0)
+	for _, term := range c.Terms {
+		sum += term.Coefficient
+	}
+	return sum
+}
+
+/**
+ * Add the constraint to the CNF formula.
+ * Constraints that are clauses or cardinality constraints are added directly (cardinality constraints are handled
+ * natively by the solver). All the other constraints are encoded into clauses using the given encoder.
+ * New variables are created using the fresh function.
+ */
+func AddConstraint(formula *sat_solver.CNFFormula, c Constraint, encoder Encoder, fresh func() sat_solver.CNFLiteral) error {
+	err, normalized := c.Normalize()
+	if err != nil {
+		return err
+	}
+	for _, nc := range normalized {
+		if nc.IsTrivial() {
+			continue
+		} else if nc.IsUnsatisfiable() {
+			formula.Variables = append(formula.Variables, sat_solver.CNFClause{})
+			continue
+		}
+
+		literals := make([]sat_solver.CNFLiteral, len(nc.Terms))
+		for i, term := range nc.Terms {
+			literals[i] = term.Literal
+		}
+		// The terms are sorted, so all the coefficients are equal if the first and the last are equal
+		coefficient := nc.Terms[0].Coefficient
+		if nc.Terms[len(nc.Terms)-1].Coefficient == coefficient {
+			k := int((nc.Bound + coefficient - 1) / coefficient)
+			if k == 1 {
+				formula.Variables = append(formula.Variables, sat_solver.CNFClause(literals))
+			} else {
+				formula.Cardinalities = append(formula.Cardinalities, sat_solver.NewAtLeastClause(literals, k))
+			}
+			continue
+		}
+
+		err, clauses := encoder.Encode(nc, fresh)
+		if err != nil {
+			return err
+		}
+		formula.Variables = append(formula.Variables, clauses...)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package pb
+
+/**
+ * This file implements the encoding of the pseudo-Boolean constraints with the sorting networks.
+ *
+ * Each literal is repeated as many times as its coefficient says and the resulting sequence is sorted
+ * by the odd-even merge sorting network (Batcher 1968). The constraint holds when the output at the position
+ * equal to the bound is true. Comparators are encoded only in the direction needed by that output,
+ * so each comparator produces three clauses.
+ * The coefficients are written in unary, so the encoding is suited for the constraints with small coefficients
+ * and the size of the network is limited by SORTER_MAX_INPUTS.
+ *
+ * For more details please see the paper by Een and Sorensson:
+ *   "Translating Pseudo-Boolean Constraints into SAT" (JSAT 2006)
+ */
+
+import (
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+// Maximal number of inputs of the sorting network (sum of the coefficients)
+const SORTER_MAX_INPUTS = 1 << 16
+
+type SorterEncoder struct {}
+
+func (encoder *SorterEncoder) GetName() string {
+	return "sorter"
+}
+
+func (encoder *SorterEncoder) Encode(c NormalizedConstraint, fresh func() sat_solver.CNFLiteral) (error, []sat_solver.CNFClause) {
+	if c.coefficientsSum() > SORTER_MAX_INPUTS {
+		return fmt.Errorf("Pseudo-Boolean constraint is too large for the sorting network encoding (sum of coefficients is %d, the limit is %d). Please use other encoding.", c.coefficientsSum(), SORTER_MAX_INPUTS), nil
+	}
+	builder := &clauseBuilder{
+		clauses: []sat_solver.CNFClause{},
+		fresh:   fresh,
+	}
+	wires := []node{}
+	for _, term := range c.Terms {
+		for i := int64(0); i < term.Coefficient; i++ {
+			wires = append(wires, literalNode(term.Literal))
+		}
+	}
+	// The network works on the powers of two, so the input is padded with false values
+	n := 1
+	for n < len(wires) {
+		n *= 2
+	}
+	for len(wires) < n {
+		wires = append(wires, nodeFalse)
+	}
+
+	// Iterative odd-even merge sort, the true values are moved to the front
+	for p := 1; p < n; p *= 2 {
+		for k := p; k >= 1; k /= 2 {
+			for j := k % p; j + k < n; j += 2*k {
+				for i := 0; i < k && i + j + k < n; i++ {
+					if (i + j) / (2*p) == (i + j + k) / (2*p) {
+						x, y := wires[i+j], wires[i+j+k]
+						wires[i+j] = builder.or(x, y)
+						wires[i+j+k] = builder.and(x, y)
+					}
+				}
+			}
+		}
+	}
+
+	builder.assert(wires[c.Bound-1])
+	return nil, builder.clauses
+}
+
+func init() {
+	RegisterEncoder(&SorterEncoder{})
+}
@@ -6,8 +6,79 @@ import "github.com/styczynski/go-sat-solver/sat_solver"
  * Eliminates x by clause distribution if the result has fewer clauses than the original
  * (after removing trivially satisfied clauses)
  */
-func (opt *SimpleOptimizer) maybeClauseDistribute(varID sat_solver.CNFLiteral) {
-	// TODO: Implement
+func (opt *SimpleOptimizer) maybeClauseDistribute(varID sat_solver.CNFLiteral) error {
+	positive := opt.occur[varID]
+	negative := opt.occur[-varID]
+	if len(positive) == 0 && len(negative) == 0 {
+		return nil
+	}
+
+	resolvents := []sat_solver.CNFClause{}
+	for p := range positive {
+		if p.isTautology() {
+			continue
+		}
+		for n := range negative {
+			if n.isTautology() {
+				continue
+			}
+			resolvent, isTautology := opt.resolve(p, n, varID)
+			if isTautology {
+				continue
+			}
+			resolvents = append(resolvents, resolvent)
+			if len(resolvents) > len(positive) + len(negative) {
+				return nil
+			}
+		}
+	}
+
+	// Remember removed clauses so the model can be extended with the value of eliminated variable
+	clausesToRemove := []*Clause{}
+	for c := range positive {
+		opt.reconstruction.Push(varID, opt.clauseLiterals(c))
+		clausesToRemove = append(clausesToRemove, c)
+	}
+	for c := range negative {
+		opt.reconstruction.Push(-varID, opt.clauseLiterals(c))
+		clausesToRemove = append(clausesToRemove, c)
+	}
+	for _, c := range clausesToRemove {
+		opt.removeClause(c)
+	}
+
+	for _, resolvent := range resolvents {
+		if len(resolvent) == 0 {
+			return sat_solver.NewUnsatError(NewUnsatReasonElimination(varID, opt))
+		}
+		opt.addClause(resolvent)
+	}
+	return nil
+}
+
+/*
+ * Resolve two clauses on the given variable.
+ * The second returned value is true if the resolvent is a tautology.
+ */
+func (opt *SimpleOptimizer) resolve(positive *Clause, negative *Clause, varID sat_solver.CNFLiteral) (sat_solver.CNFClause, bool) {
+	resolvent := sat_solver.CNFClause{}
+	for v := range positive.vars {
+		if v != varID {
+			resolvent = append(resolvent, v)
+		}
+	}
+	for v := range negative.vars {
+		if v == -varID {
+			continue
+		}
+		if _, ok := positive.vars[-v]; ok {
+			return nil, true
+		}
+		if _, ok := positive.vars[v]; !ok {
+			resolvent = append(resolvent, v)
+		}
+	}
+	return resolvent, false
 }
 
 func (opt *SimpleOptimizer) tryDistributeClauses() bool {
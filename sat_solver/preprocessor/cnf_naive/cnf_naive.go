@@ -26,7 +26,7 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping)
 	// For variable return formula unmodified
 	if expr.Variable != nil {
 		return nil, &sat_solver.CNFFormula{
-			[]sat_solver.CNFClause{ { vars.Get(expr.Variable.Name), } },
+			Variables: []sat_solver.CNFClause{ { vars.Get(expr.Variable.Name), } },
 		}
 	} else if expr.And != nil {
 		err, arg1 := convertToCnf(expr.And.Arg1, vars)
@@ -69,7 +69,7 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping)
 		// Not with variable
 		if inner.Variable != nil {
 			return nil, &sat_solver.CNFFormula{
-				[]sat_solver.CNFClause{ { -vars.Get(inner.Variable.Name), } },
+				Variables: []sat_solver.CNFClause{ { -vars.Get(inner.Variable.Name), } },
 			}
 		} else if inner.Not != nil {
 			// Double not
@@ -90,14 +90,20 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping)
 			return convertToCnf(sat_solver.MakeOr(
 				sat_solver.MakeAnd(inner.Iff.Arg1, sat_solver.MakeNot(inner.Iff.Arg2)),
 				sat_solver.MakeAnd(inner.Iff.Arg2, sat_solver.MakeNot(inner.Iff.Arg1))), vars)
+		} else if inner.Xor != nil {
+			return convertToCnf(sat_solver.MakeOr(
+				sat_solver.MakeAnd(inner.Xor.Arg1, inner.Xor.Arg2),
+				sat_solver.MakeAnd(sat_solver.MakeNot(inner.Xor.Arg1), sat_solver.MakeNot(inner.Xor.Arg2))), vars)
+		} else if inner.Cardinality != nil {
+			return convertToCnf(sat_solver.MakeNot(inner.Cardinality.Expand()), vars)
 		} else if inner.Constant != nil {
 			if inner.Constant.Bool == "F" {
 				return nil, &sat_solver.CNFFormula{
-					[]sat_solver.CNFClause{ },
+					Variables: []sat_solver.CNFClause{ },
 				}
 			} else {
 				return nil, &sat_solver.CNFFormula{
-					[]sat_solver.CNFClause{ { } },
+					Variables: []sat_solver.CNFClause{ { } },
 				}
 			}
 		}
@@ -109,14 +115,20 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping)
 		return convertToCnf(sat_solver.MakeOr(
 			sat_solver.MakeAnd(expr.Iff.Arg1, expr.Iff.Arg2),
 			sat_solver.MakeAnd(sat_solver.MakeNot(expr.Iff.Arg1), sat_solver.MakeNot(expr.Iff.Arg2))), vars)
+	} else if expr.Xor != nil {
+		return convertToCnf(sat_solver.MakeOr(
+			sat_solver.MakeAnd(expr.Xor.Arg1, sat_solver.MakeNot(expr.Xor.Arg2)),
+			sat_solver.MakeAnd(sat_solver.MakeNot(expr.Xor.Arg1), expr.Xor.Arg2)), vars)
+	} else if expr.Cardinality != nil {
+		return convertToCnf(expr.Cardinality.Expand(), vars)
 	} else if expr.Constant != nil {
 		if expr.Constant.Bool == "T" {
 			return nil, &sat_solver.CNFFormula{
-				[]sat_solver.CNFClause{ },
+				Variables: []sat_solver.CNFClause{ },
 			}
 		} else {
 			return nil, &sat_solver.CNFFormula{
-				[]sat_solver.CNFClause{ { } },
+				Variables: []sat_solver.CNFClause{ { } },
 			}
 		}
 	}
@@ -69,6 +69,31 @@ func convertToCnfNaive(expr *sat_solver.Formula, vars *sat_solver.SATVariableMap
 		name, _ := vars.Fresh()
 		*ts = append(*ts, sat_solver.MakeIff(sat_solver.MakeVar(name), sat_solver.MakeIff(leftVar, rightVar)))
 		return nil, sat_solver.MakeVar(name), false
+	} else if expr.Xor != nil {
+		err, leftVar, _ := convertToCnfNaive(expr.Xor.Arg1, vars, ts)
+		if err != nil {
+			return err, nil, false
+		}
+		err, rightVar, _ := convertToCnfNaive(expr.Xor.Arg2, vars, ts)
+		if err != nil {
+			return err, nil, false
+		}
+		name, _ := vars.Fresh()
+		*ts = append(*ts, sat_solver.MakeIff(sat_solver.MakeVar(name), sat_solver.MakeXor(leftVar, rightVar)))
+		return nil, sat_solver.MakeVar(name), false
+	} else if expr.Cardinality != nil {
+		args := make([]*sat_solver.Formula, len(expr.Cardinality.Args))
+		for i, arg := range expr.Cardinality.Args {
+			err, argVar, _ := convertToCnfNaive(arg, vars, ts)
+			if err != nil {
+				return err, nil, false
+			}
+			args[i] = argVar
+		}
+		name, _ := vars.Fresh()
+		*ts = append(*ts, sat_solver.MakeIff(sat_solver.MakeVar(name),
+			sat_solver.MakeCardinality(expr.Cardinality.Kind, expr.Cardinality.Bound, args)))
+		return nil, sat_solver.MakeVar(name), false
 	} else if expr.Constant != nil {
 		return nil, expr, true
 	}
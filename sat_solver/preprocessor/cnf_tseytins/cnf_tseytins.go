@@ -20,17 +20,34 @@ func copyAndAppend(i []int64, vals ...int64) []int64 {
 	return append(j, vals...)
 }
 
-func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping, ts *[]sat_solver.CNFClause) (error, sat_solver.CNFLiteral, sat_solver.CNFLiteral) {
+/*
+ * Convert the formula and return the literal equivalent to it.
+ * The formula can be a DAG (the loaders share the subformulas, for example the let-bindings), so each node
+ * is converted only once and the next uses get the same literal.
+ */
+func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping, ts *[]sat_solver.CNFClause, xs *[]sat_solver.XORClause, cs *[]sat_solver.CardinalityClause, converted map[*sat_solver.Formula]sat_solver.CNFLiteral) (error, sat_solver.CNFLiteral, sat_solver.CNFLiteral) {
+	if literal, ok := converted[expr]; ok {
+		return nil, literal, 0
+	}
+	err, literal, topLevelVar := convertNode(expr, vars, ts, xs, cs, converted)
+	if err != nil {
+		return err, 0, 0
+	}
+	converted[expr] = literal
+	return nil, literal, topLevelVar
+}
+
+func convertNode(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping, ts *[]sat_solver.CNFClause, xs *[]sat_solver.XORClause, cs *[]sat_solver.CardinalityClause, converted map[*sat_solver.Formula]sat_solver.CNFLiteral) (error, sat_solver.CNFLiteral, sat_solver.CNFLiteral) {
 	// For variable return formula unmodified
 	if expr.Variable != nil {
 		v := vars.Get(expr.Variable.Name)
 		return nil, v, v
 	} else if expr.And != nil {
-		err, leftVar, _ := convertToCnf(expr.And.Arg1, vars, ts)
+		err, leftVar, _ := convertToCnf(expr.And.Arg1, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
-		err, rightVar, _ := convertToCnf(expr.And.Arg2, vars, ts)
+		err, rightVar, _ := convertToCnf(expr.And.Arg2, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
@@ -46,11 +63,11 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping,
 		//	sat_solver.MakeOr(sat_solver.MakeOr(sat_solver.MakeNot(b), sat_solver.MakeNot(c)), a))
 		return nil, a, 0
 	} else if expr.Or != nil {
-		err, leftVar, _ := convertToCnf(expr.Or.Arg1, vars, ts)
+		err, leftVar, _ := convertToCnf(expr.Or.Arg1, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
-		err, rightVar, _ := convertToCnf(expr.Or.Arg2, vars, ts)
+		err, rightVar, _ := convertToCnf(expr.Or.Arg2, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
@@ -70,7 +87,7 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping,
 			v := vars.Get(expr.Not.Formula.Variable.Name)
 			return nil, -v, -v
 		}
-		err, argVar, _ := convertToCnf(expr.Not.Formula, vars, ts)
+		err, argVar, _ := convertToCnf(expr.Not.Formula, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
@@ -83,11 +100,11 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping,
 		*ts = append(*ts, sat_solver.CNFClause{-a, -b}, sat_solver.CNFClause{b, a})
 		return nil, a, 0
 	} else if expr.Implies != nil {
-		err, leftVar, _ := convertToCnf(expr.Implies.Arg1, vars, ts)
+		err, leftVar, _ := convertToCnf(expr.Implies.Arg1, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
-		err, rightVar, _ := convertToCnf(expr.Implies.Arg2, vars, ts)
+		err, rightVar, _ := convertToCnf(expr.Implies.Arg2, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
@@ -104,11 +121,11 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping,
 		//	sat_solver.MakeOr(sat_solver.MakeNot(c), a),)
 		return nil, a, 0
 	} else if expr.Iff != nil {
-		err, leftVar, _ := convertToCnf(expr.Iff.Arg1, vars, ts)
+		err, leftVar, _ := convertToCnf(expr.Iff.Arg1, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
-		err, rightVar, _ := convertToCnf(expr.Iff.Arg2, vars, ts)
+		err, rightVar, _ := convertToCnf(expr.Iff.Arg2, vars, ts, xs, cs, converted)
 		if err != nil {
 			return err, 0, 0
 		}
@@ -133,6 +150,49 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping,
 		//	sat_solver.MakeOr(sat_solver.MakeOr(sat_solver.MakeNot(c), sat_solver.MakeNot(a)), b),
 		//	sat_solver.MakeOr(sat_solver.MakeOr(sat_solver.MakeNot(c), sat_solver.MakeNot(b)), a))
 		return nil, a, 0
+	} else if expr.Xor != nil {
+		literals := []sat_solver.CNFLiteral{}
+		err := collectXorOperands(expr, vars, ts, xs, cs, converted, &literals)
+		if err != nil {
+			return err, 0, 0
+		}
+		_, newVar := vars.Fresh()
+
+		// The XOR is not encoded into clauses, but kept as a native constraint:
+		//   a = b1 x b2 x ... x bn  <=>  (a x b1 x b2 x ... x bn = F)
+		*xs = append(*xs, sat_solver.NewXORClause(append(literals, newVar), false))
+		return nil, newVar, 0
+	} else if expr.Cardinality != nil {
+		err, constraints := convertCardinalityOperands(expr.Cardinality, vars, ts, xs, cs, converted)
+		if err != nil {
+			return err, 0, 0
+		}
+
+		// Each constraint c is kept as a native constraint and reified with a fresh variable t (t <=> c)
+		definitions := make([]sat_solver.CNFLiteral, len(constraints))
+		for i, c := range constraints {
+			if c.IsTrivial() {
+				definitions[i] = 1
+			} else if c.IsUnsatisfiable() {
+				definitions[i] = -1
+			} else {
+				_, t := vars.Fresh()
+				*cs = append(*cs, c.Reify(t)...)
+				definitions[i] = t
+			}
+		}
+		if len(definitions) == 1 {
+			return nil, definitions[0], 0
+		}
+		_, newVar := vars.Fresh()
+
+		a := newVar
+		b := definitions[0]
+		c := definitions[1]
+
+		// (~a | b) & (~a | c) & (~b | ~c | a)
+		*ts = append(*ts, sat_solver.CNFClause{-a, b}, sat_solver.CNFClause{-a, c}, sat_solver.CNFClause{a, -b, -c})
+		return nil, a, 0
 	} else if expr.Constant != nil {
 		if expr.Constant.Bool == "T" {
 			return nil, 1, 0
@@ -144,6 +204,55 @@ func convertToCnf(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping,
 	return fmt.Errorf("Invalid formula given to convertToCnf: %#v", expr), 0, 0
 }
 
+/*
+ * Nested XORs are flattened, so a chain of XORs becomes a single parity constraint
+ */
+func collectXorOperands(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping, ts *[]sat_solver.CNFClause, xs *[]sat_solver.XORClause, cs *[]sat_solver.CardinalityClause, converted map[*sat_solver.Formula]sat_solver.CNFLiteral, literals *[]sat_solver.CNFLiteral) error {
+	for _, arg := range []*sat_solver.Formula{ expr.Xor.Arg1, expr.Xor.Arg2 } {
+		// Nested XOR that was already converted elsewhere is used as a single literal
+		if _, isConverted := converted[arg]; arg.Xor != nil && !isConverted {
+			err := collectXorOperands(arg, vars, ts, xs, cs, converted, literals)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err, argVar, _ := convertToCnf(arg, vars, ts, xs, cs, converted)
+		if err != nil {
+			return err
+		}
+		*literals = append(*literals, argVar)
+	}
+	return nil
+}
+
+/*
+ * Convert arguments of the cardinality constraint to literals and create the constraints (Exactly creates two of them)
+ */
+func convertCardinalityOperands(expr *sat_solver.Cardinality, vars *sat_solver.SATVariableMapping, ts *[]sat_solver.CNFClause, xs *[]sat_solver.XORClause, cs *[]sat_solver.CardinalityClause, converted map[*sat_solver.Formula]sat_solver.CNFLiteral) (error, []sat_solver.CardinalityClause) {
+	literals := make([]sat_solver.CNFLiteral, len(expr.Args))
+	for i, arg := range expr.Args {
+		err, argVar, _ := convertToCnf(arg, vars, ts, xs, cs, converted)
+		if err != nil {
+			return err, nil
+		}
+		literals[i] = argVar
+	}
+	return nil, sat_solver.NewCardinalityClauses(expr.Kind, literals, expr.Bound)
+}
+
+/*
+ * Split the top-level conjunction into separate formulas
+ */
+func collectConjuncts(expr *sat_solver.Formula, conjuncts *[]*sat_solver.Formula) {
+	if expr.And != nil {
+		collectConjuncts(expr.And.Arg1, conjuncts)
+		collectConjuncts(expr.And.Arg2, conjuncts)
+		return
+	}
+	*conjuncts = append(*conjuncts, expr)
+}
+
 func eliminateCNFTF(formula *sat_solver.SATFormula) (error, *sat_solver.SATFormula) {
 	if v, ok := formula.Formula().(*sat_solver.CNFFormula); ok {
 		newVars := make([]sat_solver.CNFClause, 0, len(v.Variables))
@@ -174,8 +283,30 @@ func eliminateCNFTF(formula *sat_solver.SATFormula) (error, *sat_solver.SATFormu
 			}
 		}
 
+		newXors := make([]sat_solver.XORClause, 0, len(v.Xors))
+		for _, xor := range v.Xors {
+			if len(xor.Vars) == 0 {
+				if xor.Parity {
+					return sat_solver.NewUnsatError(sat_solver.NewUnsatReasonCNFNormalization()), nil
+				}
+				continue
+			}
+			newXors = append(newXors, xor)
+		}
+
+		newCardinalities := make([]sat_solver.CardinalityClause, 0, len(v.Cardinalities))
+		for _, c := range v.Cardinalities {
+			if c.IsUnsatisfiable() {
+				return sat_solver.NewUnsatError(sat_solver.NewUnsatReasonCNFNormalization()), nil
+			} else if !c.IsTrivial() {
+				newCardinalities = append(newCardinalities, c)
+			}
+		}
+
 		res := sat_solver.NewSATFormula(&sat_solver.CNFFormula{
-			Variables: newVars,
+			Variables:     newVars,
+			Xors:          newXors,
+			Cardinalities: newCardinalities,
 		}, formula.Variables(), nil)
 
 		return nil, res
@@ -191,20 +322,39 @@ func ConvertToCNFTseytins(formula *sat_solver.Formula, context *sat_solver.SATCo
 
 	vars := sat_solver.NewSATVariableMapping()
 	ts := []sat_solver.CNFClause{}
-	err, f, topLevelVar := convertToCnf(formula, vars, &ts)
-	if err != nil {
-		return err, nil
-	}
+	xs := []sat_solver.XORClause{}
+	cs := []sat_solver.CardinalityClause{}
+	converted := map[*sat_solver.Formula]sat_solver.CNFLiteral{}
 
-	// Add substitution for the entire formula
-	if topLevelVar != 0 {
-		ts = append(ts, sat_solver.CNFClause{ topLevelVar })
-	} else {
-		ts = append(ts, sat_solver.CNFClause{ f })
+	// Top-level conjuncts are converted separately, so the top-level cardinality constraints do not need to be reified
+	conjuncts := []*sat_solver.Formula{}
+	collectConjuncts(formula, &conjuncts)
+	for _, conjunct := range conjuncts {
+		if conjunct.Cardinality != nil {
+			err, constraints := convertCardinalityOperands(conjunct.Cardinality, vars, &ts, &xs, &cs, converted)
+			if err != nil {
+				return err, nil
+			}
+			cs = append(cs, constraints...)
+			continue
+		}
+		err, f, topLevelVar := convertToCnf(conjunct, vars, &ts, &xs, &cs, converted)
+		if err != nil {
+			return err, nil
+		}
+
+		// Add substitution for the entire formula
+		if topLevelVar != 0 {
+			ts = append(ts, sat_solver.CNFClause{ topLevelVar })
+		} else {
+			ts = append(ts, sat_solver.CNFClause{ f })
+		}
 	}
 
 	tseytinsCnf := sat_solver.NewSATFormula(&sat_solver.CNFFormula{
-		Variables: ts,
+		Variables:     ts,
+		Xors:          xs,
+		Cardinalities: cs,
 	}, vars, nil)
 	err, tseytinsCnf = eliminateCNFTF(tseytinsCnf)
 	if err != nil {
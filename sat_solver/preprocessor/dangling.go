@@ -9,7 +9,7 @@ func (opt *SimpleOptimizer) RemoveDanglingVariables() {
 func (opt *SimpleOptimizer) tryRemoveDanglingVariables() bool {
 	varsToRemove := map[sat_solver.CNFLiteral]struct{}{}
 	for v, occurs := range opt.occur {
-		if len(occurs) > 0 && len(opt.occur[-v]) == 0 {
+		if len(occurs) > 0 && len(opt.occur[-v]) == 0 && !opt.isFrozen(v) {
 			// Negated variable does not occur anywhere
 			varsToRemove[v] = struct{}{}
 		}
@@ -22,6 +22,7 @@ func (opt *SimpleOptimizer) tryRemoveDanglingVariables() bool {
 	// Remove all unwanted clauses
 	for v := range varsToRemove {
 		for c := range opt.occur[v] {
+			opt.reconstruction.Push(v, opt.clauseLiterals(c))
 			opt.removeClause(c)
 		}
 	}
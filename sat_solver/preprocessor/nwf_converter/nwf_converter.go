@@ -136,11 +136,57 @@ func convert(expr *sat_solver.Formula, vars *sat_solver.SATVariableMapping) (err
 			Or:       nil,
 			Variable: nil,
 		}).UpdateTopNodeMetrics()
+	} else if expr.Cardinality != nil {
+		return convert(expr.Cardinality.Expand(), vars)
+	} else if expr.Xor != nil {
+		err, e1 := convert(expr.Xor.Arg1, vars)
+		if err != nil {
+			return err, nil
+		}
+
+		err, e2 := convert(expr.Xor.Arg2, vars)
+		if err != nil {
+			return err, nil
+		}
+		ne1 := e1.Copy()
+		ne1.Negate()
+		ne2 := e2.Copy()
+		ne2.Negate()
+
+		return nil, (&sat_solver.NWFFormula{
+			And: &sat_solver.NWFAnd{
+				Arg1: (&sat_solver.NWFFormula{
+					And:      nil,
+					Or:       &sat_solver.NWFOr{
+						Arg1:  e1,
+						Arg2:  e2,
+						IsNeg: false,
+					},
+					Variable: nil,
+				}).UpdateTopNodeMetrics(),
+				Arg2: (&sat_solver.NWFFormula{
+					And:      nil,
+					Or:       &sat_solver.NWFOr{
+						Arg1:  ne1,
+						Arg2:  ne2,
+						IsNeg: false,
+					},
+					Variable: nil,
+				}).UpdateTopNodeMetrics(),
+				IsNeg: false,
+			},
+			Or:       nil,
+			Variable: nil,
+		}).UpdateTopNodeMetrics()
 	}
 
 	return fmt.Errorf("NWF Could not convert unknown boolean expression."), nil
 }
 
+/*
+ * Simplify the constants and the duplicated arguments in the formula.
+ * The subformulas can be shared (for example by both halves of Iff), so they are copied before being negated.
+ */
 func optimizeTree(formula *sat_solver.NWFFormula, changeDetected *bool) (error, *sat_solver.NWFFormula) {
 	if formula.Or != nil {
 		err, opt1 := optimizeTree(formula.Or.Arg1, changeDetected)
@@ -177,6 +223,7 @@ func optimizeTree(formula *sat_solver.NWFFormula, changeDetected *bool) (error,
 			} else if !opt1.Const.Value {
 				*changeDetected = true
 				if formula.Or.IsNeg {
+					opt2 = opt2.Copy()
 					opt2.Negate()
 				}
 				return nil, opt2
@@ -200,6 +247,7 @@ func optimizeTree(formula *sat_solver.NWFFormula, changeDetected *bool) (error,
 			} else if !opt2.Const.Value {
 				*changeDetected = true
 				if formula.Or.IsNeg {
+					opt1 = opt1.Copy()
 					opt1.Negate()
 				}
 				return nil, opt1
@@ -215,6 +263,11 @@ func optimizeTree(formula *sat_solver.NWFFormula, changeDetected *bool) (error,
 			//fmt.Printf("collapse %s and %s (%d vs %d)\n", opt1s, opt2s, opt1complex, opt2complex)
 			if opt1s == opt2s {
 				*changeDetected = true
+				// Both arguments are the same, so only the negation of the node is left
+				if formula.Or.IsNeg {
+					opt1 = opt1.Copy()
+					opt1.Negate()
+				}
 				return nil, opt1.UpdateTopNodeMetrics()
 			}
 		}
@@ -261,6 +314,7 @@ func optimizeTree(formula *sat_solver.NWFFormula, changeDetected *bool) (error,
 			} else if opt1.Const.Value {
 				*changeDetected = true
 				if formula.And.IsNeg {
+					opt2 = opt2.Copy()
 					opt2.Negate()
 				}
 				return nil, opt2
@@ -274,7 +328,7 @@ func optimizeTree(formula *sat_solver.NWFFormula, changeDetected *bool) (error,
 						Value: false,
 					},
 				}
-			} else if opt2.Const.Value && formula.And.IsNeg {
+			} else if !opt2.Const.Value && formula.And.IsNeg {
 				*changeDetected = true
 				return nil, &sat_solver.NWFFormula{
 					Const: &sat_solver.NWFConst{
@@ -284,6 +338,7 @@ func optimizeTree(formula *sat_solver.NWFFormula, changeDetected *bool) (error,
 			} else if opt2.Const.Value {
 				*changeDetected = true
 				if formula.And.IsNeg {
+					opt1 = opt1.Copy()
 					opt1.Negate()
 				}
 				return nil, opt1
@@ -299,6 +354,11 @@ func optimizeTree(formula *sat_solver.NWFFormula, changeDetected *bool) (error,
 			//fmt.Printf("collapse %s and %s (%d vs %d)\n", opt1s, opt2s, opt1complex, opt2complex)
 			if opt1s == opt2s {
 				*changeDetected = true
+				// Both arguments are the same, so only the negation of the node is left
+				if formula.And.IsNeg {
+					opt1 = opt1.Copy()
+					opt1.Negate()
+				}
 				return nil, opt1.UpdateTopNodeMetrics()
 			}
 		}
@@ -0,0 +1,91 @@
+package preprocessor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+/**
+ * Single simplification step working on the clause database of the SimpleOptimizer.
+ * Passes are looked up by name, so the pipeline can be configured with a string like "up,subsume,bve".
+ */
+type PreprocessingPass interface {
+	Run(opt *SimpleOptimizer) error
+	GetName() string
+}
+
+var DEFAULT_PREPROCESSING_PIPELINE = "up,taut,subsume,bve,bce,pure"
+var PREPROCESSING_PASSES = map[string]PreprocessingPass{}
+
+func RegisterPreprocessingPass(pass PreprocessingPass) {
+	PREPROCESSING_PASSES[pass.GetName()] = pass
+}
+
+/**
+ * Parse comma-separated list of pass names.
+ * Empty specification means the default pipeline. The same pass can be used multiple times.
+ */
+func ParsePreprocessingPipeline(spec string) (error, []PreprocessingPass) {
+	if len(strings.TrimSpace(spec)) == 0 {
+		spec = DEFAULT_PREPROCESSING_PIPELINE
+	}
+	passes := []PreprocessingPass{}
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		if pass, ok := PREPROCESSING_PASSES[name]; ok {
+			passes = append(passes, pass)
+		} else {
+			return fmt.Errorf("Preprocessing pass with name '%s' not found.", name), nil
+		}
+	}
+	return nil, passes
+}
+
+/**
+ * Result of a single pass reported to the event collector.
+ */
+type PreprocessingPassResult struct {
+	clausesBefore int
+	clausesAfter int
+	literalsBefore int
+	literalsAfter int
+	opt *SimpleOptimizer
+}
+
+func (result *PreprocessingPassResult) Brief() string {
+	return fmt.Sprintf("clauses: %d -> %d (%+d), literals: %d -> %d (%+d)",
+		result.clausesBefore, result.clausesAfter, result.clausesAfter-result.clausesBefore,
+		result.literalsBefore, result.literalsAfter, result.literalsAfter-result.literalsBefore)
+}
+
+func (result *PreprocessingPassResult) String() string {
+	return result.opt.String()
+}
+
+func (result *PreprocessingPassResult) ToSATFormula() *sat_solver.SATFormula {
+	return result.opt.Formula()
+}
+
+func (opt *SimpleOptimizer) runPass(pass PreprocessingPass) error {
+	err, newContext := opt.context.StartProcessing(fmt.Sprintf("Preprocessing pass '%s'", pass.GetName()), "")
+	if err != nil {
+		return err
+	}
+	result := &PreprocessingPassResult{
+		clausesBefore:  len(opt.clauses),
+		literalsBefore: opt.literalsCount(),
+		opt:            opt,
+	}
+	err = pass.Run(opt)
+	if err != nil {
+		return err
+	}
+	result.clausesAfter = len(opt.clauses)
+	result.literalsAfter = opt.literalsCount()
+	return newContext.EndProcessingFormula(result)
+}
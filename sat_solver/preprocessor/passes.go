@@ -0,0 +1,84 @@
+package preprocessor
+
+type unitPropagationPass struct {}
+
+func (pass *unitPropagationPass) GetName() string {
+	return "up"
+}
+
+func (pass *unitPropagationPass) Run(opt *SimpleOptimizer) error {
+	return opt.PerformUnitPropagation()
+}
+
+type tautologyPass struct {}
+
+func (pass *tautologyPass) GetName() string {
+	return "taut"
+}
+
+func (pass *tautologyPass) Run(opt *SimpleOptimizer) error {
+	opt.OptimizeTrivialTautologies()
+	return nil
+}
+
+type subsumptionPass struct {}
+
+func (pass *subsumptionPass) GetName() string {
+	return "subsume"
+}
+
+func (pass *subsumptionPass) Run(opt *SimpleOptimizer) error {
+	return opt.SubsumeClauses()
+}
+
+type variableEliminationPass struct {}
+
+func (pass *variableEliminationPass) GetName() string {
+	return "bve"
+}
+
+func (pass *variableEliminationPass) Run(opt *SimpleOptimizer) error {
+	return opt.EliminateVariables()
+}
+
+type blockedClauseEliminationPass struct {}
+
+func (pass *blockedClauseEliminationPass) GetName() string {
+	return "bce"
+}
+
+func (pass *blockedClauseEliminationPass) Run(opt *SimpleOptimizer) error {
+	for opt.blockedClauseElimination() {}
+	return nil
+}
+
+type pureLiteralPass struct {}
+
+func (pass *pureLiteralPass) GetName() string {
+	return "pure"
+}
+
+func (pass *pureLiteralPass) Run(opt *SimpleOptimizer) error {
+	opt.RemoveDanglingVariables()
+	return nil
+}
+
+type probingPass struct {}
+
+func (pass *probingPass) GetName() string {
+	return "probe"
+}
+
+func (pass *probingPass) Run(opt *SimpleOptimizer) error {
+	return opt.ProbeFailedLiterals()
+}
+
+func init() {
+	RegisterPreprocessingPass(&unitPropagationPass{})
+	RegisterPreprocessingPass(&tautologyPass{})
+	RegisterPreprocessingPass(&subsumptionPass{})
+	RegisterPreprocessingPass(&variableEliminationPass{})
+	RegisterPreprocessingPass(&blockedClauseEliminationPass{})
+	RegisterPreprocessingPass(&pureLiteralPass{})
+	RegisterPreprocessingPass(&probingPass{})
+}
@@ -0,0 +1,98 @@
+package preprocessor
+
+import (
+	"sort"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+const PROBING_LITERALS_LIMIT = 1000
+
+/*
+ * Failed literal probing
+ * Each candidate literal is assumed and propagated over the clause database.
+ * If that leads to a conflict the negation of the literal is added as a unit clause.
+ * Only literals that occur negated in binary clauses are probed as only these imply anything.
+ * The candidates that imply the most literals directly are probed first (ties are broken by the literal),
+ * so the same literals are probed in every run when the limit is reached.
+ */
+func (opt *SimpleOptimizer) ProbeFailedLiterals() error {
+	// Number of the binary clauses in which the candidate occurs negated
+	binaryOccurrences := map[sat_solver.CNFLiteral]int{}
+	for c := range opt.clauses {
+		if len(c.vars) == 2 {
+			for v := range c.vars {
+				binaryOccurrences[-v]++
+			}
+		}
+	}
+	candidates := make([]sat_solver.CNFLiteral, 0, len(binaryOccurrences))
+	for literal := range binaryOccurrences {
+		candidates = append(candidates, literal)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if binaryOccurrences[a] != binaryOccurrences[b] {
+			return binaryOccurrences[a] > binaryOccurrences[b]
+		}
+		return a < b
+	})
+
+	probesCount := 0
+	for _, literal := range candidates {
+		if probesCount >= PROBING_LITERALS_LIMIT {
+			break
+		}
+		if len(opt.occur[literal]) == 0 && len(opt.occur[-literal]) == 0 {
+			// Variable was already removed
+			continue
+		}
+		probesCount++
+		if opt.probe(literal) {
+			opt.addClause(sat_solver.CNFClause{ -literal })
+			err := opt.PerformUnitPropagation()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Returns true if assuming the literal leads to a conflict
+func (opt *SimpleOptimizer) probe(literal sat_solver.CNFLiteral) bool {
+	assigned := map[sat_solver.CNFLiteral]struct{}{
+		literal: {},
+	}
+	queue := []sat_solver.CNFLiteral{ literal }
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+		for c := range opt.occur[-l] {
+			isSatisfied := false
+			unassignedCount := 0
+			lastUnassigned := sat_solver.CNFLiteral(0)
+			for v := range c.vars {
+				if _, ok := assigned[v]; ok {
+					isSatisfied = true
+					break
+				}
+				if _, ok := assigned[-v]; !ok {
+					unassignedCount++
+					lastUnassigned = v
+				}
+			}
+			if isSatisfied {
+				continue
+			}
+			if unassignedCount == 0 {
+				return true
+			}
+			if unassignedCount == 1 {
+				assigned[lastUnassigned] = struct{}{}
+				queue = append(queue, lastUnassigned)
+			}
+		}
+	}
+	return false
+}
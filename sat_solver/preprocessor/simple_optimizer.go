@@ -41,6 +41,20 @@ func (reason *UnsatReasonStrengthening) Describe() string {
 	return fmt.Sprintf("Strengthening clause %s by variable %s produced an empty clause.", reason.clause, reason.varName)
 }
 
+type UnsatReasonElimination struct {
+	varName string
+}
+
+func NewUnsatReasonElimination(varID sat_solver.CNFLiteral, opt *SimpleOptimizer) *UnsatReasonElimination {
+	return &UnsatReasonElimination{
+		varName: opt.vars.Reverse(varID),
+	}
+}
+
+func (reason *UnsatReasonElimination) Describe() string {
+	return fmt.Sprintf("Eliminating variable %s produced an empty resolvent.", reason.varName)
+}
+
 type Clause struct {
 	hash int64
 	vars map[sat_solver.CNFLiteral]struct{}
@@ -62,6 +76,15 @@ func (c *Clause) negateClauseVar(varIDToNegate sat_solver.CNFLiteral) *Clause {
 	}
 }
 
+func (c *Clause) isTautology() bool {
+	for v := range c.vars {
+		if _, ok := c.vars[-v]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Clause) String(bve *SimpleOptimizer) string {
 	strs := []string{}
 	for v := range c.vars {
@@ -89,6 +112,14 @@ type SimpleOptimizer struct {
 
 	visitedUnits map[sat_solver.CNFLiteral]struct{}
 
+	reconstruction *sat_solver.ReconstructionStack
+
+	// XOR and cardinality constraints are not simplified (except for the unit propagation),
+	// so their variables cannot be eliminated
+	xors []sat_solver.XORClause
+	cardinalities []sat_solver.CardinalityClause
+	frozen map[sat_solver.CNFLiteral]struct{}
+
 	vars *sat_solver.SATVariableMapping
 	context *sat_solver.SATContext
 }
@@ -108,7 +139,129 @@ func (opt *SimpleOptimizer) Formula() *sat_solver.SATFormula {
 		newFormula.Variables[i] = newClause
 		i++
 	}
-	return sat_solver.NewSATFormula(&newFormula, opt.vars, nil)
+	newFormula.Xors = opt.xors
+	newFormula.Cardinalities = opt.cardinalities
+	return sat_solver.NewSATFormulaWithReconstruction(&newFormula, opt.vars, nil, opt.reconstruction)
+}
+
+func (opt *SimpleOptimizer) isFrozen(varID sat_solver.CNFLiteral) bool {
+	_, ok := opt.frozen[varID.Var()]
+	return ok
+}
+
+/*
+ * Substitute value of the literal into the XOR constraints
+ * XORs that have only one variable left are turned into unit clauses.
+ */
+func (opt *SimpleOptimizer) assignXorVariable(literal sat_solver.CNFLiteral) error {
+	value := sat_solver.CNFLiteral(-1)
+	if literal > 0 {
+		value = 1
+	}
+	newXors := make([]sat_solver.XORClause, 0, len(opt.xors))
+	for _, xor := range opt.xors {
+		literals := make([]sat_solver.CNFLiteral, len(xor.Vars))
+		for i, v := range xor.Vars {
+			if v == literal.Var() {
+				literals[i] = value
+			} else {
+				literals[i] = v
+			}
+		}
+		xor = sat_solver.NewXORClause(literals, xor.Parity)
+		if len(xor.Vars) == 0 {
+			if xor.Parity {
+				return sat_solver.NewUnsatError(NewUnsatReasonUP())
+			}
+			continue
+		} else if len(xor.Vars) == 1 {
+			if xor.Parity {
+				opt.addClause(sat_solver.CNFClause{ xor.Vars[0] })
+			} else {
+				opt.addClause(sat_solver.CNFClause{ -xor.Vars[0] })
+			}
+		}
+		newXors = append(newXors, xor)
+	}
+	opt.xors = newXors
+	return nil
+}
+
+/*
+ * Substitute value of the literal into the cardinality constraints
+ * Constraints that require all the remaining literals to be true are replaced with unit clauses.
+ */
+func (opt *SimpleOptimizer) assignCardinalityVariable(literal sat_solver.CNFLiteral) error {
+	newCardinalities := make([]sat_solver.CardinalityClause, 0, len(opt.cardinalities))
+	for _, c := range opt.cardinalities {
+		literals := make([]sat_solver.CNFLiteral, len(c.Literals))
+		for i, l := range c.Literals {
+			if l == literal {
+				literals[i] = 1
+			} else if l == -literal {
+				literals[i] = -1
+			} else {
+				literals[i] = l
+			}
+		}
+		c = sat_solver.NewAtLeastClause(literals, c.Bound)
+		if c.IsUnsatisfiable() {
+			return sat_solver.NewUnsatError(NewUnsatReasonUP())
+		} else if c.IsTrivial() {
+			continue
+		} else if c.Bound == len(c.Literals) {
+			for _, l := range c.Literals {
+				opt.addClause(sat_solver.CNFClause{ l })
+			}
+			continue
+		}
+		newCardinalities = append(newCardinalities, c)
+	}
+	opt.cardinalities = newCardinalities
+	return nil
+}
+
+func (opt *SimpleOptimizer) literalsCount() int {
+	count := 0
+	for c := range opt.clauses {
+		count += len(c.vars)
+	}
+	return count
+}
+
+func (opt *SimpleOptimizer) clauseLiterals(clause *Clause) sat_solver.CNFClause {
+	literals := make(sat_solver.CNFClause, 0, len(clause.vars))
+	for v := range clause.vars {
+		literals = append(literals, v)
+	}
+	return literals
+}
+
+// Add new clause to the formula and update all the bookkeeping
+func (opt *SimpleOptimizer) addClause(literals sat_solver.CNFClause) *Clause {
+	c := &Clause{
+		vars:      map[sat_solver.CNFLiteral]struct{}{},
+		isDeleted: false,
+	}
+	for _, v := range literals {
+		c.vars[v] = struct{}{}
+		if _, ok := opt.occur[v]; !ok {
+			opt.occur[v] = map[*Clause]struct{}{}
+		}
+		if _, ok := opt.occur[-v]; !ok {
+			opt.occur[-v] = map[*Clause]struct{}{}
+		}
+		opt.occur[v][c] = struct{}{}
+		opt.touched[v] = struct{}{}
+	}
+	c.Rehash()
+	opt.clauses[c] = struct{}{}
+	opt.added[c] = struct{}{}
+	if len(c.vars) == 1 {
+		opt.singular[c] = struct{}{}
+	}
+	opt.validateState()
+	return c
 }
 
 func (opt *SimpleOptimizer) ToSATFormula() *sat_solver.SATFormula {
@@ -173,7 +326,7 @@ func (opt *SimpleOptimizer) findSubsumed(clause *Clause) []*Clause {
 	for cPrim := range opt.occur[pLit] {
 		if !cPrim.isDeleted {
 			//fmt.Printf("Check %s <%p> and %s <%p>\n", clause.String(opt), clause, cPrim.String(opt), cPrim)
-			if opt.notEqual(clause, cPrim) && len(clause.vars) <= len(cPrim.vars) && opt.subset(clause, cPrim) {
+			if cPrim != clause && len(clause.vars) <= len(cPrim.vars) && opt.subset(clause, cPrim) {
 				res = append(res, cPrim)
 			}
 		}
@@ -416,6 +569,18 @@ func (opt *SimpleOptimizer) tryPerformUnitPropagation() (error, bool) {
 			return sat_solver.WrapError(err, "When performing unit propagation for variable %s (removing negation)", opt.vars.Reverse(varToRemove)), false
 		}
 	}
+	if opt.isFrozen(varToRemove) {
+		err := opt.assignXorVariable(varToRemove)
+		if err != nil {
+			return err, false
+		}
+		err = opt.assignCardinalityVariable(varToRemove)
+		if err != nil {
+			return err, false
+		}
+	}
+	// The variable disappears from the formula, so remember its value
+	opt.reconstruction.Push(varToRemove, sat_solver.CNFClause{ varToRemove })
 	for c := range opt.occur[varToRemove] {
 		opt.removeClause(c)
 	}
@@ -423,24 +588,17 @@ func (opt *SimpleOptimizer) tryPerformUnitPropagation() (error, bool) {
 	return nil, true
 }
 
-func (opt *SimpleOptimizer) maybeEliminate(varID sat_solver.CNFLiteral) {
+func (opt *SimpleOptimizer) maybeEliminate(varID sat_solver.CNFLiteral) error {
+	if opt.isFrozen(varID) {
+		return nil
+	}
 	if len(opt.occur[varID]) > 10 || len(opt.occur[-varID]) > 10 {
-		return // Heuristic cut-off
+		return nil // Heuristic cut-off
 	}
-	opt.maybeClauseDistribute(varID)
+	return opt.maybeClauseDistribute(varID)
 }
 
-func (opt *SimpleOptimizer) propagateToplevel() {
-	// TODO: Implement
-}
-
-func (opt *SimpleOptimizer) cleanup() {
-	v := true
-	for v { _, v = opt.tryPerformUnitPropagation() }
-	for opt.blockedClauseElimination() {}
-}
-
-func (opt *SimpleOptimizer) simplify() error {
+func (opt *SimpleOptimizer) initBookkeeping() {
 
 	opt.singular = map[*Clause]struct{}{}
 	for clause := range opt.clauses {
@@ -476,70 +634,56 @@ func (opt *SimpleOptimizer) simplify() error {
 	 * it is added to this set. Initially the set is empty.
 	 */
 	opt.strenghtened = map[*Clause]struct{}{}
+}
 
+/*
+ * Run subsumption and self-subsuming resolution until there are no new or strengthened clauses
+ */
+func (opt *SimpleOptimizer) SubsumeClauses() error {
 	for {
-		// Subsumption
-
-		//fmt.Printf("Iterate added %d\n", len(opt.added))
-
-		S0 := opt.getAddedClauseCandidates(&opt.added,  true)
-		for {
-			//fmt.Printf("Iterate strenghtened\n")
-
-			S1 := opt.getAddedClauseCandidates(&opt.added, false)
-			for a := range opt.added {
-				S1[a] = struct{}{}
-			}
-			for s := range opt.strenghtened {
-				S1[s] = struct{}{}
-			}
-			// Clear Added and Strengthened
-			opt.added = map[*Clause]struct{}{}
-			opt.strenghtened = map[*Clause]struct{}{}
-
-			// Loop
-			for c := range S1 {
-				err := opt.selfSubsume(c)
-				if err != nil {
-					return err
-				}
-			}
-			// May strengthen/remove clauses
-			opt.propagateToplevel()
-
-			if len(opt.strenghtened) == 0 {
-				break
-			}
+		candidates := map[*Clause]struct{}{}
+		for c := range opt.added {
+			candidates[c] = struct{}{}
 		}
-
-		//fmt.Printf("Subsuming S0\n")
-		for c := range S0 {
-			if !c.isDeleted {
-				opt.subsume(c)
-			}
+		for c := range opt.strenghtened {
+			candidates[c] = struct{}{}
 		}
+		// Clear Added and Strengthened
+		opt.added = map[*Clause]struct{}{}
+		opt.strenghtened = map[*Clause]struct{}{}
 
-		// Variable elimination
+		if len(candidates) == 0 {
+			break
+		}
 
-		//fmt.Printf("Variable elimination loop\n")
-		for {
-			//fmt.Printf("Eliminate variables\n")
-			S := opt.touched
-			opt.touched = map[sat_solver.CNFLiteral]struct{}{}
-			for x := range S {
-				opt.maybeEliminate(x)
+		for c := range candidates {
+			if c.isDeleted || c.isTautology() {
+				continue
 			}
-			if len(opt.touched) == 0 {
-				break
+			opt.subsume(c)
+			err := opt.selfSubsume(c)
+			if err != nil {
+				return err
 			}
 		}
-		opt.cleanup()
+	}
+	return nil
+}
 
-		if len(opt.added) == 0 {
-			break
+/*
+ * Eliminate variables by clause distribution until no touched variables are left
+ */
+func (opt *SimpleOptimizer) EliminateVariables() error {
+	for len(opt.touched) > 0 {
+		S := opt.touched
+		opt.touched = map[sat_solver.CNFLiteral]struct{}{}
+		for x := range S {
+			err := opt.maybeEliminate(x.Var())
+			if err != nil {
+				return err
+			}
 		}
 	}
-
 	return nil
 }
 
@@ -547,6 +691,10 @@ func (opt *SimpleOptimizer) selfSubsume(clause *Clause) error {
 	for v := range clause.vars {
 		subsumedBy := opt.findSubsumed(clause.negateClauseVar(v))
 		for _, cPrim := range subsumedBy {
+			if _, ok := cPrim.vars[v]; ok {
+				// Tautologies cannot be strengthened
+				continue
+			}
 			err := opt.strenghten(cPrim, -v)
 			if err != nil {
 				return err
@@ -560,6 +708,9 @@ func (opt *SimpleOptimizer) blockedClauseElimination() bool {
 
 	changeDetected := false
 	for v, clausesWithV := range opt.occur {
+		if opt.isFrozen(v) {
+			continue
+		}
 		// Check if clauseWithV is blocked
 		for clauseWithV := range clausesWithV {
 			isBlocked := true
@@ -592,6 +743,7 @@ func (opt *SimpleOptimizer) blockedClauseElimination() bool {
 					for i, c := range debugTraceClause {
 						fmt.Printf("   by %s (var %s)\n", c.String(opt), opt.vars.Reverse(debugTraceClauseVarID[i]))
 					}*/
+					opt.reconstruction.Push(v, opt.clauseLiterals(clauseWithV))
 					opt.removeClause(clauseWithV)
 					changeDetected = true
 				}
@@ -613,6 +765,11 @@ func Optimize(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (e
 	formRepr := formula.Formula()
 	if f, ok := formRepr.(*sat_solver.CNFFormula); ok {
 
+		err, passes := ParsePreprocessingPipeline(context.GetConfiguration().PreprocessingPipeline)
+		if err != nil {
+			return err, nil
+		}
+
 		hashVal := int64(0)
 
 		bve := SimpleOptimizer{
@@ -620,6 +777,11 @@ func Optimize(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (e
 			occur:   map[sat_solver.CNFLiteral]map[*Clause]struct{}{},
 			vars:    formula.Variables(),
 			visitedUnits: map[sat_solver.CNFLiteral]struct{}{},
+			reconstruction: formula.Reconstruction().Concat(nil),
+			xors:    f.Xors,
+			cardinalities: f.Cardinalities,
+			frozen:  map[sat_solver.CNFLiteral]struct{}{},
+			context: context,
 		}
 
 		for _, clause := range f.Variables {
@@ -650,37 +812,31 @@ func Optimize(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (e
 			c.hash = hashVal
 			bve.clauses[c] = struct{}{}
 		}
-
-
-		err, newContext := context.StartProcessing("Run simple optimizer","")
-		if err != nil {
-			return err, nil
-		}
-		err = bve.simplify()
-		if err != nil {
-			if v, ok := err.(*sat_solver.UnsatError); ok {
-				f := bve.Formula()
-				return nil, sat_solver.NewSATFormulaShortcut(f.Formula(), f.Variables(), nil, v)
+		for _, xor := range f.Xors {
+			for _, v := range xor.Vars {
+				bve.frozen[v] = struct{}{}
 			}
 		}
-		err = newContext.EndProcessingFormula(&bve)
-		if err != nil {
-			return err, nil
+		for _, c := range f.Cardinalities {
+			for _, literal := range c.Literals {
+				bve.frozen[literal.Var()] = struct{}{}
+			}
 		}
+		bve.initBookkeeping()
 
-		err, newContext = context.StartProcessing("Run simple postprocess","")
-		if err != nil {
-			return err, nil
-		}
-		bve.RemoveDanglingVariables()
-		err = newContext.EndProcessingFormula(&bve)
-		if err != nil {
-			return err, nil
+		for _, pass := range passes {
+			err = bve.runPass(pass)
+			if err != nil {
+				if v, ok := err.(*sat_solver.UnsatError); ok {
+					f := bve.Formula()
+					return nil, sat_solver.NewSATFormulaShortcut(f.Formula(), f.Variables(), nil, v)
+				}
+				return err, nil
+			}
 		}
 
-
 		return nil, bve.Formula()
 	}
 
 	return nil, formula
-}
\ No newline at end of file
+}
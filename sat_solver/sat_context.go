@@ -26,8 +26,26 @@ type SATConfiguration struct {
 	EnableCNFConversion    bool
 	EnableASTOptimization  bool
 	EnableCNFOptimizations bool
+	EnableInprocessing     bool
+	PreprocessingPipeline  string
 	SolverName             string
 	LoaderName             string
+	EnableStrictHeaders    bool
+	PBEncoding             string
+	MaxSATAlgorithm        string
+	EnablePartialModels    bool
+	EnableModelChecking    bool
+	SLSAlgorithm           string
+	SLSNoise               float64
+	SLSMaxFlips            int64
+	SLSRestarts            int
+	SLSSeed                int64
+	EnableSLSRephasing     bool
+	DecisionHeuristic      string
+	EnableChronoBacktrack  bool
+	ChronoJumpLimit        int
+	ChronoMinConflicts     int
+	SearchMode             string
 }
 
 func DefaultSATConfiguration() SATConfiguration {
@@ -39,8 +57,26 @@ func DefaultSATConfiguration() SATConfiguration {
 		EnableCNFConversion: true,
 		EnableASTOptimization: false,
 		EnableCNFOptimizations: false,
+		EnableInprocessing: true,
+		PreprocessingPipeline: "",
 		SolverName: "",
 		LoaderName: "",
+		EnableStrictHeaders: false,
+		PBEncoding: "",
+		MaxSATAlgorithm: "",
+		EnablePartialModels: false,
+		EnableModelChecking: true,
+		SLSAlgorithm: "",
+		SLSNoise: 0,
+		SLSMaxFlips: 0,
+		SLSRestarts: 10,
+		SLSSeed: 0,
+		EnableSLSRephasing: false,
+		DecisionHeuristic: "",
+		EnableChronoBacktrack: true,
+		ChronoJumpLimit: 0,
+		ChronoMinConflicts: 0,
+		SearchMode: "",
 	}
 }
 
@@ -66,6 +102,19 @@ func NewSATContextWithEventCollector(conf SATConfiguration, eventCollector log.E
 	}
 }
 
+/**
+ * Create context that uses the given configuration and shares everything else with this context.
+ */
+func (l *SATContext) WithConfiguration(conf SATConfiguration) *SATContext {
+	return &SATContext{
+		context:        l.context,
+		configuration:  &conf,
+		eventCollector: l.eventCollector,
+		contextID:      l.contextID,
+		processID:      l.processID,
+	}
+}
+
 func boolToStr(v bool) string {
 	if v {
 		return "[X]"
@@ -86,12 +135,30 @@ func (context *SATContext) DescribeConfiguration() string {
 		fmt.Sprintf("\tExpected result           => %s", expectedResultStr),
 		fmt.Sprintf("\tUsed solver               => '%s'", conf.SolverName),
 		fmt.Sprintf("\tUsed loader               => '%s'", conf.LoaderName),
+		fmt.Sprintf("\tEnable strict headers?    => %s", boolToStr(conf.EnableStrictHeaders)),
 		fmt.Sprintf("\tEnable event collector?   => %s", boolToStr(conf.EnableEventCollector)),
 		fmt.Sprintf("\tEnable self verification? => %s", boolToStr(conf.EnableSelfVerification)),
 		fmt.Sprintf("\tEnable solver tracing?    => %s", boolToStr(conf.EnableSolverTracing)),
 		fmt.Sprintf("\tEnable CNF conversion?    => %s", boolToStr(conf.EnableCNFConversion)),
 		fmt.Sprintf("\tEnable CNF optimizations? => %s", boolToStr(conf.EnableCNFConversion && conf.EnableCNFOptimizations)),
+		fmt.Sprintf("\tPreprocessing pipeline    => '%s'", conf.PreprocessingPipeline),
 		fmt.Sprintf("\tEnable AST optimization?  => %s", boolToStr(conf.EnableASTOptimization)),
+		fmt.Sprintf("\tEnable inprocessing?      => %s", boolToStr(conf.EnableInprocessing)),
+		fmt.Sprintf("\tPseudo-Boolean encoding   => '%s'", conf.PBEncoding),
+		fmt.Sprintf("\tMaxSAT algorithm          => '%s'", conf.MaxSATAlgorithm),
+		fmt.Sprintf("\tEnable partial models?    => %s", boolToStr(conf.EnablePartialModels)),
+		fmt.Sprintf("\tEnable model checking?    => %s", boolToStr(conf.EnableModelChecking)),
+		fmt.Sprintf("\tSLS algorithm             => '%s'", conf.SLSAlgorithm),
+		fmt.Sprintf("\tSLS noise                 => %g", conf.SLSNoise),
+		fmt.Sprintf("\tSLS flips per restart     => %d", conf.SLSMaxFlips),
+		fmt.Sprintf("\tSLS restarts              => %d", conf.SLSRestarts),
+		fmt.Sprintf("\tSLS random seed           => %d", conf.SLSSeed),
+		fmt.Sprintf("\tEnable SLS rephasing?     => %s", boolToStr(conf.EnableSLSRephasing)),
+		fmt.Sprintf("\tDecision heuristic        => '%s'", conf.DecisionHeuristic),
+		fmt.Sprintf("\tEnable chrono backtrack?  => %s", boolToStr(conf.EnableChronoBacktrack)),
+		fmt.Sprintf("\tChrono jump limit         => %d", conf.ChronoJumpLimit),
+		fmt.Sprintf("\tChrono min conflicts      => %d", conf.ChronoMinConflicts),
+		fmt.Sprintf("\tSearch mode               => '%s'", conf.SearchMode),
 	}, "\n")
 }
 
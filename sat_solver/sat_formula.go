@@ -17,6 +17,7 @@ type SATFormula struct {
 	vars *SATVariableMapping
 	err *UnsatError
 	stats *SATFormulaStatistics
+	reconstruction *ReconstructionStack
 }
 
 func NewSATFormulaShortcut(formula FormulaRepresentation, vars *SATVariableMapping, stats *SATFormulaStatistics, unsatError *UnsatError) *SATFormula {
@@ -36,6 +37,15 @@ func NewSATFormula(formula FormulaRepresentation, vars *SATVariableMapping, stat
 	}
 }
 
+func NewSATFormulaWithReconstruction(formula FormulaRepresentation, vars *SATVariableMapping, stats *SATFormulaStatistics, reconstruction *ReconstructionStack) *SATFormula {
+	return &SATFormula{
+		formula: formula,
+		vars:    vars,
+		stats: stats,
+		reconstruction: reconstruction,
+	}
+}
+
 func (f *SATFormula) IsQuickUNSAT() bool {
 	return f.err != nil
 }
@@ -49,6 +59,9 @@ func (f *SATFormula) Normalize() (error, []bool) {
 	if err != nil {
 		return err, nil
 	}
+	if f.reconstruction != nil {
+		f.reconstruction = f.reconstruction.remap(f.vars, newVars)
+	}
 	f.vars = newVars
 	return nil, make([]bool, varCount)
 }
@@ -65,6 +78,28 @@ func (f *SATFormula) Formula() FormulaRepresentation {
 	return f.formula
 }
 
+func (f *SATFormula) Reconstruction() *ReconstructionStack {
+	return f.reconstruction
+}
+
+/**
+ * Convert model of the formula into an assignment of the founder variables.
+ * Values of variables removed by simplifications are restored using the reconstruction stack.
+ */
+func (f *SATFormula) FounderAssignment(model map[CNFLiteral]bool) map[string]bool {
+	if f.reconstruction != nil {
+		f.reconstruction.Extend(model)
+	}
+	result := map[string]bool{}
+	for v, value := range model {
+		// If the variable was introduced later during optimizations we discard it
+		if f.vars.IsFounderVariable(v) {
+			result[f.vars.Reverse(v)] = value
+		}
+	}
+	return result
+}
+
 func (f *SATFormula) ToSATFormula() *SATFormula {
 	return f
 }
@@ -107,6 +142,9 @@ func (f *SATFormula) ConvertToAST() *Entry {
 }
 
 func (f *SATFormula) IsCNF() bool {
-	_, ok := f.formula.(*CNFFormula)
-	return ok
+	switch f.formula.(type) {
+	case *CNFFormula, *WeightedCNFFormula:
+		return true
+	}
+	return false
 }
\ No newline at end of file
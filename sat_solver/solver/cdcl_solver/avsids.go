@@ -19,11 +19,13 @@ package cdcl_solver
  */
 
 import (
-	"container/heap"
 	"github.com/styczynski/go-sat-solver/sat_solver"
 )
 
 type AVSIDS struct {
+	// Solver that uses the heuristic
+	solver *CDCLSolver
+
 	// LBD is Literal blocks distance a heuristic value used to control the decay of variables
 	// Some research about LBD is done here: https://www.ijcai.org/Proceedings/09/Papers/074.pdf
 	lbdSeen     map[int]struct{}
@@ -50,51 +52,67 @@ type AVSIDS struct {
 	varOrderHeap *LiteralPriorityQueue
 }
 
+func (avsids *AVSIDS) GetName() string {
+	return "avsids"
+}
+
 /**
  * Init AVSIDS variables.
  */
-func (solver *CDCLSolver) avsidsInit() {
+func (avsids *AVSIDS) Init(solver *CDCLSolver) {
+	avsids.solver = solver
 	/*
 	 * Setup initial decay rates.
 	 * This values are taken from a paper but sure, you can change that and see what happens!
 	 */
-	solver.varDecay = 0.85
-	solver.varThreshDecay = 0.99
-	solver.lbdEmaDecay = 0.95
-	solver.clauseDecay = 0.99
-	solver.varInc = 1
-	solver.clauseInc = 1
-	solver.lbdEma = 0
+	avsids.varDecay = 0.85
+	avsids.varThreshDecay = 0.99
+	avsids.lbdEmaDecay = 0.95
+	avsids.clauseDecay = 0.99
+	avsids.varInc = 1
+	avsids.clauseInc = 1
+	avsids.lbdEma = 0
 
 	// No literal was seen yet
-	solver.lbdSeen = map[int]struct{}{}
+	avsids.lbdSeen = map[int]struct{}{}
 
 	// Set initial scores
-	solver.varOrderHeap = NewLiteralPriorityQueue(solver)
 	vars := solver.vars.GetAllVariables()
-	solver.activity = map[sat_solver.CNFLiteral]float64{}
+	avsids.activity = map[sat_solver.CNFLiteral]float64{}
 	for _, v := range vars {
 		if v < 0 {
 			v = -v
 		}
-		solver.activity[v] = 0
+		avsids.activity[v] = 0
 	}
+	avsids.varOrderHeap = NewLiteralPriorityQueue(vars, avsids.activity)
+}
+
+/**
+ * Add new variable with zero score.
+ */
+func (avsids *AVSIDS) AddVariable(v sat_solver.CNFLiteral) {
+	avsids.activity[v] = 0
+	insertVariable(avsids.varOrderHeap, v)
 }
 
 /**
  * Return recommended literal for decision based on AVSIDS heuristics.
  */
-func (solver *CDCLSolver) avsidsSuggestSelect() (sat_solver.CNFLiteral, bool) {
-	for solver.varOrderHeap.Len() > 0 {
-		lit := heap.Pop(solver.varOrderHeap).(*PQLitItem)
-		if lit.value < 0 {
-			lit.value = -lit.value
-		}
-		if _, ok := solver.currentAssignment[lit.value]; !ok {
-			return lit.value, true
-		}
-	}
-	return sat_solver.CNF_UNDEFINED, false
+func (avsids *AVSIDS) PickNext() (sat_solver.CNFLiteral, bool) {
+	return avsids.solver.popDecisionCandidate(avsids.varOrderHeap)
+}
+
+/**
+ * Assigned variables are removed from the queue lazily by PickNext().
+ */
+func (avsids *AVSIDS) OnAssign(v sat_solver.CNFLiteral) {}
+
+/**
+ * Put the unassigned variable back to the queue, so it can be picked again.
+ */
+func (avsids *AVSIDS) OnUnassign(v sat_solver.CNFLiteral) {
+	insertVariable(avsids.varOrderHeap, v)
 }
 
 /**
@@ -102,14 +120,14 @@ func (solver *CDCLSolver) avsidsSuggestSelect() (sat_solver.CNFLiteral, bool) {
  * Note: Clause scores are not yet handled correctly.
  *       Activity for a clause can be used to filter out old learned clauses.
  */
-func (solver *CDCLSolver) avsidsBumpClauseActivity(clause *sat_solver.CNFClause) {
+func (avsids *AVSIDS) bumpClauseActivity(clause *sat_solver.CNFClause) {
 	// TODO: Capture clause scores here and use them to filter out learned clauses
-	//solver.clauseActivity[clause] += solver.clauseInc
-	//if solver.clauseActivity[clause] > 1e20 {
-	//	for c := range solver.clauseActivity {
-	//		solver.clauseActivity[c] *= 1e-20
+	//avsids.clauseActivity[clause] += avsids.clauseInc
+	//if avsids.clauseActivity[clause] > 1e20 {
+	//	for c := range avsids.clauseActivity {
+	//		avsids.clauseActivity[c] *= 1e-20
 	//	}
-	//	solver.clauseInc *= 1e-20
+	//	avsids.clauseInc *= 1e-20
 	//}
 }
 
@@ -118,66 +136,66 @@ func (solver *CDCLSolver) avsidsBumpClauseActivity(clause *sat_solver.CNFClause)
  * Note: Clause scores are not yet handled correctly.
  *       Activity for a clause can be used to filter out old learned clauses.
  */
-func (solver *CDCLSolver) avsidsDecayClauseActivity() {
+func (avsids *AVSIDS) decayClauseActivity() {
 	// TODO: Capture clauses scores here and use them to filter out learned clauses
-	// solver.clauseInc *= (1 / solver.clauseDecay);
+	// avsids.clauseInc *= (1 / avsids.clauseDecay);
 }
 
 /**
  * Increment scores for the given literal.
  */
-func (solver *CDCLSolver) avsidsBumpVarActivity(literal sat_solver.CNFLiteral) {
+func (avsids *AVSIDS) BumpVariable(literal sat_solver.CNFLiteral) {
 	if literal  < 0 {
 		literal  = -literal
 	}
-	if _, ok := solver.activity[literal]; ok {
-		solver.activity[literal] += solver.varInc
-		if solver.activity[literal] > 1e100 {
-			for varID := range solver.activity {
-				solver.activity[varID] *= 1e-100
+	if _, ok := avsids.activity[literal]; ok {
+		avsids.activity[literal] += avsids.varInc
+		if avsids.activity[literal] > 1e100 {
+			for varID := range avsids.activity {
+				avsids.activity[varID] *= 1e-100
 			}
-			solver.varInc *= 1e-100
+			avsids.varInc *= 1e-100
 		}
 	}
-	solver.varOrderHeap.Update(literal)
+	avsids.varOrderHeap.Update(literal)
 }
 
 /**
  * Decay activity incrementation for all variables.
  */
-func (solver *CDCLSolver) avsidsDecayVarActivity(factor float64) {
-	solver.varInc *= 1 / factor
+func (avsids *AVSIDS) decayVarActivity(factor float64) {
+	avsids.varInc *= 1 / factor
 }
 
 /**
  * Handle new learned clause.
  */
-func (solver *CDCLSolver) avsidsClauseLearnt(clause *sat_solver.CNFClause) {
-	solver.avsidsBumpClauseActivity(clause)
+func (avsids *AVSIDS) Decay(clause sat_solver.CNFClause) {
+	avsids.bumpClauseActivity(&clause)
 
-	lbdVal := solver.lbd(*clause)
-	solver.lbdEma = solver.lbdEmaDecay * solver.lbdEma + (1 - solver.lbdEmaDecay) * lbdVal;
-	if lbdVal >= solver.lbdEma {
-		solver.avsidsDecayVarActivity(solver.varDecay)
+	lbdVal := avsids.lbd(clause)
+	avsids.lbdEma = avsids.lbdEmaDecay * avsids.lbdEma + (1 - avsids.lbdEmaDecay) * lbdVal;
+	if lbdVal >= avsids.lbdEma {
+		avsids.decayVarActivity(avsids.varDecay)
 	} else {
-		solver.avsidsDecayVarActivity(solver.varThreshDecay)
+		avsids.decayVarActivity(avsids.varThreshDecay)
 	}
-	solver.avsidsDecayClauseActivity()
+	avsids.decayClauseActivity()
 }
 
 /**
  * Calculate LBD value for a literal.
  */
-func (solver *CDCLSolver) lbd(clause sat_solver.CNFClause) float64 {
+func (avsids *AVSIDS) lbd(clause sat_solver.CNFClause) float64 {
 	lbd := float64(0)
 	for _, lit := range clause {
 		litVar := lit
 		if litVar < 0 {
 			litVar = -litVar
 		}
-		level := solver.getDecisionLevelForVar(litVar)
-		if _, ok := solver.lbdSeen[level]; ok {
-			solver.lbdSeen[level] = struct{}{}
+		level := avsids.solver.getDecisionLevelForVar(litVar)
+		if _, ok := avsids.lbdSeen[level]; ok {
+			avsids.lbdSeen[level] = struct{}{}
 			lbd++
 		}
 	}
@@ -186,8 +204,8 @@ func (solver *CDCLSolver) lbd(clause sat_solver.CNFClause) float64 {
 		if litVar < 0 {
 			litVar = -litVar
 		}
-		level := solver.getDecisionLevelForVar(litVar)
-		solver.lbdSeen[level] = struct{}{}
+		level := avsids.solver.getDecisionLevelForVar(litVar)
+		avsids.lbdSeen[level] = struct{}{}
 	}
 	return lbd
-}
\ No newline at end of file
+}
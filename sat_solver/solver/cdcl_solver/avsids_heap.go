@@ -31,13 +31,13 @@ func (pqItem *PQLitItem) Copy() *PQLitItem {
 }
 
 /*
- * LiteralPriorityQueue implements heap.Interface and stores literals comparing them by solver.activity.
+ * LiteralPriorityQueue implements heap.Interface and stores literals comparing them by their scores.
  * For more details how priority queues can be implemented in Go please see:
  *   https://golang.org/src/container/heap/example_pq_test.go
  */
 type LiteralPriorityQueue struct {
-	// Solver instance
-	solver *CDCLSolver
+	// Scores of the literals (shared with the decision heuristic)
+	scores map[sat_solver.CNFLiteral]float64
 	// Items on a heap
 	items []*PQLitItem
 	// Mapping from literal to its index
@@ -49,7 +49,7 @@ type LiteralPriorityQueue struct {
  */
 func (pq LiteralPriorityQueue) Copy() LiteralPriorityQueue {
 	ret := LiteralPriorityQueue{
-		solver:  pq.solver,
+		scores:  pq.scores,
 		items:   make([]*PQLitItem, len(pq.items)),
 		indexes: map[sat_solver.CNFLiteral]int{},
 	}
@@ -69,7 +69,7 @@ func (pq LiteralPriorityQueue) String() string {
 	i := 0
 	for c.Len() > 0 {
 		item := heap.Pop(&c).(*PQLitItem)
-		ret[i] = fmt.Sprintf("%s(%.2f)", item.value.DebugString(), pq.solver.activity[item.value])
+		ret[i] = fmt.Sprintf("%s(%.2f)", item.value.DebugString(), pq.scores[item.value])
 		i++
 	}
 	return fmt.Sprintf("Queue[%s]", strings.Join(ret, ", "))
@@ -95,7 +95,7 @@ func (pq LiteralPriorityQueue) Has(lit sat_solver.CNFLiteral) bool {
  */
 func (pq LiteralPriorityQueue) Less(i, j int) bool {
 	// We want Pop to give us the highest, not lowest, priority so we use greater than here.
-	return pq.solver.activity[pq.items[i].value] > pq.solver.activity[pq.items[j].value]
+	return pq.scores[pq.items[i].value] > pq.scores[pq.items[j].value]
 }
 
 /**
@@ -149,12 +149,11 @@ func (pq *LiteralPriorityQueue) Update(value sat_solver.CNFLiteral) {
 }
 
 /**
- * Create new priority queue for a given solver instance.
+ * Create new priority queue with the given variables ordered by the scores.
  */
-func NewLiteralPriorityQueue(solver *CDCLSolver) *LiteralPriorityQueue {
-	vars := solver.vars.GetAllVariables()
+func NewLiteralPriorityQueue(vars []sat_solver.CNFLiteral, scores map[sat_solver.CNFLiteral]float64) *LiteralPriorityQueue {
 	pq := LiteralPriorityQueue{
-		solver: solver,
+		scores: scores,
 		items:  make([]*PQLitItem, len(vars)),
 		indexes: map[sat_solver.CNFLiteral]int{},
 	}
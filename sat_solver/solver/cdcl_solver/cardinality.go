@@ -0,0 +1,185 @@
+package cdcl_solver
+
+/**
+ * This file provides native support for cardinality constraints (at least k of the literals are true).
+ *
+ * Each constraint keeps a counter of its literals that are false. The counter is updated by the unit propagation
+ * when a trace literal is processed (and decreased again when the solver jumps back), so only the constraints
+ * that contain the negation of the assigned literal are visited:
+ *   - if fewer than k literals can still be true, then the constraint is a conflict
+ *   - if exactly k literals can still be true, then all the unassigned literals are asserted
+ *
+ * Reasons are not stored as clauses. Instead the assignment remembers the constraint and the explanation clause
+ * is built only when learnClause() needs it. It consists of the implied literal and all the literals of
+ * the constraint that were false before the implied literal was assigned.
+ *
+ * For more details please see the paper by Chai and Kuehlmann:
+ *   "A Fast Pseudo-Boolean Constraint Solver" (DAC 2003)
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type SolverCardinalityState struct {
+	// All cardinality constraints
+	cardinalities      []*cardinalityConstraint
+	// Constraints containing the negation of the literal (so they are updated when the literal becomes true)
+	// The constraint occurs once for each occurrence of the literal
+	cardinalityWatches map[sat_solver.CNFLiteral][]*cardinalityConstraint
+}
+
+/**
+ * Single cardinality constraint: at least bound of the literals must be true.
+ */
+type cardinalityConstraint struct {
+	literals   []sat_solver.CNFLiteral
+	bound      int
+	// Number of false literals among the assignments already processed by the unit propagation
+	falseCount int
+}
+
+/**
+ * Add cardinality constraint to the solver at decision level 0.
+ * Returns false if the constraint is trivially UNSAT.
+ */
+func (solver *CDCLSolver) addCardinalityConstraint(c sat_solver.CardinalityClause) bool {
+	if c.IsTrivial() {
+		return true
+	} else if c.IsUnsatisfiable() {
+		return false
+	}
+	if solver.cardinalityWatches == nil {
+		solver.cardinalityWatches = map[sat_solver.CNFLiteral][]*cardinalityConstraint{}
+	}
+	constraint := &cardinalityConstraint{
+		literals: c.Literals,
+		bound:    c.Bound,
+	}
+	solver.cardinalities = append(solver.cardinalities, constraint)
+	for _, literal := range c.Literals {
+		solver.cardinalityWatches[-literal] = append(solver.cardinalityWatches[-literal], constraint)
+		// Elimination of that variable would require rewriting the constraint
+		solver.frozenVars[literal.Var()] = true
+	}
+
+	// Propagation is triggered only by false literals, so constraints that need all the literals are asserted now
+	if c.Bound == len(c.Literals) {
+		for _, literal := range c.Literals {
+			value := solver.currentLiteralValue(literal)
+			if value.IsFalse() {
+				return false
+			} else if value.IsUndefined() {
+				solver.performCardinalityAssertion(literal, constraint)
+			}
+		}
+	}
+	return true
+}
+
+/**
+ * Assert the literal implied by the cardinality constraint.
+ * The reason clause is computed later if it's needed.
+ */
+func (solver *CDCLSolver) performCardinalityAssertion(literal sat_solver.CNFLiteral, c *cardinalityConstraint) {
+	solver.performLiteralAssertion(literal, nil)
+	info := solver.varsInfo[literal.Var()]
+	info.reasonConstraint = c
+	solver.varsInfo[literal.Var()] = info
+	for _, observer := range solver.observers {
+		observer.OnPropagate(literal, solver.getReasonClause(literal.Var()))
+	}
+}
+
+/**
+ * Update the constraints that contain the negation of the literal p which was just assigned.
+ * Returns a conflicting clause if any constraint cannot be satisfied anymore.
+ */
+func (solver *CDCLSolver) propagateCardinalities(p sat_solver.CNFLiteral) sat_solver.CNFClause {
+	watches := solver.cardinalityWatches[p]
+	// All the counters must be updated even if a conflict is found, because the literal is marked as processed
+	for _, c := range watches {
+		c.falseCount++
+	}
+	for _, c := range watches {
+		slack := len(c.literals) - c.falseCount - c.bound
+		if slack < 0 {
+			if solver.enableDebugLogging {
+				solver.context.Trace("cardinality", "Cardinality constraint is violated.")
+			}
+			return solver.cardinalityConflict(c)
+		} else if slack == 0 {
+			for _, literal := range c.literals {
+				if solver.currentLiteralValue(literal).IsUndefined() {
+					if solver.enableDebugLogging {
+						solver.context.Trace("cardinality", "Cardinality constraint propagates %s.", literal.String(solver.vars))
+					}
+					solver.performCardinalityAssertion(literal, c)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ * Undo the counter updates for the trace literals that are removed when the solver jumps back.
+ * Only the literals before the currentTraceCheckIndex were processed, so only they are counted.
+ */
+func (solver *CDCLSolver) unassignCardinalities(fromTraceIndex int) {
+	if len(solver.cardinalities) == 0 {
+		return
+	}
+	for i := fromTraceIndex; i < solver.currentTraceCheckIndex && i < len(solver.assignmentTrace); i++ {
+		for _, c := range solver.cardinalityWatches[solver.assignmentTrace[i]] {
+			c.falseCount--
+		}
+	}
+}
+
+/**
+ * Build the conflicting clause (all the false literals of the constraint).
+ * Literals that occur multiple times in the constraint are repeated, which is fine as learnClause() visits
+ * each variable only once.
+ */
+func (solver *CDCLSolver) cardinalityConflict(c *cardinalityConstraint) sat_solver.CNFClause {
+	clause := sat_solver.CNFClause{}
+	for _, literal := range c.literals {
+		if solver.currentLiteralValue(literal).IsFalse() {
+			clause = append(clause, literal)
+		}
+	}
+	return clause
+}
+
+/**
+ * Build the explanation clause for the variable v implied by the constraint c.
+ * The implied literal is placed at the first position and it's followed by all the literals of the constraint
+ * that were already false when the implied literal was assigned.
+ */
+func (solver *CDCLSolver) cardinalityExplanation(c *cardinalityConstraint, v sat_solver.CNFLiteral) sat_solver.CNFClause {
+	info := solver.varsInfo[v]
+	clause := sat_solver.CNFClause{ solver.assignmentTrace[info.traceIndex] }
+	for _, literal := range c.literals {
+		if literal.Var() == v || !solver.currentLiteralValue(literal).IsFalse() {
+			continue
+		}
+		if solver.varsInfo[literal.Var()].traceIndex < info.traceIndex {
+			clause = append(clause, literal)
+		}
+	}
+	return clause
+}
+
+/**
+ * Get the clause that caused the assignment of the variable.
+ * Explanations of the cardinality constraints are built on the first use and then remembered.
+ */
+func (solver *CDCLSolver) getReasonClause(v sat_solver.CNFLiteral) sat_solver.CNFClause {
+	info := solver.varsInfo[v]
+	if info.reasonClause == nil && info.reasonConstraint != nil {
+		info.reasonClause = solver.cardinalityExplanation(info.reasonConstraint, v)
+		solver.varsInfo[v] = info
+	}
+	return info.reasonClause
+}
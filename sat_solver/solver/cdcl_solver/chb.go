@@ -0,0 +1,130 @@
+package cdcl_solver
+
+/**
+ * This file provides the CHB (conflict history based branching) decision heuristic.
+ *
+ * Each variable has a score Q. When the variables assigned since the last decision (or conflict) are propagated,
+ * each of them gets the reward:
+ *     multiplier / (conflicts - lastConflict[v] + 1)
+ * where lastConflict[v] is the number of the conflict in which the variable was last seen during the analysis
+ * and the multiplier is 1 if the propagation led to a conflict and 0.9 otherwise.
+ * The score is updated as the exponential moving average: Q = (1 - step) * Q + step * reward.
+ * The step starts at 0.4 and decreases by 1e-6 with each conflict down to 0.06.
+ * The decision picks the unassigned variable with the highest score.
+ *
+ * For more details please see:
+ *   "Exponential Recency Weighted Average Branching Heuristic for SAT Solvers"
+ *     by Jia Hui Liang, Vijay Ganesh, Pascal Poupart and Krzysztof Czarnecki (AAAI 2016)
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+const (
+	// Initial and minimal step of the exponential moving average (shared with LRB)
+	ERWA_INITIAL_STEP = 0.4
+	ERWA_MIN_STEP = 0.06
+	// The step decreases by this value with each conflict
+	ERWA_STEP_DECAY = 1e-6
+	// Multiplier of the reward when the propagation does not lead to a conflict
+	CHB_NO_CONFLICT_MULTIPLIER = 0.9
+)
+
+type CHB struct {
+	// Solver that uses the heuristic
+	solver       *CDCLSolver
+	// Scores of the variables
+	score        map[sat_solver.CNFLiteral]float64
+	// Number of the conflict in which the variable was last seen during the analysis
+	lastConflict map[sat_solver.CNFLiteral]int
+	// Number of the conflicts so far
+	conflicts    int
+	step         float64
+	// Variables assigned since the last reward
+	assigned     []sat_solver.CNFLiteral
+	// Priority queue with variables sorted by the score
+	queue        *LiteralPriorityQueue
+}
+
+func (chb *CHB) GetName() string {
+	return "chb"
+}
+
+/**
+ * Init the scores of all the variables.
+ */
+func (chb *CHB) Init(solver *CDCLSolver) {
+	chb.solver = solver
+	chb.score = map[sat_solver.CNFLiteral]float64{}
+	chb.lastConflict = map[sat_solver.CNFLiteral]int{}
+	chb.conflicts = 0
+	chb.step = ERWA_INITIAL_STEP
+	chb.assigned = []sat_solver.CNFLiteral{}
+	vars := solver.vars.GetAllVariables()
+	for _, v := range vars {
+		chb.score[v.Var()] = 0
+	}
+	chb.queue = NewLiteralPriorityQueue(vars, chb.score)
+}
+
+/**
+ * Add new variable with zero score.
+ */
+func (chb *CHB) AddVariable(v sat_solver.CNFLiteral) {
+	chb.score[v] = 0
+	insertVariable(chb.queue, v)
+}
+
+/**
+ * Remember that the variable took part in the current conflict.
+ */
+func (chb *CHB) BumpVariable(v sat_solver.CNFLiteral) {
+	chb.lastConflict[v.Var()] = chb.conflicts + 1
+}
+
+/**
+ * Reward the variables whose propagation led to the conflict.
+ */
+func (chb *CHB) Decay(clause sat_solver.CNFClause) {
+	chb.conflicts++
+	chb.reward(1.0)
+	if chb.step > ERWA_MIN_STEP {
+		chb.step -= ERWA_STEP_DECAY
+	}
+}
+
+/*
+ * Update the scores of the variables assigned since the last reward.
+ */
+func (chb *CHB) reward(multiplier float64) {
+	for _, v := range chb.assigned {
+		if _, ok := chb.score[v]; !ok {
+			continue
+		}
+		reward := multiplier / float64(chb.conflicts - chb.lastConflict[v] + 1)
+		chb.score[v] = (1 - chb.step) * chb.score[v] + chb.step * reward
+		chb.queue.Update(v)
+	}
+	chb.assigned = chb.assigned[:0]
+}
+
+/**
+ * Return the unassigned variable with the highest score.
+ * The propagation before the decision did not lead to a conflict, so the assigned variables are rewarded first.
+ */
+func (chb *CHB) PickNext() (sat_solver.CNFLiteral, bool) {
+	chb.reward(CHB_NO_CONFLICT_MULTIPLIER)
+	return chb.solver.popDecisionCandidate(chb.queue)
+}
+
+func (chb *CHB) OnAssign(v sat_solver.CNFLiteral) {
+	chb.assigned = append(chb.assigned, v)
+}
+
+/**
+ * Put the unassigned variable back to the queue, so it can be picked again.
+ */
+func (chb *CHB) OnUnassign(v sat_solver.CNFLiteral) {
+	insertVariable(chb.queue, v)
+}
@@ -0,0 +1,249 @@
+package cdcl_solver
+
+/**
+ * This file provides the chronological backtracking for the CDCL solver.
+ *
+ * After a conflict the solver usually jumps back to the assertion level of the learned clause and throws away
+ * all the assignments above it, even if most of them would be made again right after the jump.
+ * When the jump would skip more than CHRONO_BACKTRACK_JUMP_LIMIT levels, the solver goes back only one level
+ * and the learned clause asserts its literal at the (lower) assertion level anyway.
+ * Both limits can be changed in the configuration (ChronoJumpLimit and ChronoMinConflicts).
+ *
+ * That means the trail is no longer sorted by the decision levels:
+ *   - the implied literal gets the highest level of the other literals of its reason (see performLiteralAssertion)
+ *   - going back to a level keeps the literals of the lower levels that were assigned after it
+ *     and propagates them again (see reverseToDecisionLevel)
+ *   - the conflict can be found on a level lower than the current one, so the solver first goes back
+ *     to the conflict level (see prepareConflictingClause)
+ *   - the conflict analysis skips the literals of the lower levels when it walks back the trail (see learnClause)
+ *
+ * For more details please see:
+ *   "Chronological Backtracking" by Alexander Nadel and Vadim Ryvchin (SAT 2018)
+ *   "Backing Backtracking" by Sibylle Möhle and Armin Biere (SAT 2019)
+ */
+
+import (
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+const (
+	// Default number of decision levels: backtrack chronologically when the jump would skip more levels than this
+	CHRONO_BACKTRACK_JUMP_LIMIT = 100
+	// Default number of conflicts before the chronological backtracking is used for the first time
+	CHRONO_BACKTRACK_MIN_CONFLICTS = 4000
+)
+
+type SolverChronoState struct {
+	// Is the chronological backtracking enabled at all?
+	enableChronoBacktrack bool
+	// Backtrack chronologically when the jump would skip more decision levels than this
+	chronoJumpLimit       int
+	// Number of conflicts before the chronological backtracking is used for the first time
+	chronoMinConflicts    int
+	// Number of chronological backtracks made so far
+	chronoBacktracks      int
+	// Check the invariants of the trail after each conflict (see checkTrailInvariants)
+	checkTrailAfterConflicts bool
+}
+
+/**
+ * Load the chronological backtracking settings from the configuration.
+ */
+func (solver *CDCLSolver) chronoInit() {
+	conf := solver.context.GetConfiguration()
+	solver.enableChronoBacktrack = conf.EnableChronoBacktrack
+	solver.chronoJumpLimit = conf.ChronoJumpLimit
+	if solver.chronoJumpLimit <= 0 {
+		solver.chronoJumpLimit = CHRONO_BACKTRACK_JUMP_LIMIT
+	}
+	solver.chronoMinConflicts = conf.ChronoMinConflicts
+	if solver.chronoMinConflicts <= 0 {
+		solver.chronoMinConflicts = CHRONO_BACKTRACK_MIN_CONFLICTS
+	}
+	solver.checkTrailAfterConflicts = solver.context.IsSelfVerificationEnabled()
+}
+
+/**
+ * Get the decision level of the literal implied by the reason clause.
+ * The implied literal is the first one in the clause, so it's the highest level of the other literals.
+ */
+func (solver *CDCLSolver) getReasonLevel(reason sat_solver.CNFClause) int {
+	decisionLevel := 0
+	for _, literal := range reason[1:] {
+		if level := solver.getDecisionLevelForVar(literal.Var()); level > decisionLevel {
+			decisionLevel = level
+		}
+	}
+	return decisionLevel
+}
+
+/**
+ * Get the decision level to go back to after learning a clause with the given assertion level.
+ */
+func (solver *CDCLSolver) getBacktrackLevel(assertionLevel int) int {
+	decisionLevel := solver.getDecisionLevel()
+	if !solver.enableChronoBacktrack || solver.conflictsCount < solver.chronoMinConflicts ||
+		decisionLevel - assertionLevel <= solver.chronoJumpLimit {
+		return assertionLevel
+	}
+	solver.chronoBacktracks++
+	if solver.enableDebugLogging {
+		solver.context.Trace("chrono", "Backtracking chronologically to level %d instead of %d.", decisionLevel - 1, assertionLevel)
+	}
+	return decisionLevel - 1
+}
+
+/**
+ * Move the literals of the conflicting clause with the highest decision levels to the first two positions
+ * and return the highest level (the conflict level).
+ * The second value is true if only the first literal is on the conflict level, so the clause
+ * is not a real conflict but a missed implication of that literal on a lower level.
+ * If the clause is in the database, then its watches are moved with the literals, so the TWL invariant holds
+ * after going back to a lower level.
+ */
+func (solver *CDCLSolver) prepareConflictingClause(clause sat_solver.CNFClause) (int, bool) {
+	if len(clause) == 0 {
+		return 0, false
+	}
+	isWatched := false
+	isWatchChecked := false
+	for position := 0; position < 2 && position < len(clause); position++ {
+		best := position
+		bestLevel := solver.getDecisionLevelForVar(clause[position].Var())
+		for i := position + 1; i < len(clause); i++ {
+			if level := solver.getDecisionLevelForVar(clause[i].Var()); level > bestLevel {
+				best, bestLevel = i, level
+			}
+		}
+		if best == position {
+			continue
+		}
+		if best >= 2 && !isWatchChecked {
+			isWatched = solver.isWatchedClause(clause)
+			isWatchChecked = true
+		}
+		if best >= 2 && isWatched {
+			// The literal on the position stops being watched
+			solver.unwatchClause(clause[position], clause)
+			clause[position], clause[best] = clause[best], clause[position]
+			solver.watchedLiterals[-clause[position]] = append(solver.watchedLiterals[-clause[position]], &TWLRecord{
+				Literal: clause[1 - position],
+				Clause:  clause,
+			})
+		} else {
+			clause[position], clause[best] = clause[best], clause[position]
+		}
+	}
+
+	conflictLevel := solver.getDecisionLevelForVar(clause[0].Var())
+	if len(clause) == 1 {
+		return conflictLevel, true
+	}
+	return conflictLevel, solver.getDecisionLevelForVar(clause[1].Var()) < conflictLevel
+}
+
+/*
+ * Check if the clause is in the watch lists (clauses built from XOR and cardinality constraints are not).
+ */
+func (solver *CDCLSolver) isWatchedClause(clause sat_solver.CNFClause) bool {
+	for _, record := range solver.watchedLiterals[-clause[0]] {
+		if &record.Clause[0] == &clause[0] {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * Remove the record of the clause from the watch list of the literal.
+ */
+func (solver *CDCLSolver) unwatchClause(literal sat_solver.CNFLiteral, clause sat_solver.CNFClause) {
+	watches := solver.watchedLiterals[-literal]
+	for i, record := range watches {
+		if &record.Clause[0] == &clause[0] {
+			watches[i] = watches[len(watches) - 1]
+			solver.watchedLiterals[-literal] = watches[:len(watches) - 1]
+			return
+		}
+	}
+}
+
+/**
+ * Check the invariants of the trail that must hold also when it's out of order, and panic if any of them is broken:
+ *   - the decisions are on the consecutive levels and they are the only literals without reasons above the level 0
+ *   - each assigned variable is on the trail exactly once, at the position remembered in its information
+ *   - all the other literals of the reason are false, assigned before the implied literal and the implied literal
+ *     is on the highest of their levels (or on a higher level if the chronological backtracking is disabled)
+ * It takes time linear in the size of the trail, so it's used only when the self verification is enabled.
+ */
+func (solver *CDCLSolver) checkTrailInvariants() {
+	decisionLevel := solver.getDecisionLevel()
+	fail := func(format string, args ...interface{}) {
+		panic(fmt.Sprintf("checkTrailInvariants: " + format + " Decision trace: %s.", append(args, solver.getDecisionTraceString())...))
+	}
+	if len(solver.currentAssignment) != len(solver.assignmentTrace) {
+		fail("%d variables are assigned, but the trail has %d literals.", len(solver.currentAssignment), len(solver.assignmentTrace))
+	}
+	isDecision := map[int]bool{}
+	for i, index := range solver.decisionTrace {
+		if i > 0 && index <= solver.decisionTrace[i - 1] {
+			fail("Decision of the level %d is not after the decision of the previous level.", i + 1)
+		}
+		if index >= len(solver.assignmentTrace) {
+			fail("Decision of the level %d is missing from the trail.", i + 1)
+		}
+		isDecision[index] = true
+		if level := solver.getDecisionLevelForVar(solver.assignmentTrace[index].Var()); level != i + 1 {
+			fail("Decision of the level %d is on the level %d.", i + 1, level)
+		}
+	}
+	for position, literal := range solver.assignmentTrace {
+		v := literal.Var()
+		if value, ok := solver.currentAssignment[v]; !ok || value != BoolToTernary(literal > 0) {
+			fail("Literal %d of the trail is not assigned.", literal)
+		}
+		info := solver.varsInfo[v]
+		if info.traceIndex != position {
+			fail("Literal %d is on the position %d of the trail instead of %d.", literal, position, info.traceIndex)
+		}
+		if info.decisionLevel > decisionLevel {
+			fail("Literal %d is on the level %d above the current level %d.", literal, info.decisionLevel, decisionLevel)
+		}
+		if isDecision[position] {
+			continue
+		}
+		if info.reasonClause == nil {
+			// The reasons built from the cardinality constraints are not checked, they are built when they are needed
+			if info.reasonConstraint == nil && info.decisionLevel > 0 {
+				fail("Literal %d on the level %d has no reason.", literal, info.decisionLevel)
+			}
+			continue
+		}
+		if info.decisionLevel == 0 {
+			// The reasons on the level 0 may be changed by the simplifications of the clause database
+			continue
+		}
+		reason := info.reasonClause
+		if reason[0] != literal {
+			fail("Literal %d is not the first literal of its reason.", literal)
+		}
+		reasonLevel := 0
+		for _, other := range reason[1:] {
+			otherInfo := solver.varsInfo[other.Var()]
+			if value, ok := solver.currentAssignment[other.Var()]; !ok || value != BoolToTernary(other < 0) {
+				fail("Literal %d of the reason of %d is not false.", other, literal)
+			}
+			if otherInfo.traceIndex >= position {
+				fail("Literal %d of the reason of %d is assigned after it.", other, literal)
+			}
+			if otherInfo.decisionLevel > reasonLevel {
+				reasonLevel = otherInfo.decisionLevel
+			}
+		}
+		if info.decisionLevel < reasonLevel || (solver.enableChronoBacktrack && info.decisionLevel != reasonLevel) {
+			fail("Literal %d is on the level %d, but its reason is on the level %d.", literal, info.decisionLevel, reasonLevel)
+		}
+	}
+}
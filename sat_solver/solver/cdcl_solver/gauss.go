@@ -0,0 +1,260 @@
+package cdcl_solver
+
+/**
+ * This file provides native support for XOR constraints.
+ *
+ * XOR constraints form a system of linear equations over GF(2). Each equation is a row of a bit matrix and
+ * each variable that occurs in any XOR is a column of that matrix.
+ * After the unit propagation reaches a fixpoint the current assignment is substituted into the matrix and
+ * the Gauss-Jordan elimination is performed on the unassigned columns:
+ *   - a row without any unassigned variables and with odd parity is a conflict
+ *   - a row with exactly one unassigned variable forces the value of that variable
+ * Working on the reduced matrix finds implications that are not visible when each XOR is propagated separately
+ * (for example a x b x c = F and b x c x d = F together imply a = d).
+ *
+ * Each row of the reduced matrix is a sum of the original XORs, so it is implied by the formula.
+ * The explanation clause for the propagation (or conflict) is obtained from the row by taking all of its variables
+ * with the literals that are currently false. Those clauses are used as reason clauses during learning.
+ *
+ * For more details please see the paper by Han and Jiang:
+ *   "When Boolean Satisfiability Meets Gaussian Elimination in a Simplex Way" (CAV 2012)
+ */
+
+import (
+	"math/bits"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type SolverGaussState struct {
+	// Variables that occur in the XOR constraints (columns of the matrix)
+	xorColumns             []sat_solver.CNFLiteral
+	// Column index for each XOR variable
+	xorColumnIndex         map[sat_solver.CNFLiteral]int
+	// Input XOR constraints
+	xorRows                []gaussRow
+	// Matrix used for the elimination (allocated once and reused)
+	gaussMatrix            []gaussRow
+	// All the assignments on the trace before this index were already seen by the Gaussian elimination
+	// (the index is moved back when the solver jumps back)
+	gaussCheckedTraceIndex int
+}
+
+/**
+ * Single XOR in the matrix.
+ */
+type gaussRow struct {
+	// Columns of variables that are not assigned
+	unassigned []uint64
+	// All the columns of the row (used to build explanation clauses)
+	all        []uint64
+	// Parity of the row after the assigned variables were substituted
+	parity     bool
+}
+
+func (row *gaussRow) xorWith(other *gaussRow) {
+	for i := range row.all {
+		row.all[i] ^= other.all[i]
+		row.unassigned[i] ^= other.unassigned[i]
+	}
+	row.parity = row.parity != other.parity
+}
+
+/**
+ * Add XOR constraint to the solver.
+ * Returns false if the constraint is trivially UNSAT.
+ */
+func (solver *CDCLSolver) addXorConstraint(xor sat_solver.XORClause) bool {
+	if len(xor.Vars) == 0 {
+		return !xor.Parity
+	}
+	if solver.xorColumnIndex == nil {
+		solver.xorColumnIndex = map[sat_solver.CNFLiteral]int{}
+	}
+	for _, v := range xor.Vars {
+		if _, ok := solver.xorColumnIndex[v]; !ok {
+			solver.xorColumnIndex[v] = len(solver.xorColumns)
+			solver.xorColumns = append(solver.xorColumns, v)
+			// Elimination of that variable would require rewriting the XORs
+			solver.frozenVars[v] = true
+		}
+	}
+	solver.xorRows = append(solver.xorRows, gaussRow{
+		all:    solver.xorColumnsToBits(xor.Vars),
+		parity: xor.Parity,
+	})
+	return true
+}
+
+func (solver *CDCLSolver) xorColumnsToBits(vars []sat_solver.CNFLiteral) []uint64 {
+	result := make([]uint64, (len(solver.xorColumns) + 63) / 64)
+	for _, v := range vars {
+		column := solver.xorColumnIndex[v]
+		result[column / 64] |= uint64(1) << uint(column % 64)
+	}
+	return result
+}
+
+/**
+ * Allocate the elimination matrix when all the XOR constraints were added.
+ */
+func (solver *CDCLSolver) gaussInit() {
+	words := (len(solver.xorColumns) + 63) / 64
+	solver.gaussMatrix = make([]gaussRow, len(solver.xorRows))
+	for i := range solver.xorRows {
+		// Rows added before all the columns were known may be shorter
+		row := make([]uint64, words)
+		copy(row, solver.xorRows[i].all)
+		solver.xorRows[i].all = row
+		solver.gaussMatrix[i] = gaussRow{
+			unassigned: make([]uint64, words),
+			all:        make([]uint64, words),
+		}
+	}
+	// The first elimination is performed even if there are no assignments, because the XORs can be inconsistent
+	solver.gaussCheckedTraceIndex = -1
+}
+
+/**
+ * Check if any XOR variable was assigned since the last Gaussian elimination.
+ */
+func (solver *CDCLSolver) hasNewXorAssignments() bool {
+	if solver.gaussCheckedTraceIndex < 0 {
+		return true
+	}
+	for _, literal := range solver.assignmentTrace[solver.gaussCheckedTraceIndex:] {
+		if _, ok := solver.xorColumnIndex[literal.Var()]; ok {
+			return true
+		}
+	}
+	solver.gaussCheckedTraceIndex = len(solver.assignmentTrace)
+	return false
+}
+
+/**
+ * Propagate XOR constraints using the Gauss-Jordan elimination.
+ * Returns a conflicting clause if a conflict was detected and true as the second value if any literal was asserted.
+ */
+func (solver *CDCLSolver) performGaussPropagation() (sat_solver.CNFClause, bool) {
+	if len(solver.xorRows) == 0 || !solver.hasNewXorAssignments() {
+		return nil, false
+	}
+
+	matrix := solver.gaussMatrix
+	for i := range solver.xorRows {
+		copy(matrix[i].all, solver.xorRows[i].all)
+		copy(matrix[i].unassigned, solver.xorRows[i].all)
+		matrix[i].parity = solver.xorRows[i].parity
+	}
+
+	// Substitute the current assignment
+	for column, v := range solver.xorColumns {
+		value := solver.currentLiteralValue(v)
+		if value.IsUndefined() {
+			continue
+		}
+		word, bit := column / 64, uint64(1) << uint(column % 64)
+		for i := range matrix {
+			if matrix[i].unassigned[word] & bit != 0 {
+				matrix[i].unassigned[word] &^= bit
+				if value.IsTrue() {
+					matrix[i].parity = !matrix[i].parity
+				}
+			}
+		}
+	}
+
+	// Gauss-Jordan elimination on the unassigned columns
+	pivotRow := 0
+	for column := range solver.xorColumns {
+		if pivotRow >= len(matrix) {
+			break
+		}
+		word, bit := column / 64, uint64(1) << uint(column % 64)
+		found := -1
+		for i := pivotRow; i < len(matrix); i++ {
+			if matrix[i].unassigned[word] & bit != 0 {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			continue
+		}
+		matrix[pivotRow], matrix[found] = matrix[found], matrix[pivotRow]
+		for i := range matrix {
+			if i != pivotRow && matrix[i].unassigned[word] & bit != 0 {
+				matrix[i].xorWith(&matrix[pivotRow])
+			}
+		}
+		pivotRow++
+	}
+
+	// Rows without pivot have no unassigned variables, so they may be conflicts
+	for i := pivotRow; i < len(matrix); i++ {
+		if matrix[i].parity {
+			if solver.enableDebugLogging {
+				solver.context.Trace("xor", "Gaussian elimination detected a conflict.")
+			}
+			return solver.gaussExplanation(&matrix[i], -1), false
+		}
+	}
+
+	propagated := false
+	for i := 0; i < pivotRow; i++ {
+		count := 0
+		lastColumn := -1
+		for word, bitsSet := range matrix[i].unassigned {
+			if bitsSet != 0 {
+				count += bits.OnesCount64(bitsSet)
+				lastColumn = word * 64 + bits.TrailingZeros64(bitsSet)
+			}
+		}
+		if count == 1 {
+			literal := solver.xorColumns[lastColumn]
+			if !matrix[i].parity {
+				literal = -literal
+			}
+			if solver.enableDebugLogging {
+				solver.context.Trace("xor", "Gaussian elimination propagates %s.", literal.String(solver.vars))
+			}
+			solver.performLiteralAssertion(literal, solver.gaussExplanation(&matrix[i], lastColumn))
+			propagated = true
+		}
+	}
+
+	solver.gaussCheckedTraceIndex = len(solver.assignmentTrace)
+	return nil, propagated
+}
+
+/**
+ * Build clause explaining the row.
+ * If impliedColumn is not negative, then the implied literal is placed at the first position of the clause.
+ * All the other literals of the clause are false under the current assignment.
+ */
+func (solver *CDCLSolver) gaussExplanation(row *gaussRow, impliedColumn int) sat_solver.CNFClause {
+	clause := sat_solver.CNFClause{}
+	if impliedColumn >= 0 {
+		literal := solver.xorColumns[impliedColumn]
+		if !row.parity {
+			literal = -literal
+		}
+		clause = append(clause, literal)
+	}
+	for word, bitsSet := range row.all {
+		for bitsSet != 0 {
+			column := word * 64 + bits.TrailingZeros64(bitsSet)
+			bitsSet &= bitsSet - 1
+			if column == impliedColumn {
+				continue
+			}
+			v := solver.xorColumns[column]
+			if solver.currentLiteralValue(v).IsTrue() {
+				clause = append(clause, -v)
+			} else {
+				clause = append(clause, v)
+			}
+		}
+	}
+	return clause
+}
@@ -0,0 +1,102 @@
+package cdcl_solver
+
+/**
+ * This file provides the interface of the decision heuristics of the CDCL solver.
+ *
+ * The heuristic decides which variable is assigned next. It's notified about the search:
+ *   - BumpVariable is called for each variable seen during the conflict analysis
+ *   - Decay is called once per conflict, after the analysis
+ *   - OnAssign and OnUnassign are called for each assigned and unassigned variable
+ *   - PickNext returns the unassigned variable for the next decision
+ * The value of the decision variable is chosen by the solver (see rephase.go).
+ *
+ * The heuristic of the stable mode is selected with SATConfiguration.DecisionHeuristic,
+ * the focused mode always uses VMTF (see modes.go). Available heuristics:
+ *   - "avsids" (default) - Adaptive VSIDS (see avsids.go)
+ *   - "vmtf" - variable move-to-front (see vmtf.go)
+ *   - "chb" - conflict history based branching (see chb.go)
+ *   - "lrb" - learning rate based branching (see lrb.go)
+ */
+
+import (
+	"container/heap"
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type DecisionHeuristic interface {
+	// Prepare the heuristic for the variables of the loaded formula
+	Init(solver *CDCLSolver)
+	// Add the variable created after Init
+	AddVariable(v sat_solver.CNFLiteral)
+	// The variable takes part in the conflict analysis
+	BumpVariable(v sat_solver.CNFLiteral)
+	// The conflict was analysed, the clause is the conflicting clause
+	Decay(clause sat_solver.CNFClause)
+	// Get the unassigned variable for the next decision or false if there is none
+	PickNext() (sat_solver.CNFLiteral, bool)
+	// The variable was assigned or unassigned
+	OnAssign(v sat_solver.CNFLiteral)
+	OnUnassign(v sat_solver.CNFLiteral)
+	GetName() string
+}
+
+var DEFAULT_DECISION_HEURISTIC = "avsids"
+var DECISION_HEURISTICS = map[string]func() DecisionHeuristic{}
+
+func RegisterDecisionHeuristic(name string, create func() DecisionHeuristic) {
+	DECISION_HEURISTICS[name] = create
+}
+
+func init() {
+	RegisterDecisionHeuristic("avsids", func() DecisionHeuristic { return &AVSIDS{} })
+	RegisterDecisionHeuristic("vmtf", func() DecisionHeuristic { return &VMTF{} })
+	RegisterDecisionHeuristic("chb", func() DecisionHeuristic { return &CHB{} })
+	RegisterDecisionHeuristic("lrb", func() DecisionHeuristic { return &LRB{} })
+}
+
+/**
+ * Create the decision heuristic with the given name. Empty name means the default heuristic.
+ */
+func NewDecisionHeuristic(name string) (error, DecisionHeuristic) {
+	if len(name) == 0 {
+		name = DEFAULT_DECISION_HEURISTIC
+	}
+	if create, ok := DECISION_HEURISTICS[name]; ok {
+		return nil, create()
+	}
+	return fmt.Errorf("Decision heuristic with name '%s' not found.", name), nil
+}
+
+/**
+ * Check if the variable can be used for the decision.
+ */
+func (solver *CDCLSolver) isDecisionCandidate(v sat_solver.CNFLiteral) bool {
+	_, isAssigned := solver.currentAssignment[v]
+	return !isAssigned && !solver.eliminatedVars[v]
+}
+
+/*
+ * Pop variables from the queue until the one that can be used for the decision is found.
+ * Assigned variables are not removed from the queue when they are assigned, but only here.
+ * They are inserted back by insertVariable() when they are unassigned.
+ */
+func (solver *CDCLSolver) popDecisionCandidate(queue *LiteralPriorityQueue) (sat_solver.CNFLiteral, bool) {
+	for queue.Len() > 0 {
+		v := heap.Pop(queue).(*PQLitItem).value
+		if solver.isDecisionCandidate(v) {
+			return v, true
+		}
+	}
+	return sat_solver.CNF_UNDEFINED, false
+}
+
+/*
+ * Insert the variable into the queue if it's not there yet.
+ */
+func insertVariable(queue *LiteralPriorityQueue, v sat_solver.CNFLiteral) {
+	if !queue.Has(v) {
+		heap.Push(queue, &PQLitItem{ value: v })
+	}
+}
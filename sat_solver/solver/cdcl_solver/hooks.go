@@ -0,0 +1,81 @@
+package cdcl_solver
+
+/**
+ * This file provides the observer interface of the CDCL solver.
+ *
+ * Observers added with AddObserver() are notified about the events of the search, so custom tools can be built
+ * on top of the solver: export of the learned clauses, visualisation of the search or stopping the search
+ * when something interesting happens (any hook can call Interrupt()).
+ * Observers are called synchronously from the search, in the order they were added.
+ * Clauses passed to the hooks belong to the solver and can change later, so they must be copied to be kept.
+ *
+ * When no observer is added, the only cost is checking the empty list of observers.
+ *
+ * Example:
+ *     type learnedClausePrinter struct { cdcl_solver.NoopSolverObserver }
+ *
+ *     func (p learnedClausePrinter) OnLearn(clause sat_solver.CNFClause, lbd int) {
+ *         fmt.Printf("learned %s (LBD %d)\n", clause.DebugString(), lbd)
+ *     }
+ *
+ *     s := cdcl_solver.NewCDCLSolver()
+ *     s.AddObserver(learnedClausePrinter{})
+ *     err, result := s.Solve(formula, context)
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type SolverObserver interface {
+	// New decision was made on the given level (including the assumptions)
+	OnDecision(literal sat_solver.CNFLiteral, level int)
+	// The literal was implied by the reason clause (the literal is the first one in the clause)
+	OnPropagate(literal sat_solver.CNFLiteral, reason sat_solver.CNFClause)
+	// All the literals of the clause are false
+	OnConflict(clause sat_solver.CNFClause)
+	// The clause was learned from the last conflict, lbd is the number of its distinct decision levels
+	OnLearn(clause sat_solver.CNFClause, lbd int)
+	// The search went back to the given level after a conflict
+	OnBacktrack(level int)
+	// The search went back to the level 0 because of the restart policy
+	OnRestart()
+	// The satisfying assignment was found
+	OnModel(model map[string]bool)
+}
+
+/**
+ * Observer that ignores all the events.
+ * Embed it to implement only some of the hooks.
+ */
+type NoopSolverObserver struct {}
+
+func (o NoopSolverObserver) OnDecision(literal sat_solver.CNFLiteral, level int) {}
+func (o NoopSolverObserver) OnPropagate(literal sat_solver.CNFLiteral, reason sat_solver.CNFClause) {}
+func (o NoopSolverObserver) OnConflict(clause sat_solver.CNFClause) {}
+func (o NoopSolverObserver) OnLearn(clause sat_solver.CNFClause, lbd int) {}
+func (o NoopSolverObserver) OnBacktrack(level int) {}
+func (o NoopSolverObserver) OnRestart() {}
+func (o NoopSolverObserver) OnModel(model map[string]bool) {}
+
+type SolverHooksState struct {
+	// Observers notified about the search
+	observers     []SolverObserver
+	// Was the search interrupted by an observer?
+	isInterrupted bool
+}
+
+/**
+ * Add observer that is notified about the search events.
+ */
+func (solver *CDCLSolver) AddObserver(observer SolverObserver) {
+	solver.observers = append(solver.observers, observer)
+}
+
+/**
+ * Stop the search as soon as possible, the search returns the undefined result.
+ * It's meant to be called from the hooks. The next search (see SolveWithAssumptions) runs normally.
+ */
+func (solver *CDCLSolver) Interrupt() {
+	solver.isInterrupted = true
+}
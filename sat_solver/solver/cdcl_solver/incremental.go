@@ -0,0 +1,166 @@
+package cdcl_solver
+
+/**
+ * This file provides the incremental interface of the CDCL solver.
+ *
+ * After the formula is loaded, the solver can be called many times with different assumptions and new clauses
+ * can be added between the calls. The learned clauses are kept, so the following calls are usually much faster.
+ *
+ * The assumptions are decided before any other literal, one per decision level. If an assumption is already true,
+ * then an empty decision level is created, so the decision level of the assumption i is always i+1.
+ * If an assumption is false, then the formula is UNSAT under the assumptions and the implication graph is walked
+ * back to find the assumptions responsible for that (analyzeFinal() in Minisat).
+ *
+ * Variables used in the assumptions and in the added clauses must not be eliminated by the inprocessing,
+ * so they are frozen.
+ *
+ * For code reference please see Minisat:
+ *   Solver::analyzeFinal  https://github.com/niklasso/minisat/blob/master/minisat/core/Solver.cc#L401
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+type SolverAssumptionsState struct {
+	// Literals that are decided before any other decision
+	assumptions       []sat_solver.CNFLiteral
+	// Assumptions that caused the last UNSAT result
+	failedAssumptions []sat_solver.CNFLiteral
+	// The formula is UNSAT without any assumptions
+	isUnsat           bool
+	// Number of the finished searches
+	searchesCount     int
+}
+
+/**
+ * Solve the loaded formula under the assumptions.
+ * When the result is UNSAT, the subset of the assumptions that is enough to make the formula UNSAT is returned.
+ * The subset is empty if the formula is UNSAT without any assumptions.
+ */
+func (solver *CDCLSolver) SolveWithAssumptions(assumptions []sat_solver.CNFLiteral) (error, solver.SolverResult, []sat_solver.CNFLiteral) {
+	if solver.searchesCount > 0 && !solver.isUnsat {
+		solver.reverseToDecisionLevel(0)
+	}
+	solver.searchesCount++
+	solver.assumptions = assumptions
+	solver.failedAssumptions = []sat_solver.CNFLiteral{}
+	for _, literal := range assumptions {
+		solver.frozenVars[literal.Var()] = true
+	}
+
+	result := solver.search()
+	solver.assumptions = nil
+	if result.IsUNSAT() && !solver.isUnsat {
+		return nil, result, solver.failedAssumptions
+	}
+	return nil, result, []sat_solver.CNFLiteral{}
+}
+
+/**
+ * Add clause to the loaded formula. It's used between the calls of SolveWithAssumptions().
+ * Returns false if the formula became UNSAT.
+ */
+func (solver *CDCLSolver) AddClause(clause sat_solver.CNFClause) bool {
+	if solver.isUnsat {
+		return false
+	}
+	solver.reverseToDecisionLevel(0)
+	for _, literal := range clause {
+		solver.frozenVars[literal.Var()] = true
+	}
+	if !solver.addClause(clause) {
+		solver.isUnsat = true
+		return false
+	}
+	return true
+}
+
+/**
+ * Create new variable that can be used in the added clauses.
+ */
+func (solver *CDCLSolver) NewVariable() sat_solver.CNFLiteral {
+	_, v := solver.vars.Fresh()
+	solver.addVariable(v)
+	return v
+}
+
+/**
+ * Create new variable with the given name. Unlike the fresh variables, it's included in the satisfying assignments.
+ * If the name is already used, then its variable is returned.
+ */
+func (solver *CDCLSolver) NewNamedVariable(name string) sat_solver.CNFLiteral {
+	if v, ok := solver.vars.Lookup(name); ok {
+		return v
+	}
+	v := solver.vars.Get(name)
+	solver.addVariable(v)
+	return v
+}
+
+func (solver *CDCLSolver) addVariable(v sat_solver.CNFLiteral) {
+	for _, heuristic := range solver.heuristics {
+		heuristic.AddVariable(v)
+	}
+}
+
+/**
+ * Prevent the variable from being eliminated, so it can be used later in the assumptions or the added clauses.
+ */
+func (solver *CDCLSolver) FreezeVariable(v sat_solver.CNFLiteral) {
+	solver.frozenVars[v.Var()] = true
+}
+
+/**
+ * Decide the next assumption.
+ * Returns false if the assumption is false, so the formula is UNSAT under the assumptions.
+ */
+func (solver *CDCLSolver) decideNextAssumption() bool {
+	literal := solver.assumptions[solver.getDecisionLevel()]
+	value := solver.currentLiteralValue(literal)
+	if value.IsTrue() {
+		// Keep the decision levels aligned with the assumptions
+		solver.decisionTrace = append(solver.decisionTrace, len(solver.assignmentTrace))
+		return true
+	} else if value.IsFalse() {
+		solver.failedAssumptions = solver.analyzeFinal(literal)
+		if solver.enableDebugLogging {
+			solver.context.Trace("assumptions", "Assumption %s is false, %d assumptions failed.", literal.String(solver.vars), len(solver.failedAssumptions))
+		}
+		return false
+	}
+	solver.newDecision(literal)
+	return true
+}
+
+/**
+ * Find the assumptions that imply the negation of the given assumption literal.
+ * All the decisions on the trace are assumptions at this point, so the walk back through the reasons
+ * stops at the decisions and they are collected.
+ */
+func (solver *CDCLSolver) analyzeFinal(literal sat_solver.CNFLiteral) []sat_solver.CNFLiteral {
+	failed := []sat_solver.CNFLiteral{ literal }
+	if solver.getDecisionLevel() == 0 || solver.getDecisionLevelForVar(literal.Var()) == 0 {
+		return failed
+	}
+	seen := map[sat_solver.CNFLiteral]bool{ literal.Var(): true }
+	for i := len(solver.assignmentTrace) - 1; i >= solver.decisionTrace[0]; i-- {
+		traceLiteral := solver.assignmentTrace[i]
+		v := traceLiteral.Var()
+		if !seen[v] {
+			continue
+		}
+		reason := solver.getReasonClause(v)
+		if reason == nil {
+			failed = append(failed, traceLiteral)
+			continue
+		}
+		for _, reasonLiteral := range reason {
+			if reasonLiteral.Var() != v && solver.getDecisionLevelForVar(reasonLiteral.Var()) > 0 {
+				seen[reasonLiteral.Var()] = true
+			}
+		}
+	}
+	return failed
+}
@@ -0,0 +1,674 @@
+package cdcl_solver
+
+/**
+ * This file provides the inprocessing framework for the CDCL solver.
+ *
+ * Preprocessing (see preprocessor package) runs only once, before the search starts.
+ * Inprocessing periodically stops the search, goes back to the decision level 0 and simplifies the live
+ * clause database (both the input and the learned clauses) using the units discovered so far.
+ * The scheduler runs the registered passes one by one. After each pass the database is brought back to the
+ * level 0 fixpoint: satisfied clauses are removed, false literals are dropped and the watches are rebuilt,
+ * so the TWL invariant holds again before the search continues.
+ *
+ * For more details please see:
+ *   "Inprocessing Rules" by Matti Järvisalo, Marijn J.H. Heule and Armin Biere
+ *     https://www.cs.utexas.edu/~marijn/publications/inprocessing.pdf
+ *   "Effective Preprocessing in SAT through Variable and Clause Elimination" by Niklas Eén and Armin Biere
+ *     http://fmv.jku.at/papers/EenBiere-SAT05.pdf
+ *
+ * For code reference please see the Minisat SimpSolver:
+ *   https://github.com/niklasso/minisat/blob/master/minisat/simp/SimpSolver.cc
+ */
+
+import (
+	"sort"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+const (
+	// Number of conflicts before the first inprocessing round
+	INPROCESSING_FIRST_INTERVAL = 500
+	// The interval between rounds grows by this number of conflicts after each round
+	INPROCESSING_INTERVAL_INCREMENT = 500
+	// Maximum number of variables probed in a single round
+	PROBING_VARS_LIMIT = 300
+	// Maximum number of literal comparisons done by subsumption in a single round
+	SUBSUMPTION_STEPS_LIMIT = 2000000
+	// Variables with more occurrences of both polarities are not eliminated
+	ELIMINATION_OCCURRENCES_LIMIT = 10
+	// Variables are not eliminated if that would create a resolvent longer than this
+	ELIMINATION_RESOLVENT_LENGTH_LIMIT = 20
+)
+
+type SolverInprocessingState struct {
+	// Is the inprocessing enabled at all?
+	enableInprocessing bool
+	// Number of conflicts encountered since the solver started
+	conflictsCount int
+	// Inprocessing round is started when conflictsCount reaches this value
+	nextInprocessingConflicts int
+	// Number of already finished inprocessing rounds
+	inprocessingRounds int
+	// Number of level 0 assignments when the clause database was simplified for the last time
+	lastSimplificationUnits int
+	// Variables removed from the clause database by the variable elimination
+	eliminatedVars map[sat_solver.CNFLiteral]bool
+	// Variables that should never be eliminated
+	frozenVars map[sat_solver.CNFLiteral]bool
+	// Clauses removed by variable elimination, used to extend the model of the remaining formula
+	eliminationStack []EliminatedClause
+}
+
+/**
+ * Single clause removed by the variable elimination.
+ * When the model is extended the pivot literal is set to true if the clause is not satisfied.
+ */
+type EliminatedClause struct {
+	pivot  sat_solver.CNFLiteral
+	clause sat_solver.CNFClause
+}
+
+/**
+ * Single simplification technique that can be run during the search.
+ * Each pass works at decision level 0 and returns false only if it found the formula to be UNSAT.
+ */
+type InprocessingPass struct {
+	name string
+	run  func(solver *CDCLSolver) bool
+}
+
+/**
+ * Passes executed (in order) on every inprocessing round.
+ * Removing satisfied clauses is not listed here, because it's done after each pass anyway.
+ */
+var INPROCESSING_PASSES = []InprocessingPass{
+	{ name: "probe",     run: (*CDCLSolver).probeLiterals },
+	{ name: "subsume",   run: (*CDCLSolver).subsumeClauses },
+	{ name: "eliminate", run: (*CDCLSolver).eliminateVariables },
+}
+
+/**
+ * Init the inprocessing scheduler.
+ */
+func (solver *CDCLSolver) inprocessingInit() {
+	solver.enableInprocessing = solver.context.GetConfiguration().EnableInprocessing
+	solver.nextInprocessingConflicts = INPROCESSING_FIRST_INTERVAL
+	solver.eliminatedVars = map[sat_solver.CNFLiteral]bool{}
+	solver.frozenVars = map[sat_solver.CNFLiteral]bool{}
+}
+
+/**
+ * Check if the scheduler wants to run the inprocessing now.
+ */
+func (solver *CDCLSolver) shouldInprocess() bool {
+	return solver.enableInprocessing && solver.conflictsCount >= solver.nextInprocessingConflicts
+}
+
+/**
+ * Go back to the decision level 0 and run all the inprocessing passes.
+ * Returns false if the formula was found to be UNSAT.
+ */
+func (solver *CDCLSolver) inprocess() bool {
+	solver.inprocessingRounds++
+	solver.nextInprocessingConflicts = solver.conflictsCount + INPROCESSING_FIRST_INTERVAL + solver.inprocessingRounds * INPROCESSING_INTERVAL_INCREMENT
+
+	if solver.enableDebugLogging {
+		solver.context.Trace("inprocess", "Starting inprocessing round %d (%d conflicts, %d clauses, %d learned clauses).",
+			solver.inprocessingRounds, solver.conflictsCount, len(solver.clauses), len(solver.learnedClauses))
+	}
+
+	solver.reverseToDecisionLevel(0)
+	if !solver.simplifyAtLevelZero() {
+		return false
+	}
+	for _, pass := range INPROCESSING_PASSES {
+		if !pass.run(solver) {
+			if solver.enableDebugLogging {
+				solver.context.Trace("inprocess", "Pass %s proved the formula is UNSAT.", pass.name)
+			}
+			return false
+		}
+		if !solver.simplifyAtLevelZero() {
+			return false
+		}
+		if solver.enableDebugLogging {
+			solver.context.Trace("inprocess", "Finished pass %s (%d clauses, %d learned clauses, %d units, %d eliminated vars).",
+				pass.name, len(solver.clauses), len(solver.learnedClauses), len(solver.assignmentTrace), len(solver.eliminatedVars))
+		}
+	}
+	solver.lastSimplificationUnits = len(solver.assignmentTrace)
+	return true
+}
+
+/**
+ * Check if the search is at level 0 and some new units were found since the last simplification.
+ */
+func (solver *CDCLSolver) hasNewLevelZeroUnits() bool {
+	return solver.enableInprocessing && solver.getDecisionLevel() == 0 && len(solver.assignmentTrace) > solver.lastSimplificationUnits
+}
+
+/**
+ * Use the new level 0 units to remove satisfied clauses and false literals from the clause database.
+ * Returns false if the formula was found to be UNSAT.
+ */
+func (solver *CDCLSolver) simplifyNewUnits() bool {
+	if !solver.simplifyAtLevelZero() {
+		return false
+	}
+	solver.lastSimplificationUnits = len(solver.assignmentTrace)
+	return true
+}
+
+/**
+ * Propagate all the units at level 0, remove satisfied clauses and false literals from the clause database.
+ * After this function returns true, there are no assigned literals inside the clauses and the watches are rebuilt.
+ * Returns false if the formula was found to be UNSAT.
+ */
+func (solver *CDCLSolver) simplifyAtLevelZero() bool {
+	for {
+		if solver.performUnitPropagation() != nil {
+			return false
+		}
+		units := []sat_solver.CNFLiteral{}
+		ok := true
+		solver.clauses, ok = solver.removeFalsifiedLiterals(solver.clauses, &units)
+		if !ok {
+			return false
+		}
+		solver.learnedClauses, ok = solver.removeFalsifiedLiterals(solver.learnedClauses, &units)
+		if !ok {
+			return false
+		}
+		solver.rebuildWatches()
+		if len(units) == 0 {
+			return true
+		}
+		for _, unit := range units {
+			value := solver.currentLiteralValue(unit)
+			if value.IsFalse() {
+				return false
+			} else if !value.IsTrue() {
+				solver.performLiteralAssertion(unit, nil)
+			}
+		}
+	}
+}
+
+/**
+ * Remove satisfied clauses and false literals from the given clauses.
+ * Clauses that became units are removed and their literals are appended to units.
+ * Returns false if any of the clauses became empty.
+ */
+func (solver *CDCLSolver) removeFalsifiedLiterals(clauses []sat_solver.CNFClause, units *[]sat_solver.CNFLiteral) ([]sat_solver.CNFClause, bool) {
+	result := clauses[:0]
+	for _, clause := range clauses {
+		isSatisfied := false
+		falseCount := 0
+		for _, literal := range clause {
+			value := solver.currentLiteralValue(literal)
+			if value.IsTrue() {
+				isSatisfied = true
+				break
+			} else if value.IsFalse() {
+				falseCount++
+			}
+		}
+		if isSatisfied {
+			continue
+		}
+		if falseCount > 0 {
+			newClause := make(sat_solver.CNFClause, 0, len(clause) - falseCount)
+			for _, literal := range clause {
+				if !solver.currentLiteralValue(literal).IsFalse() {
+					newClause = append(newClause, literal)
+				}
+			}
+			clause = newClause
+		}
+		if len(clause) == 0 {
+			return result, false
+		} else if len(clause) == 1 {
+			*units = append(*units, clause[0])
+			continue
+		}
+		result = append(result, clause)
+	}
+	return result, true
+}
+
+/**
+ * Drop all the watches and watch each clause from the database again.
+ */
+func (solver *CDCLSolver) rebuildWatches() {
+	solver.watchedLiterals = map[sat_solver.CNFLiteral][]*TWLRecord{}
+	for _, clause := range solver.clauses {
+		solver.watchClause(clause)
+	}
+	for _, clause := range solver.learnedClauses {
+		solver.watchClause(clause)
+	}
+}
+
+/**
+ * Failed literal probing.
+ * For a variable x we assert x on a new decision level and propagate it. If that leads to a conflict, then -x
+ * must hold at level 0. If both x and -x imply the same literal, then that literal must hold at level 0 as well.
+ */
+func (solver *CDCLSolver) probeLiterals() bool {
+	// Probe only variables that occur in binary clauses, because only those can start propagation chains
+	candidatesSet := map[sat_solver.CNFLiteral]struct{}{}
+	for _, clause := range solver.clauses {
+		if len(clause) == 2 {
+			candidatesSet[clause[0].Var()] = struct{}{}
+			candidatesSet[clause[1].Var()] = struct{}{}
+		}
+	}
+	candidates := make([]sat_solver.CNFLiteral, 0, len(candidatesSet))
+	for v := range candidatesSet {
+		candidates = append(candidates, v)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > PROBING_VARS_LIMIT {
+		// Rotate the candidates so each round probes a different part of the formula
+		offset := (solver.inprocessingRounds * PROBING_VARS_LIMIT) % len(candidates)
+		candidates = append(candidates[offset:], candidates[:offset]...)[:PROBING_VARS_LIMIT]
+	}
+
+	for _, v := range candidates {
+		if !solver.currentLiteralValue(v).IsUndefined() {
+			continue
+		}
+		failed, positiveImplied := solver.probe(v)
+		if failed {
+			if !solver.assertAtLevelZero(-v) {
+				return false
+			}
+			continue
+		}
+		failed, negativeImplied := solver.probe(-v)
+		if failed {
+			if !solver.assertAtLevelZero(v) {
+				return false
+			}
+			continue
+		}
+		for literal := range positiveImplied {
+			if _, ok := negativeImplied[literal]; ok {
+				if !solver.assertAtLevelZero(literal) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+/**
+ * Assert the literal on a new decision level and propagate it.
+ * Returns true if that leads to a conflict, otherwise returns all the implied literals.
+ * The solver is always brought back to the decision level 0.
+ */
+func (solver *CDCLSolver) probe(literal sat_solver.CNFLiteral) (bool, map[sat_solver.CNFLiteral]struct{}) {
+	start := len(solver.assignmentTrace)
+	solver.decisionTrace = append(solver.decisionTrace, start)
+	solver.performLiteralAssertion(literal, nil)
+	conflict := solver.performUnitPropagation()
+	implied := map[sat_solver.CNFLiteral]struct{}{}
+	if conflict == nil {
+		for _, impliedLiteral := range solver.assignmentTrace[start+1:] {
+			implied[impliedLiteral] = struct{}{}
+		}
+	}
+	solver.reverseToDecisionLevel(0)
+	return conflict != nil, implied
+}
+
+/**
+ * Assert literal at level 0 (if it's not assigned yet) and propagate it.
+ * Returns false if that leads to a conflict.
+ */
+func (solver *CDCLSolver) assertAtLevelZero(literal sat_solver.CNFLiteral) bool {
+	value := solver.currentLiteralValue(literal)
+	if value.IsFalse() {
+		return false
+	} else if value.IsTrue() {
+		return true
+	}
+	solver.performLiteralAssertion(literal, nil)
+	return solver.performUnitPropagation() == nil
+}
+
+/**
+ * Clause entry used by the subsumption pass.
+ */
+type subsumptionEntry struct {
+	clause    sat_solver.CNFClause
+	signature uint64
+	isLearned bool
+	isDeleted bool
+}
+
+func clauseSignature(clause sat_solver.CNFClause) uint64 {
+	signature := uint64(0)
+	for _, literal := range clause {
+		signature |= uint64(1) << (uint64(literal.Var()) % 64)
+	}
+	return signature
+}
+
+/**
+ * Check if the clause subsumes the other one.
+ * Returns (true, 0) if clause is a subset of other.
+ * Returns (true, l) if clause with l negated is a subset of other, so -l can be removed from the other clause (strengthening).
+ * Otherwise returns (false, 0).
+ */
+func subsumptionCheck(clause sat_solver.CNFClause, other sat_solver.CNFClause, steps *int) (bool, sat_solver.CNFLiteral) {
+	flipped := sat_solver.CNF_UNDEFINED
+	for _, literal := range clause {
+		found := false
+		for _, otherLiteral := range other {
+			*steps++
+			if literal == otherLiteral {
+				found = true
+				break
+			} else if literal == -otherLiteral && flipped == sat_solver.CNF_UNDEFINED {
+				flipped = literal
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, sat_solver.CNF_UNDEFINED
+		}
+	}
+	return true, flipped
+}
+
+/**
+ * Backward subsumption and self-subsuming resolution (strengthening).
+ * Clause C subsumes D if C is a subset of D, then D can be removed.
+ * If C with a single literal l negated is a subset of D, then -l can be removed from D.
+ * A learned clause that subsumes an input clause becomes an input clause itself.
+ */
+func (solver *CDCLSolver) subsumeClauses() bool {
+	entries := make([]*subsumptionEntry, 0, len(solver.clauses) + len(solver.learnedClauses))
+	for _, clause := range solver.clauses {
+		entries = append(entries, &subsumptionEntry{ clause: clause, signature: clauseSignature(clause) })
+	}
+	for _, clause := range solver.learnedClauses {
+		entries = append(entries, &subsumptionEntry{ clause: clause, signature: clauseSignature(clause), isLearned: true })
+	}
+
+	occur := map[sat_solver.CNFLiteral][]*subsumptionEntry{}
+	for _, entry := range entries {
+		for _, literal := range entry.clause {
+			occur[literal.Var()] = append(occur[literal.Var()], entry)
+		}
+	}
+
+	queue := make([]*subsumptionEntry, len(entries))
+	copy(queue, entries)
+	sort.SliceStable(queue, func(i, j int) bool {
+		return len(queue[i].clause) < len(queue[j].clause)
+	})
+
+	steps := 0
+	for len(queue) > 0 && steps < SUBSUMPTION_STEPS_LIMIT {
+		entry := queue[0]
+		queue = queue[1:]
+		if entry.isDeleted {
+			continue
+		}
+
+		// Any clause subsumed or strengthened by the entry contains its least occurring variable
+		bestVar := entry.clause[0].Var()
+		for _, literal := range entry.clause[1:] {
+			if len(occur[literal.Var()]) < len(occur[bestVar]) {
+				bestVar = literal.Var()
+			}
+		}
+
+		for _, other := range occur[bestVar] {
+			if other == entry || other.isDeleted || len(other.clause) < len(entry.clause) {
+				continue
+			}
+			if entry.signature & ^other.signature != 0 {
+				continue
+			}
+			ok, flipped := subsumptionCheck(entry.clause, other.clause, &steps)
+			if !ok {
+				continue
+			}
+			if flipped == sat_solver.CNF_UNDEFINED {
+				other.isDeleted = true
+				if entry.isLearned && !other.isLearned {
+					entry.isLearned = false
+				}
+			} else {
+				newClause := make(sat_solver.CNFClause, 0, len(other.clause) - 1)
+				for _, literal := range other.clause {
+					if literal != -flipped {
+						newClause = append(newClause, literal)
+					}
+				}
+				if len(newClause) == 0 {
+					return false
+				}
+				other.clause = newClause
+				other.signature = clauseSignature(newClause)
+				// The strengthened clause can now subsume other clauses
+				queue = append(queue, other)
+			}
+		}
+	}
+
+	solver.clauses = solver.clauses[:0]
+	solver.learnedClauses = solver.learnedClauses[:0]
+	for _, entry := range entries {
+		if entry.isDeleted {
+			continue
+		}
+		if entry.isLearned {
+			solver.learnedClauses = append(solver.learnedClauses, entry.clause)
+		} else {
+			solver.clauses = append(solver.clauses, entry.clause)
+		}
+	}
+	return true
+}
+
+/**
+ * Bounded variable elimination by clause distribution.
+ * Variable x is eliminated by replacing all the clauses containing x or -x with all the non-tautological
+ * resolvents on x, but only if this does not increase the number of clauses.
+ * The removed clauses are pushed onto the elimination stack, so the model can be extended later.
+ * Learned clauses containing eliminated variables are simply dropped.
+ */
+func (solver *CDCLSolver) eliminateVariables() bool {
+	isDeleted := map[int]bool{}
+	clauses := solver.clauses
+	occur := map[sat_solver.CNFLiteral][]int{}
+	for i, clause := range clauses {
+		for _, literal := range clause {
+			occur[literal] = append(occur[literal], i)
+		}
+	}
+
+	candidates := []sat_solver.CNFLiteral{}
+	for literal := range occur {
+		v := literal.Var()
+		if literal > 0 || len(occur[v]) == 0 {
+			if !solver.frozenVars[v] && !solver.eliminatedVars[v] && solver.currentLiteralValue(v).IsUndefined() {
+				candidates = append(candidates, v)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		costI := len(occur[candidates[i]]) * len(occur[-candidates[i]])
+		costJ := len(occur[candidates[j]]) * len(occur[-candidates[j]])
+		if costI == costJ {
+			return candidates[i] < candidates[j]
+		}
+		return costI < costJ
+	})
+
+	liveOccurrences := func(literal sat_solver.CNFLiteral) []int {
+		live := occur[literal][:0]
+		for _, i := range occur[literal] {
+			if !isDeleted[i] {
+				live = append(live, i)
+			}
+		}
+		occur[literal] = live
+		return live
+	}
+
+	eliminated := map[sat_solver.CNFLiteral]bool{}
+	for _, v := range candidates {
+		positive := liveOccurrences(v)
+		negative := liveOccurrences(-v)
+		if len(positive) == 0 && len(negative) == 0 {
+			continue
+		}
+		if len(positive) > ELIMINATION_OCCURRENCES_LIMIT && len(negative) > ELIMINATION_OCCURRENCES_LIMIT {
+			// Heuristic cut-off
+			continue
+		}
+
+		resolvents := []sat_solver.CNFClause{}
+		tooExpensive := false
+		for _, i := range positive {
+			for _, j := range negative {
+				resolvent, isTautology := resolve(clauses[i], clauses[j], v)
+				if isTautology {
+					continue
+				}
+				if len(resolvent) > ELIMINATION_RESOLVENT_LENGTH_LIMIT || len(resolvents) >= len(positive) + len(negative) {
+					tooExpensive = true
+					break
+				}
+				resolvents = append(resolvents, resolvent)
+			}
+			if tooExpensive {
+				break
+			}
+		}
+		if tooExpensive {
+			continue
+		}
+
+		// Eliminate the variable
+		for _, i := range positive {
+			solver.eliminationStack = append(solver.eliminationStack, EliminatedClause{ pivot: v, clause: clauses[i] })
+			isDeleted[i] = true
+		}
+		for _, i := range negative {
+			solver.eliminationStack = append(solver.eliminationStack, EliminatedClause{ pivot: -v, clause: clauses[i] })
+			isDeleted[i] = true
+		}
+		for _, resolvent := range resolvents {
+			index := len(clauses)
+			clauses = append(clauses, resolvent)
+			for _, literal := range resolvent {
+				occur[literal] = append(occur[literal], index)
+			}
+		}
+		eliminated[v] = true
+		solver.eliminatedVars[v] = true
+	}
+
+	if len(eliminated) == 0 {
+		return true
+	}
+
+	solver.clauses = make([]sat_solver.CNFClause, 0, len(clauses))
+	for i, clause := range clauses {
+		if !isDeleted[i] {
+			if len(clause) == 0 {
+				return false
+			}
+			solver.clauses = append(solver.clauses, clause)
+		}
+	}
+	learnedClauses := solver.learnedClauses[:0]
+	for _, clause := range solver.learnedClauses {
+		containsEliminated := false
+		for _, literal := range clause {
+			if eliminated[literal.Var()] {
+				containsEliminated = true
+				break
+			}
+		}
+		if !containsEliminated {
+			learnedClauses = append(learnedClauses, clause)
+		}
+	}
+	solver.learnedClauses = learnedClauses
+
+	// Single literal resolvents are handled by simplifyAtLevelZero(), but it cannot see them in the watches yet
+	for _, clause := range solver.clauses {
+		if len(clause) == 1 {
+			if !solver.assertAtLevelZero(clause[0]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+/**
+ * Resolve two clauses on the given variable. The first clause must contain v and the second one -v.
+ * Returns true as the second value if the resolvent is a tautology.
+ */
+func resolve(clause sat_solver.CNFClause, other sat_solver.CNFClause, v sat_solver.CNFLiteral) (sat_solver.CNFClause, bool) {
+	resolvent := make(sat_solver.CNFClause, 0, len(clause) + len(other) - 2)
+	for _, literal := range clause {
+		if literal != v {
+			resolvent = append(resolvent, literal)
+		}
+	}
+	for _, literal := range other {
+		if literal == -v {
+			continue
+		}
+		isDuplicate := false
+		for _, existing := range resolvent {
+			if existing == literal {
+				isDuplicate = true
+				break
+			} else if existing == -literal {
+				return nil, true
+			}
+		}
+		if !isDuplicate {
+			resolvent = append(resolvent, literal)
+		}
+	}
+	return resolvent, false
+}
+
+/**
+ * Extend the model with values for the eliminated variables.
+ * The elimination stack is processed backwards and each clause that is not satisfied gets its pivot flipped.
+ */
+func (solver *CDCLSolver) extendModel(model map[sat_solver.CNFLiteral]bool) {
+	for v := range solver.eliminatedVars {
+		model[v] = false
+	}
+	for i := len(solver.eliminationStack) - 1; i >= 0; i-- {
+		entry := solver.eliminationStack[i]
+		isSatisfied := false
+		for _, literal := range entry.clause {
+			if model[literal.Var()] == (literal > 0) {
+				isSatisfied = true
+				break
+			}
+		}
+		if !isSatisfied {
+			model[entry.pivot.Var()] = entry.pivot > 0
+		}
+	}
+}
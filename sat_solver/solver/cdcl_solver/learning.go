@@ -17,6 +17,8 @@ import (
 type SolverLearnState struct {
 	// Learned clause is stored here
 	currentLearnedClause   sat_solver.CNFClause
+	// All the clauses learned so far (except for the units which are asserted at level 0)
+	learnedClauses         []sat_solver.CNFClause
 	// Map of visited literals is used only in learnClause() to prevent updating literals twice
 	visited                map[sat_solver.CNFLiteral]bool
 }
@@ -45,7 +47,9 @@ func (solver *CDCLSolver) learnClause(conflictingClause sat_solver.CNFClause) in
 			}
 			learnedVarLevel := solver.getDecisionLevelForVar(learnedVar)
 			if !solver.visited[learnedVar] && learnedVarLevel > 0 {
-				solver.avsidsBumpVarActivity(learnedVar);
+				for _, heuristic := range solver.heuristics {
+					heuristic.BumpVariable(learnedVar)
+				}
 				if learnedVarLevel >= solver.getDecisionLevel() {
 					literalsLeft++
 				} else {
@@ -56,13 +60,14 @@ func (solver *CDCLSolver) learnClause(conflictingClause sat_solver.CNFClause) in
 		}
 
 		/**
-		 * This loop gets the last visited literal on a trace
+		 * This loop gets the last visited literal of the current decision level on a trace
+		 * (with chronological backtracking the literals of the lower levels can be placed after them)
 		 */
 		traceVisitedLiteral := solver.assignmentTrace[traceIndex]
 		if traceVisitedLiteral < 0 {
 			traceVisitedLiteral = -traceVisitedLiteral
 		}
-		for !solver.visited[traceVisitedLiteral] {
+		for !solver.visited[traceVisitedLiteral] || solver.getDecisionLevelForVar(traceVisitedLiteral) < solver.getDecisionLevel() {
 			traceIndex = traceIndex-1
 			traceVisitedLiteral = solver.assignmentTrace[traceIndex]
 			if traceVisitedLiteral < 0 {
@@ -78,7 +83,7 @@ func (solver *CDCLSolver) learnClause(conflictingClause sat_solver.CNFClause) in
 			traceLiteralVar = -traceLiteralVar
 		}
 		solver.visited[traceLiteralVar] = false
-		conflictingClause = solver.varsInfo[traceLiteralVar].reasonClause
+		conflictingClause = solver.getReasonClause(traceLiteralVar)
 
 		if literalsLeft <= 1 {
 			break
@@ -0,0 +1,113 @@
+package cdcl_solver
+
+/**
+ * This file provides the LRB (learning rate based branching) decision heuristic.
+ *
+ * The score of a variable estimates its learning rate: the fraction of the conflicts that happened
+ * while the variable was assigned, in which the variable took part in the conflict analysis.
+ * When the variable is unassigned, the rate of its last assignment interval is computed as:
+ *     participated / (conflicts since the assignment)
+ * and the score is updated as the exponential moving average: Q = (1 - step) * Q + step * rate.
+ * The step changes in the same way as in CHB (see chb.go).
+ * The decision picks the unassigned variable with the highest score.
+ *
+ * For more details please see:
+ *   "Learning Rate Based Branching Heuristic for SAT Solvers"
+ *     by Jia Hui Liang, Vijay Ganesh, Pascal Poupart and Krzysztof Czarnecki (SAT 2016)
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type LRB struct {
+	// Solver that uses the heuristic
+	solver       *CDCLSolver
+	// Scores of the variables
+	score        map[sat_solver.CNFLiteral]float64
+	// Number of the conflicts when the variable was assigned
+	assignedAt   map[sat_solver.CNFLiteral]int
+	// Number of the conflicts in which the variable took part since it was assigned
+	participated map[sat_solver.CNFLiteral]int
+	// Number of the conflicts so far
+	conflicts    int
+	step         float64
+	// Priority queue with variables sorted by the score
+	queue        *LiteralPriorityQueue
+}
+
+func (lrb *LRB) GetName() string {
+	return "lrb"
+}
+
+/**
+ * Init the scores of all the variables.
+ */
+func (lrb *LRB) Init(solver *CDCLSolver) {
+	lrb.solver = solver
+	lrb.score = map[sat_solver.CNFLiteral]float64{}
+	lrb.assignedAt = map[sat_solver.CNFLiteral]int{}
+	lrb.participated = map[sat_solver.CNFLiteral]int{}
+	lrb.conflicts = 0
+	lrb.step = ERWA_INITIAL_STEP
+	vars := solver.vars.GetAllVariables()
+	for _, v := range vars {
+		lrb.score[v.Var()] = 0
+	}
+	lrb.queue = NewLiteralPriorityQueue(vars, lrb.score)
+}
+
+/**
+ * Add new variable with zero score.
+ */
+func (lrb *LRB) AddVariable(v sat_solver.CNFLiteral) {
+	lrb.score[v] = 0
+	insertVariable(lrb.queue, v)
+}
+
+/**
+ * Count the conflict for the variable.
+ */
+func (lrb *LRB) BumpVariable(v sat_solver.CNFLiteral) {
+	lrb.participated[v.Var()]++
+}
+
+/**
+ * Count the conflict and decrease the step.
+ */
+func (lrb *LRB) Decay(clause sat_solver.CNFClause) {
+	lrb.conflicts++
+	if lrb.step > ERWA_MIN_STEP {
+		lrb.step -= ERWA_STEP_DECAY
+	}
+}
+
+/**
+ * Return the unassigned variable with the highest score.
+ */
+func (lrb *LRB) PickNext() (sat_solver.CNFLiteral, bool) {
+	return lrb.solver.popDecisionCandidate(lrb.queue)
+}
+
+/**
+ * Start the new assignment interval of the variable.
+ */
+func (lrb *LRB) OnAssign(v sat_solver.CNFLiteral) {
+	lrb.assignedAt[v] = lrb.conflicts
+	lrb.participated[v] = 0
+}
+
+/**
+ * Update the score with the learning rate of the finished interval and put the variable back to the queue.
+ */
+func (lrb *LRB) OnUnassign(v sat_solver.CNFLiteral) {
+	if _, ok := lrb.score[v]; !ok {
+		return
+	}
+	if interval := lrb.conflicts - lrb.assignedAt[v]; interval > 0 {
+		rate := float64(lrb.participated[v]) / float64(interval)
+		lrb.score[v] = (1 - lrb.step) * lrb.score[v] + lrb.step * rate
+		lrb.queue.Update(v)
+	}
+	insertVariable(lrb.queue, v)
+}
@@ -0,0 +1,309 @@
+package cdcl_solver
+
+/**
+ * This file provides the focused and stable search modes of the CDCL solver and their restart policies.
+ *
+ * The two modes are good at different things:
+ *   - focused mode restarts often (when the recent learned clauses get worse than the average, measured by LBD)
+ *     and uses the VMTF heuristic which reacts quickly to the recent conflicts. It's good at proving UNSAT.
+ *   - stable mode restarts rarely (after the Luby sequence of conflicts), uses the configured decision heuristic
+ *     (Adaptive VSIDS by default) and the target phases: the values of the largest conflict-free assignment
+ *     found since the last restart. It's good at finding models, as the search stays close to the best assignment.
+ * By default the solver alternates between the modes: it starts in the focused mode and switches the mode
+ * after MODE_SWITCH_FIRST_INTERVAL conflicts. The interval grows after each stable mode phase.
+ * The mode is switched only on a restart. Both heuristics are updated all the time, so their scores are fresh
+ * when the mode changes, but only the heuristic of the current mode picks the decisions.
+ *
+ * The mode is selected with SATConfiguration.SearchMode ("alternate" (default), "focused" or "stable").
+ *
+ * For more details please see:
+ *   "CaDiCaL, Kissat, Paracooba, Plingeling and Treengeling Entering the SAT Competition 2020" by Armin Biere et al.
+ *   "Refining Restarts Strategies for SAT and UNSAT" by Gilles Audemard and Laurent Simon (CP 2012)
+ *   "Optimal Speedup of Las Vegas Algorithms" by Michael Luby, Alistair Sinclair and David Zuckerman (1993)
+ */
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+const (
+	// Number of conflicts before the first mode switch
+	MODE_SWITCH_FIRST_INTERVAL = 1000
+	// The interval between the mode switches is multiplied by this factor after each stable mode phase
+	MODE_SWITCH_INTERVAL_FACTOR = 2
+	// Decision heuristic used in the focused mode
+	FOCUSED_DECISION_HEURISTIC = "vmtf"
+	// Smoothing factors of the fast and slow moving averages of the LBD of the learned clauses
+	RESTART_FAST_LBD_ALPHA = 0.03
+	RESTART_SLOW_LBD_ALPHA = 1e-5
+	// Focused mode restarts when the fast average exceeds the slow one by this factor
+	RESTART_LBD_MARGIN = 1.1
+	// Minimal number of conflicts between two restarts in the focused mode
+	RESTART_MIN_CONFLICTS = 2
+	// Stable mode restarts after the next element of the Luby sequence multiplied by this number of conflicts
+	STABLE_RESTART_UNIT = 1024
+)
+
+type SearchMode int8
+
+const (
+	SEARCH_MODE_FOCUSED SearchMode = 0
+	SEARCH_MODE_STABLE  SearchMode = 1
+)
+
+/*
+ * Return human readable name of the mode
+ */
+func (mode SearchMode) String() string {
+	if mode == SEARCH_MODE_STABLE {
+		return "stable"
+	}
+	return "focused"
+}
+
+type SolverModeState struct {
+	// Current mode and are the modes switched at all?
+	mode                       SearchMode
+	enableModeSwitching        bool
+	// Heuristics of both modes, all distinct heuristics (they are notified about the search) and
+	// the heuristic of the current mode
+	focusedHeuristic           DecisionHeuristic
+	stableHeuristic            DecisionHeuristic
+	heuristics                 []DecisionHeuristic
+	heuristic                  DecisionHeuristic
+	// The mode is switched when the number of conflicts reaches this value
+	nextModeSwitchConflicts    int
+	modeSwitchInterval         int
+	// Moving averages of the LBD of the learned clauses
+	fastLBD                    float64
+	slowLBD                    float64
+	lbdCount                   int
+	// Decision levels seen by the LBD computation are marked with the current stamp
+	lbdStamp                   map[int]int
+	lbdStampCounter            int
+	// Number of conflicts at the last restart
+	lastRestartConflicts       int
+	// Position in the Luby sequence and the number of conflicts of the next restart in the stable mode
+	lubyIndex                  int
+	nextStableRestartConflicts int
+	// Values of the largest conflict-free assignment since the last restart (used in the stable mode)
+	targetPhase                map[sat_solver.CNFLiteral]bool
+	targetSize                 int
+	// Statistics
+	focusedConflicts           int
+	stableConflicts            int
+	focusedRestarts            int
+	stableRestarts             int
+	modeSwitches               int
+}
+
+/**
+ * Load the mode settings from the configuration and create the decision heuristics of the modes.
+ * The heuristics are initialized by modeStart() when the formula is loaded.
+ */
+func (solver *CDCLSolver) modeInit() error {
+	conf := solver.context.GetConfiguration()
+	switch conf.SearchMode {
+	case "", "alternate":
+		solver.mode = SEARCH_MODE_FOCUSED
+		solver.enableModeSwitching = true
+	case "focused":
+		solver.mode = SEARCH_MODE_FOCUSED
+	case "stable":
+		solver.mode = SEARCH_MODE_STABLE
+	default:
+		return fmt.Errorf("Search mode with name '%s' not found.", conf.SearchMode)
+	}
+
+	err, stableHeuristic := NewDecisionHeuristic(conf.DecisionHeuristic)
+	if err != nil {
+		return err
+	}
+	solver.stableHeuristic = stableHeuristic
+	solver.focusedHeuristic = stableHeuristic
+	if solver.enableModeSwitching || solver.mode == SEARCH_MODE_FOCUSED {
+		if stableHeuristic.GetName() != FOCUSED_DECISION_HEURISTIC {
+			err, solver.focusedHeuristic = NewDecisionHeuristic(FOCUSED_DECISION_HEURISTIC)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if solver.mode == SEARCH_MODE_FOCUSED {
+		solver.stableHeuristic = solver.focusedHeuristic
+	}
+
+	solver.modeSwitchInterval = MODE_SWITCH_FIRST_INTERVAL
+	solver.nextModeSwitchConflicts = MODE_SWITCH_FIRST_INTERVAL
+	solver.lbdStamp = map[int]int{}
+	solver.targetPhase = map[sat_solver.CNFLiteral]bool{}
+	solver.scheduleStableRestart()
+	return nil
+}
+
+/**
+ * Init the heuristics of the modes, so the search can start.
+ */
+func (solver *CDCLSolver) modeStart() {
+	solver.focusedHeuristic.Init(solver)
+	solver.heuristics = []DecisionHeuristic{ solver.focusedHeuristic }
+	if solver.stableHeuristic != solver.focusedHeuristic {
+		solver.stableHeuristic.Init(solver)
+		solver.heuristics = append(solver.heuristics, solver.stableHeuristic)
+	}
+	solver.heuristic = solver.getModeHeuristic()
+}
+
+/*
+ * Get the decision heuristic of the current mode.
+ */
+func (solver *CDCLSolver) getModeHeuristic() DecisionHeuristic {
+	if solver.mode == SEARCH_MODE_STABLE {
+		return solver.stableHeuristic
+	}
+	return solver.focusedHeuristic
+}
+
+/**
+ * Get the element of the Luby sequence (1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8, ...) at the given position
+ * (starting from 1).
+ */
+func luby(i int) int {
+	for {
+		k := 1
+		for (1 << uint(k)) - 1 < i {
+			k++
+		}
+		if (1 << uint(k)) - 1 == i {
+			return 1 << uint(k - 1)
+		}
+		i -= (1 << uint(k - 1)) - 1
+	}
+}
+
+/*
+ * Set the number of conflicts of the next restart in the stable mode.
+ */
+func (solver *CDCLSolver) scheduleStableRestart() {
+	solver.lubyIndex++
+	solver.nextStableRestartConflicts = solver.conflictsCount + luby(solver.lubyIndex) * STABLE_RESTART_UNIT
+}
+
+/**
+ * Update the restart and target phase statistics after the conflict was analysed and return the LBD
+ * of the learned clause.
+ * It's called before the solver goes back to a lower level, so the levels of the learned clause are still valid.
+ */
+func (solver *CDCLSolver) onConflictAnalysed(learnedClause sat_solver.CNFClause) int {
+	if solver.mode == SEARCH_MODE_STABLE {
+		solver.stableConflicts++
+		solver.updateTargetPhase()
+	} else {
+		solver.focusedConflicts++
+	}
+
+	// LBD is the number of distinct decision levels of the learned clause
+	solver.lbdStampCounter++
+	lbd := 0
+	for _, literal := range learnedClause {
+		level := solver.getDecisionLevelForVar(literal.Var())
+		if solver.lbdStamp[level] != solver.lbdStampCounter {
+			solver.lbdStamp[level] = solver.lbdStampCounter
+			lbd++
+		}
+	}
+	// The first values get larger weights, so the averages do not start at zero
+	solver.lbdCount++
+	fastAlpha := math.Max(RESTART_FAST_LBD_ALPHA, 1 / float64(solver.lbdCount))
+	slowAlpha := math.Max(RESTART_SLOW_LBD_ALPHA, 1 / float64(solver.lbdCount))
+	solver.fastLBD += fastAlpha * (float64(lbd) - solver.fastLBD)
+	solver.slowLBD += slowAlpha * (float64(lbd) - solver.slowLBD)
+	return lbd
+}
+
+/*
+ * Save the values of the trail below the current decision level as the target phases
+ * if it's larger than any conflict-free assignment seen since the last restart.
+ * All the literals before the current decision were propagated without a conflict.
+ */
+func (solver *CDCLSolver) updateTargetPhase() {
+	consistentSize := solver.decisionTrace[solver.getDecisionLevel() - 1]
+	if consistentSize <= solver.targetSize {
+		return
+	}
+	for _, literal := range solver.assignmentTrace[:consistentSize] {
+		solver.targetPhase[literal.Var()] = literal > 0
+	}
+	solver.targetSize = consistentSize
+}
+
+/**
+ * Check if the solver should restart now.
+ * It's called when the propagation is finished without a conflict.
+ */
+func (solver *CDCLSolver) shouldRestart() bool {
+	if solver.getDecisionLevel() <= len(solver.assumptions) {
+		return false
+	}
+	if solver.enableModeSwitching && solver.conflictsCount >= solver.nextModeSwitchConflicts {
+		return true
+	}
+	if solver.mode == SEARCH_MODE_STABLE {
+		return solver.conflictsCount >= solver.nextStableRestartConflicts
+	}
+	return solver.conflictsCount - solver.lastRestartConflicts >= RESTART_MIN_CONFLICTS &&
+		solver.fastLBD > RESTART_LBD_MARGIN * solver.slowLBD
+}
+
+/**
+ * Go back to the decision level 0 and switch the mode (or rephase from the local search) if it's the time for it.
+ */
+func (solver *CDCLSolver) restart() {
+	if solver.mode == SEARCH_MODE_STABLE {
+		solver.stableRestarts++
+	} else {
+		solver.focusedRestarts++
+	}
+	if solver.enableDebugLogging {
+		solver.context.Trace("restart", "Restart in the %s mode after %d conflicts.", solver.mode.String(), solver.conflictsCount)
+	}
+	solver.reverseToDecisionLevel(0)
+	for _, observer := range solver.observers {
+		observer.OnRestart()
+	}
+	solver.lastRestartConflicts = solver.conflictsCount
+	solver.targetSize = 0
+
+	if solver.enableModeSwitching && solver.conflictsCount >= solver.nextModeSwitchConflicts {
+		solver.switchMode()
+	}
+	if solver.mode == SEARCH_MODE_STABLE && solver.conflictsCount >= solver.nextStableRestartConflicts {
+		solver.scheduleStableRestart()
+	}
+	if solver.shouldRephase() {
+		solver.rephase()
+	}
+}
+
+/*
+ * Switch between the focused and stable mode.
+ */
+func (solver *CDCLSolver) switchMode() {
+	if solver.mode == SEARCH_MODE_FOCUSED {
+		solver.mode = SEARCH_MODE_STABLE
+		solver.scheduleStableRestart()
+	} else {
+		solver.mode = SEARCH_MODE_FOCUSED
+		solver.modeSwitchInterval *= MODE_SWITCH_INTERVAL_FACTOR
+	}
+	solver.nextModeSwitchConflicts = solver.conflictsCount + solver.modeSwitchInterval
+	solver.heuristic = solver.getModeHeuristic()
+	solver.modeSwitches++
+	if solver.enableDebugLogging {
+		solver.context.Trace("mode", "Switched to the %s mode (%s heuristic) after %d conflicts.",
+			solver.mode.String(), solver.heuristic.GetName(), solver.conflictsCount)
+	}
+}
@@ -1,21 +1,22 @@
 package cdcl_solver
 
+import "github.com/styczynski/go-sat-solver/sat_solver"
+
 /**
  * Get assignments for a variables when we found SAT and want to return satisfying assingment.
  */
 func (solver *CDCLSolver) getOutputVariableAssignments() map[string]bool {
-	result := make(map[string]bool)
+	model := make(map[sat_solver.CNFLiteral]bool)
 	for k, v := range solver.currentAssignment {
 		if !v.IsUndefined() {
 			if k < 0 {
 				k = -k
 			}
-			// If the variable was introduced later during optimizations we discard it
-			if solver.vars.IsFounderVariable(k) {
-				result[solver.vars.Reverse(k)] = TernaryToBool(v)
-			}
+			model[k] = TernaryToBool(v)
 		}
 	}
+	// Variables removed by inprocessing do not have any value yet
+	solver.extendModel(model)
 
-	return result
+	return solver.formula.FounderAssignment(model)
 }
\ No newline at end of file
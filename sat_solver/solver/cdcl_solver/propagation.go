@@ -62,6 +62,13 @@ func (solver *CDCLSolver) performUnitPropagation() sat_solver.CNFClause {
 		p := solver.assignmentTrace[solver.currentTraceCheckIndex]
 		solver.currentTraceCheckIndex++
 
+		if len(solver.cardinalities) > 0 {
+			conflict := solver.propagateCardinalities(p)
+			if conflict != nil {
+				return conflict
+			}
+		}
+
 		/**
 		 * We will write new watched literals into separate slice
 		 */
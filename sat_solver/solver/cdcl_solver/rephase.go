@@ -0,0 +1,158 @@
+package cdcl_solver
+
+/**
+ * This file provides rephasing of the CDCL solver from the stochastic local search.
+ *
+ * A short local search burst (see sls_solver package) is run on the current clause database and the best
+ * assignment it found (the one with the fewest false clauses) becomes the saved phase of the variables.
+ * When the decision heuristic picks a variable, it's assigned the saved phase instead of always being set to true.
+ * On satisfiable instances the local search often gets very close to a model, so the CDCL search only has to
+ * repair a few conflicts.
+ *
+ * The burst is run before the first search and then on the restarts (see modes.go). The number of the restarts
+ * between the bursts grows arithmetically (SLS_REPHASE_RESTARTS, 2 * SLS_REPHASE_RESTARTS, ...), so the local search
+ * doesn't dominate the long runs. The saved phases are then updated by the phase saving (when the variable
+ * is unassigned, its value becomes the saved phase) and the target phases of the stable mode (see modes.go)
+ * are forgotten, so the search starts from the local search assignment.
+ * Level 0 assignments are given to the local search as unit clauses, so the saved phases agree with them.
+ * XOR and cardinality constraints are not seen by the local search, they only make the phases less accurate.
+ *
+ * The rephasing is enabled with SATConfiguration.EnableSLSRephasing. The SLS algorithm, noise and seed
+ * are taken from the same configuration as for the sls solver.
+ *
+ * For more details please see:
+ *   "CaDiCaL, Kissat, Paracooba, Plingeling and Treengeling Entering the SAT Competition 2020" by Armin Biere et al.
+ *   "Improving Exploration in CDCL with Local Search" by Shaowei Cai and Xindi Zhang (SAT 2021)
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver/sls_solver"
+)
+
+const (
+	// Number of the flips of a single local search burst per clause of the formula
+	SLS_REPHASE_FLIPS_PER_CLAUSE = 10
+	// Maximum number of the flips of a single local search burst
+	SLS_REPHASE_MAX_FLIPS = 200000
+	// Local search is run again after this number of the restarts times the number of the finished bursts
+	SLS_REPHASE_RESTARTS = 5
+)
+
+type SolverRephaseState struct {
+	// Is the rephasing from the local search enabled?
+	enableRephasing     bool
+	// Number of the finished local search bursts
+	rephaseCount        int
+	// Number of the restarts done before the last burst
+	lastRephaseRestarts int
+	// Value used for the decision on the variable, variables without the saved phase are set to true
+	savedPhase          map[sat_solver.CNFLiteral]bool
+}
+
+/**
+ * Init the rephasing state.
+ */
+func (solver *CDCLSolver) rephaseInit() {
+	solver.enableRephasing = solver.context.GetConfiguration().EnableSLSRephasing
+	solver.savedPhase = map[sat_solver.CNFLiteral]bool{}
+}
+
+/**
+ * Check if the local search should be run now.
+ * It's called at the decision level 0, before the first search and after each restart.
+ */
+func (solver *CDCLSolver) shouldRephase() bool {
+	if !solver.enableRephasing {
+		return false
+	}
+	if solver.rephaseCount == 0 {
+		return true
+	}
+	return solver.focusedRestarts + solver.stableRestarts >= solver.lastRephaseRestarts + SLS_REPHASE_RESTARTS * solver.rephaseCount
+}
+
+/**
+ * Run the local search burst and save the best assignment it found as the phases of the variables.
+ */
+func (solver *CDCLSolver) rephase() {
+	solver.rephaseCount++
+	solver.lastRephaseRestarts = solver.focusedRestarts + solver.stableRestarts
+	err, algorithm, noise := sls_solver.GetAlgorithmParameters(solver.context.GetConfiguration())
+	if err != nil {
+		if solver.enableDebugLogging {
+			solver.context.Trace("rephase", "Rephasing skipped: %s", err.Error())
+		}
+		return
+	}
+
+	clauses := make([]sat_solver.CNFClause, 0, len(solver.clauses) + len(solver.assignmentTrace))
+	maxVar := sat_solver.CNFLiteral(1)
+	for _, clause := range solver.clauses {
+		for _, literal := range clause {
+			if literal.Var() > maxVar {
+				maxVar = literal.Var()
+			}
+		}
+		clauses = append(clauses, clause)
+	}
+	for _, literal := range solver.assignmentTrace {
+		if literal.Var() > maxVar {
+			maxVar = literal.Var()
+		}
+		clauses = append(clauses, sat_solver.CNFClause{ literal })
+	}
+
+	// Start from the saved phases, so the consecutive bursts continue the previous ones
+	search := sls_solver.NewLocalSearch(clauses, maxVar, solver.context.GetConfiguration().SLSSeed + int64(solver.rephaseCount))
+	initial := make([]bool, maxVar + 1)
+	for v := range initial {
+		initial[v] = solver.decisionPhase(sat_solver.CNFLiteral(v))
+	}
+	for _, literal := range solver.assignmentTrace {
+		initial[literal.Var()] = literal > 0
+	}
+	search.SetAssignment(initial)
+
+	maxFlips := int64(SLS_REPHASE_FLIPS_PER_CLAUSE * len(clauses))
+	if maxFlips > SLS_REPHASE_MAX_FLIPS {
+		maxFlips = SLS_REPHASE_MAX_FLIPS
+	}
+	search.Search(algorithm, noise, maxFlips)
+	best, falseCount := search.BestAssignment()
+	for v := 2; v < len(best); v++ {
+		solver.savedPhase[sat_solver.CNFLiteral(v)] = best[v]
+	}
+	solver.targetPhase = map[sat_solver.CNFLiteral]bool{}
+	solver.targetSize = 0
+	if solver.enableDebugLogging {
+		solver.context.Trace("rephase", "Local search burst %d left %d of %d clauses false after %d flips.",
+			solver.rephaseCount, falseCount, len(clauses), search.Flips())
+	}
+}
+
+/**
+ * Get the value that should be used for the decision on the variable.
+ * The stable mode prefers the target phase over the saved one.
+ */
+func (solver *CDCLSolver) decisionPhase(v sat_solver.CNFLiteral) bool {
+	if solver.mode == SEARCH_MODE_STABLE {
+		if phase, ok := solver.targetPhase[v]; ok {
+			return phase
+		}
+	}
+	if phase, ok := solver.savedPhase[v]; ok {
+		return phase
+	}
+	return true
+}
+
+/**
+ * Get the literal that assigns the saved phase to the variable.
+ */
+func (solver *CDCLSolver) decisionLiteral(v sat_solver.CNFLiteral) sat_solver.CNFLiteral {
+	if solver.decisionPhase(v) {
+		return v
+	}
+	return -v
+}
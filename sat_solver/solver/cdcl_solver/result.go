@@ -10,6 +10,8 @@ type SatResult struct {
 	resultType SatResultType
 	// Optionally a variables' assignment leading to SAT
 	assgn map[string]bool
+	// Counters of the search
+	stats map[string]int64
 }
 
 // Type of the SAT result
@@ -91,6 +93,13 @@ func (result SatResult) GetSatisfyingAssignment() map[string]bool {
 	return result.assgn
 }
 
+/**
+ * Get the counters of the search that found the result.
+ */
+func (result SatResult) GetStatistics() map[string]int64 {
+	return result.stats
+}
+
 /**
  * Structure to store a metadata about the literals values:
  *   - what clause and on what decision level is a cause of the current assignment?
@@ -98,17 +107,22 @@ func (result SatResult) GetSatisfyingAssignment() map[string]bool {
 type VariableAssignmentInformation struct {
 	// What clause caused the variable assignment?
 	reasonClause  sat_solver.CNFClause
+	// Cardinality constraint that caused the assignment (the reasonClause is built from it when it's needed)
+	reasonConstraint *cardinalityConstraint
 	// Decision level when this variable was assigned
 	decisionLevel int
+	// Position of the assignment on the assignmentTrace
+	traceIndex    int
 }
 
 /**
  * Create new VariableAssignmentInformation object
  */
-func NewVariableInformation(solver *CDCLSolver, causeOfAssignment sat_solver.CNFClause) VariableAssignmentInformation {
+func NewVariableInformation(solver *CDCLSolver, causeOfAssignment sat_solver.CNFClause, decisionLevel int) VariableAssignmentInformation {
 	return VariableAssignmentInformation{
 		reasonClause:  causeOfAssignment,
-		decisionLevel: solver.getDecisionLevel(),
+		decisionLevel: decisionLevel,
+		traceIndex:    len(solver.assignmentTrace),
 	}
 }
 
@@ -117,8 +131,26 @@ func NewVariableInformation(solver *CDCLSolver, causeOfAssignment sat_solver.CNF
  */
 func (solver *CDCLSolver) foundResult(result SatResult) SatResult {
 	if solver.enableDebugLogging {
+		solver.context.Trace("stats", "Finished after %d conflicts (%d focused, %d stable), %d restarts (%d focused, %d stable), %d mode switches and %d chronological backtracks.",
+			solver.conflictsCount, solver.focusedConflicts, solver.stableConflicts, solver.focusedRestarts + solver.stableRestarts,
+			solver.focusedRestarts, solver.stableRestarts, solver.modeSwitches, solver.chronoBacktracks)
 		solver.context.Trace("result", "Found result %s.", result.String())
 	}
+	result.stats = map[string]int64{
+		"conflicts":         int64(solver.conflictsCount),
+		"focused_conflicts": int64(solver.focusedConflicts),
+		"stable_conflicts":  int64(solver.stableConflicts),
+		"restarts":          int64(solver.focusedRestarts + solver.stableRestarts),
+		"focused_restarts":  int64(solver.focusedRestarts),
+		"stable_restarts":   int64(solver.stableRestarts),
+		"mode_switches":     int64(solver.modeSwitches),
+		"chrono_backtracks": int64(solver.chronoBacktracks),
+	}
+	if result.resultType == SAT_RESULT_SAT {
+		for _, observer := range solver.observers {
+			observer.OnModel(result.assgn)
+		}
+	}
 
 	solver.result = result
 	return solver.result
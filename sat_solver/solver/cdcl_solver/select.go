@@ -11,28 +11,28 @@ import (
  *
  * This function returns a variable that will be selected for another decision.
  * This is crucial for CDCL and can speed up or slow down its search times significantly.
- * Current implementation uses AVSIDS heuristics and falls back to naive selection.
+ * Current implementation uses the decision heuristic of the current mode (see heuristic.go and modes.go)
+ * and falls back to naive selection.
+ * The variable is assigned its saved or target phase (see rephase.go).
  */
 func (solver *CDCLSolver) findNextLiteralForDecision() (sat_solver.CNFLiteral, bool) {
-	l := solver.vars.GetAllVariables()
-
-	// Default algorithm: Use AVSIDS suggestions to get variable for decision
-	avsidsSuggestion, ok := solver.avsidsSuggestSelect()
+	// Default algorithm: Use the heuristic suggestions to get variable for decision
+	suggestion, ok := solver.heuristic.PickNext()
 	if ok {
-		return avsidsSuggestion, true
+		return solver.decisionLiteral(suggestion), true
 	}
 
 	// Fallback algorithm: Choose first variable that we can assign
 	// Remember that go maps do not have any guarantee on iteration order, so this should give same reliable order, but
 	// completely different ones if you run the solver twice.
 	// If you want to debug the solver it's recommended to place here a sort function to have a deterministic, known
-	// order of suggestions and it's good idea to disable AVSIDS when you are debugging.
-	for _, raw := range l {
+	// order of suggestions and it's good idea to disable the heuristic when you are debugging.
+	for _, raw := range solver.vars.GetAllVariables() {
 		if raw < 0 {
 			raw = -raw
 		}
-		if _, ok := solver.currentAssignment[raw]; !ok {
-			return raw, true
+		if solver.isDecisionCandidate(raw) {
+			return solver.decisionLiteral(raw), true
 		}
 	}
 
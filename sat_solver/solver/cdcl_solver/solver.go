@@ -34,14 +34,28 @@ func init() {
  * Complete solver state
  */
 type CDCLSolver struct {
-	// AVSIDS state
-	AVSIDS
 	// Decision trace
 	CDCLSolverDecisionTrace
 	// TWL state
 	SolverTWLState
 	// State information used for learning
 	SolverLearnState
+	// Inprocessing scheduler state
+	SolverInprocessingState
+	// XOR constraints state
+	SolverGaussState
+	// Cardinality constraints state
+	SolverCardinalityState
+	// Assumptions used by the incremental solving
+	SolverAssumptionsState
+	// Phases saved from the local search
+	SolverRephaseState
+	// Chronological backtracking state
+	SolverChronoState
+	// Search modes, restarts and decision heuristics
+	SolverModeState
+	// Observers of the search
+	SolverHooksState
 	// The process ID is used for SATContext and mostly debugging
 	processID              uint
 	// Enable debug output
@@ -52,6 +66,7 @@ type CDCLSolver struct {
 	// Please note that the formula may change after new clauses are learnt,
 	// but variables mapping should be fine.
 	context                *sat_solver.SATContext
+	formula                *sat_solver.SATFormula
 	clauses                []sat_solver.CNFClause
 	vars                   *sat_solver.SATVariableMapping
     // This index is used as qhead in Minisat.
@@ -83,69 +98,240 @@ func NewCDCLSolver() *CDCLSolver {
  * Solve sat formula
  */
 func (solver *CDCLSolver) Solve(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	err := solver.Load(formula, context)
+	if err != nil {
+		return err, SatResultUndefined()
+	}
+	return nil, solver.search()
+}
+
+/**
+ * Prepare the solver state for the formula without starting the search.
+ */
+func (solver *CDCLSolver) Load(formula *sat_solver.SATFormula, context *sat_solver.SATContext) error {
 	solver.context = context
 	solver.enableDebugLogging = context.IsSolverTracingEnabled()
-	if f, ok := formula.Formula().(*sat_solver.CNFFormula); ok {
-		/**
-		 * Prepare solver state
-		 */
-		solver.vars = formula.Variables()
-		for _, newClause := range f.Variables {
-			if len(newClause) == 0 {
-				solver.result = SatResultUnsat()
-				continue
-			} else if len(newClause) == 1 {
-				solver.performLiteralAssertion(newClause[0], nil)
-				solver.performUnitPropagation()
-				continue
-			} else {
-				solver.clauses = append(solver.clauses, newClause)
-				solver.watchClause(newClause)
+	f, ok := formula.Formula().(*sat_solver.CNFFormula)
+	if !ok {
+		return fmt.Errorf("CDCL Solver supports only CNF formulas.")
+	}
+	solver.formula = formula
+	solver.vars = formula.Variables()
+	solver.inprocessingInit()
+	solver.rephaseInit()
+	solver.chronoInit()
+	err := solver.modeInit()
+	if err != nil {
+		return err
+	}
+	solver.isUnsat = !solver.loadConstraints(f)
+	if !solver.isUnsat {
+		solver.gaussInit()
+		solver.modeStart()
+	}
+	return nil
+}
+
+/**
+ * Add all the clauses and constraints of the formula at decision level 0.
+ * Returns false if the formula was found to be UNSAT.
+ */
+func (solver *CDCLSolver) loadConstraints(f *sat_solver.CNFFormula) bool {
+	for _, newClause := range f.Variables {
+		if !solver.addClause(newClause) {
+			return false
+		}
+	}
+	for _, xor := range f.Xors {
+		if !solver.addXorConstraint(xor) {
+			return false
+		}
+	}
+	for _, c := range f.Cardinalities {
+		if !solver.addCardinalityConstraint(c) {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * Run the CDCL search on the loaded formula.
+ * The assumptions (if there are any) are decided first, one per decision level.
+ */
+func (solver *CDCLSolver) search() SatResult {
+	if solver.isUnsat {
+		return solver.foundResult(SatResultUnsat())
+	}
+	if solver.enableDebugLogging {
+		solver.context.Trace("start", "Started solver.")
+	}
+	if solver.shouldRephase() {
+		solver.rephase()
+	}
+	solver.isInterrupted = false
+
+	for {
+		if solver.isInterrupted {
+			if solver.enableDebugLogging {
+				solver.context.Trace("interrupt", "Search was interrupted.")
 			}
+			return solver.foundResult(SatResultUndefined())
 		}
-		solver.avsidsInit()
 
-		if solver.enableDebugLogging {
-			solver.context.Trace("start", "Started solver.")
+		// Unit propagation
+		conflictingClause := solver.performUnitPropagation()
+		if conflictingClause == nil {
+			// Propagate XOR constraints when the clauses are done
+			xorPropagated := false
+			conflictingClause, xorPropagated = solver.performGaussPropagation()
+			if conflictingClause == nil && xorPropagated {
+				continue
+			}
 		}
+		if conflictingClause == nil {
+			// Simplify the clause database if the scheduler wants to
+			if solver.shouldInprocess() {
+				if !solver.inprocess() {
+					solver.isUnsat = true
+					return solver.foundResult(SatResultUnsat())
+				}
+				continue
+			} else if solver.hasNewLevelZeroUnits() {
+				if !solver.simplifyNewUnits() {
+					solver.isUnsat = true
+					return solver.foundResult(SatResultUnsat())
+				}
+			}
 
-		for {
-			// Unit propagation
-			conflictingClause := solver.performUnitPropagation()
-			if conflictingClause == nil {
-				// Make a new decision
-				lit, hasAnyLiterals := solver.findNextLiteralForDecision()
+			// Restart (and maybe switch the mode) if the schedule wants to
+			if solver.shouldRestart() {
+				solver.restart()
+				continue
+			}
 
-				if !hasAnyLiterals || lit == sat_solver.CNF_UNDEFINED {
-					return nil, solver.foundResult(SatResultSat(solver))
+			// Decide the assumptions before anything else
+			if solver.getDecisionLevel() < len(solver.assumptions) {
+				if !solver.decideNextAssumption() {
+					return solver.foundResult(SatResultUnsat())
 				}
+				continue
+			}
 
-				solver.newDecision(lit)
-			} else {
-				// We have conflict
-				if solver.enableDebugLogging {
-					solver.context.Trace("conflict", "Conflicting clause detected on unit propagation. Decision trace: %s.", solver.getDecisionTraceString())
+			// Make a new decision
+			lit, hasAnyLiterals := solver.findNextLiteralForDecision()
+
+			if !hasAnyLiterals || lit == sat_solver.CNF_UNDEFINED {
+				return solver.foundResult(SatResultSat(solver))
+			}
+
+			solver.newDecision(lit)
+		} else {
+			// We have conflict
+			if solver.enableDebugLogging {
+				solver.context.Trace("conflict", "Conflicting clause detected on unit propagation. Decision trace: %s.", solver.getDecisionTraceString())
+			}
+			for _, observer := range solver.observers {
+				observer.OnConflict(conflictingClause)
+			}
+			if solver.enableChronoBacktrack {
+				// The conflict may be on a lower level than the current one
+				conflictLevel, isMissedImplication := solver.prepareConflictingClause(conflictingClause)
+				if conflictLevel == 0 {
+					solver.isUnsat = true
+					return solver.foundResult(SatResultUnsat())
 				}
-				if solver.getDecisionLevel() == 0 {
-					return nil, solver.foundResult(SatResultUnsat())
+				if isMissedImplication {
+					if solver.enableDebugLogging {
+						solver.context.Trace("chrono", "Conflicting clause implies %s on a lower level.", conflictingClause[0].String(solver.vars))
+					}
+					solver.reverseToDecisionLevel(conflictLevel - 1)
+					for _, observer := range solver.observers {
+						observer.OnBacktrack(conflictLevel - 1)
+					}
+					solver.performLiteralAssertion(conflictingClause[0], conflictingClause)
+					if solver.checkTrailAfterConflicts {
+						solver.checkTrailInvariants()
+					}
+					continue
 				}
+				solver.reverseToDecisionLevel(conflictLevel)
+			}
+			if solver.getDecisionLevel() == 0 {
+				solver.isUnsat = true
+				return solver.foundResult(SatResultUnsat())
+			}
+			solver.conflictsCount++
 
-				// Remeber a new clause
-				newLevel := solver.learnClause(conflictingClause)
-				solver.avsidsClauseLearnt(&conflictingClause)
-
-				// Go backwards
-				solver.reverseToDecisionLevel(newLevel)
-				if len(solver.currentLearnedClause) == 1 {
-					solver.performLiteralAssertion(solver.currentLearnedClause[0], nil)
-				} else {
-					learnedClause :=solver.currentLearnedClause.Copy()
-					solver.clauses = append(solver.clauses, learnedClause)
-					solver.watchClause(learnedClause)
-					solver.performLiteralAssertion(learnedClause[0], learnedClause)
-				}
+			// Remeber a new clause
+			newLevel := solver.learnClause(conflictingClause)
+			for _, heuristic := range solver.heuristics {
+				heuristic.Decay(conflictingClause)
+			}
+			lbd := solver.onConflictAnalysed(solver.currentLearnedClause)
+			for _, observer := range solver.observers {
+				observer.OnLearn(solver.currentLearnedClause, lbd)
 			}
+
+			// Go backwards
+			backtrackLevel := solver.getBacktrackLevel(newLevel)
+			solver.reverseToDecisionLevel(backtrackLevel)
+			for _, observer := range solver.observers {
+				observer.OnBacktrack(backtrackLevel)
+			}
+			if len(solver.currentLearnedClause) == 1 {
+				// Units always belong to the level 0
+				solver.performLiteralAssertionAtLevel(solver.currentLearnedClause[0], nil, 0)
+			} else {
+				learnedClause :=solver.currentLearnedClause.Copy()
+				solver.learnedClauses = append(solver.learnedClauses, learnedClause)
+				solver.watchClause(learnedClause)
+				solver.performLiteralAssertion(learnedClause[0], learnedClause)
+			}
+			if solver.checkTrailAfterConflicts {
+				solver.checkTrailInvariants()
+			}
+		}
+	}
+}
+
+/**
+ * Add an input clause to the solver at decision level 0.
+ * The clause is simplified using the current assignment: duplicated and false literals are removed,
+ * tautologies and satisfied clauses are skipped and unit clauses are asserted.
+ * Returns false if the clause is empty after simplification (so the formula is UNSAT).
+ */
+func (solver *CDCLSolver) addClause(clause sat_solver.CNFClause) bool {
+	newClause := make(sat_solver.CNFClause, 0, len(clause))
+	for _, literal := range clause {
+		value := solver.currentLiteralValue(literal)
+		if value.IsTrue() {
+			return true
+		} else if value.IsFalse() {
+			continue
+		}
+		isDuplicate := false
+		for _, existing := range newClause {
+			if existing == literal {
+				isDuplicate = true
+				break
+			} else if existing == -literal {
+				// Tautology is always satisfied
+				return true
+			}
+		}
+		if !isDuplicate {
+			newClause = append(newClause, literal)
 		}
 	}
-	return fmt.Errorf("CDCL Solver supports only CNF formulas."), SatResultUndefined()
+
+	if len(newClause) == 0 {
+		return false
+	} else if len(newClause) == 1 {
+		solver.performLiteralAssertion(newClause[0], nil)
+		return true
+	}
+	solver.clauses = append(solver.clauses, newClause)
+	solver.watchClause(newClause)
+	return true
 }
\ No newline at end of file
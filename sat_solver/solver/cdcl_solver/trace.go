@@ -55,6 +55,9 @@ func (solver *CDCLSolver) newDecision(literal sat_solver.CNFLiteral) {
 	}
 	solver.decisionTrace = append(solver.decisionTrace, len(solver.assignmentTrace))
 	solver.performLiteralAssertion(literal, nil)
+	for _, observer := range solver.observers {
+		observer.OnDecision(literal, solver.getDecisionLevel())
+	}
 }
 
 /**
@@ -65,13 +68,34 @@ func (solver *CDCLSolver) newDecision(literal sat_solver.CNFLiteral) {
  *
  */
 func (solver *CDCLSolver) performLiteralAssertion(literal sat_solver.CNFLiteral, from sat_solver.CNFClause) {
+	decisionLevel := solver.getDecisionLevel()
+	if solver.enableChronoBacktrack && from != nil {
+		// The trail may be out of order, so the implied literal belongs to the highest level of its reason
+		decisionLevel = solver.getReasonLevel(from)
+	}
+	solver.performLiteralAssertionAtLevel(literal, from, decisionLevel)
+}
+
+/**
+ * Assert the literal on the given decision level.
+ * The level can be lower than the current one only if the chronological backtracking is enabled.
+ */
+func (solver *CDCLSolver) performLiteralAssertionAtLevel(literal sat_solver.CNFLiteral, from sat_solver.CNFClause, decisionLevel int) {
 	v := literal
 	if v < 0 {
 		v = -v
 	}
-	solver.varsInfo[v] = NewVariableInformation(solver, from)
+	solver.varsInfo[v] = NewVariableInformation(solver, from, decisionLevel)
 	solver.assignmentTrace = append(solver.assignmentTrace, literal)
 	solver.currentAssignment[v] = BoolToTernary(literal >= 0)
+	for _, heuristic := range solver.heuristics {
+		heuristic.OnAssign(v)
+	}
+	if from != nil {
+		for _, observer := range solver.observers {
+			observer.OnPropagate(literal, from)
+		}
+	}
 }
 
 /**
@@ -101,23 +125,46 @@ func (solver *CDCLSolver) reverseToDecisionLevel(decisionLevel int) {
 	}
 
 	lastDecisionIndex := solver.decisionTrace[decisionLevel]
+	solver.unassignCardinalities(lastDecisionIndex)
 
 	// Unassign anything in the assignmentTrace in higher levels
+	// When the trail is out of order, the literals of the lower levels are kept (in the same order)
+	keptLiterals := []sat_solver.CNFLiteral{}
 	for i := len(solver.assignmentTrace) - 1; i >= lastDecisionIndex; i-- {
 		trailVar := solver.assignmentTrace[i]
 		if trailVar < 0 {
 			trailVar = -trailVar
 		}
+		if solver.enableChronoBacktrack && solver.getDecisionLevelForVar(trailVar) <= decisionLevel {
+			keptLiterals = append(keptLiterals, solver.assignmentTrace[i])
+			continue
+		}
 		delete(solver.currentAssignment, trailVar)
+		// Phase saving: the next decision on the variable uses the same value
+		solver.savedPhase[trailVar] = solver.assignmentTrace[i] > 0
+		for _, heuristic := range solver.heuristics {
+			heuristic.OnUnassign(trailVar)
+		}
 	}
 
 	// Remove values from the trace
 	solver.decisionTrace = solver.decisionTrace[:decisionLevel]
 	solver.assignmentTrace = solver.assignmentTrace[:lastDecisionIndex]
 
+	// Put the kept literals back, they are propagated again
+	for i := len(keptLiterals) - 1; i >= 0; i-- {
+		info := solver.varsInfo[keptLiterals[i].Var()]
+		info.traceIndex = len(solver.assignmentTrace)
+		solver.varsInfo[keptLiterals[i].Var()] = info
+		solver.assignmentTrace = append(solver.assignmentTrace, keptLiterals[i])
+	}
+
 	// Update the index for a checked decision levels
 	// This means that we notify propagation algorithm at what level it should start
 	solver.currentTraceCheckIndex = lastDecisionIndex
+	if solver.gaussCheckedTraceIndex > lastDecisionIndex {
+		solver.gaussCheckedTraceIndex = lastDecisionIndex
+	}
 }
 
 /**
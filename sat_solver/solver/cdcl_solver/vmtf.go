@@ -0,0 +1,157 @@
+package cdcl_solver
+
+/**
+ * This file provides the VMTF (variable move-to-front) decision heuristic.
+ *
+ * All the variables are kept in a queue. After each conflict the variables seen during the analysis
+ * are moved to the end of the queue (in the order they had in the queue before) and the decision
+ * picks the last unassigned variable. Each variable gets a timestamp when it's moved, so the search pointer
+ * can be kept: all the variables after it are assigned. The pointer only moves back when picking
+ * and forward when a variable with a newer timestamp is unassigned, so picking is cheap on average.
+ *
+ * For more details please see:
+ *   "Evaluating CDCL Variable Scoring Schemes" by Armin Biere and Andreas Fröhlich (SAT 2015)
+ */
+
+import (
+	"sort"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+type VMTF struct {
+	// Solver that uses the heuristic
+	solver       *CDCLSolver
+	// Doubly linked queue of the variables, CNF_UNDEFINED marks the ends
+	previous     map[sat_solver.CNFLiteral]sat_solver.CNFLiteral
+	next         map[sat_solver.CNFLiteral]sat_solver.CNFLiteral
+	first        sat_solver.CNFLiteral
+	last         sat_solver.CNFLiteral
+	// Time when the variable was moved to the end of the queue
+	stamp        map[sat_solver.CNFLiteral]int64
+	stampCounter int64
+	// All the variables after this one are assigned
+	search       sat_solver.CNFLiteral
+	// Variables seen during the current conflict analysis
+	bumped       []sat_solver.CNFLiteral
+}
+
+func (vmtf *VMTF) GetName() string {
+	return "vmtf"
+}
+
+/**
+ * Put all the variables into the queue.
+ */
+func (vmtf *VMTF) Init(solver *CDCLSolver) {
+	vmtf.solver = solver
+	vmtf.previous = map[sat_solver.CNFLiteral]sat_solver.CNFLiteral{}
+	vmtf.next = map[sat_solver.CNFLiteral]sat_solver.CNFLiteral{}
+	vmtf.stamp = map[sat_solver.CNFLiteral]int64{}
+	vmtf.first, vmtf.last = sat_solver.CNF_UNDEFINED, sat_solver.CNF_UNDEFINED
+	vmtf.stampCounter = 0
+	vmtf.bumped = []sat_solver.CNFLiteral{}
+	for _, v := range solver.vars.GetAllVariables() {
+		vmtf.AddVariable(v.Var())
+	}
+}
+
+/*
+ * Append the variable to the end of the queue.
+ */
+func (vmtf *VMTF) enqueue(v sat_solver.CNFLiteral) {
+	vmtf.previous[v] = vmtf.last
+	vmtf.next[v] = sat_solver.CNF_UNDEFINED
+	if vmtf.last == sat_solver.CNF_UNDEFINED {
+		vmtf.first = v
+	} else {
+		vmtf.next[vmtf.last] = v
+	}
+	vmtf.last = v
+	vmtf.stampCounter++
+	vmtf.stamp[v] = vmtf.stampCounter
+}
+
+/*
+ * Remove the variable from the queue.
+ */
+func (vmtf *VMTF) dequeue(v sat_solver.CNFLiteral) {
+	previous, next := vmtf.previous[v], vmtf.next[v]
+	if previous == sat_solver.CNF_UNDEFINED {
+		vmtf.first = next
+	} else {
+		vmtf.next[previous] = next
+	}
+	if next == sat_solver.CNF_UNDEFINED {
+		vmtf.last = previous
+	} else {
+		vmtf.previous[next] = previous
+	}
+}
+
+/**
+ * Add new variable at the end of the queue.
+ */
+func (vmtf *VMTF) AddVariable(v sat_solver.CNFLiteral) {
+	if _, ok := vmtf.stamp[v]; ok {
+		return
+	}
+	vmtf.enqueue(v)
+	vmtf.search = v
+}
+
+/**
+ * Remember the variable, it's moved when the analysis is finished.
+ */
+func (vmtf *VMTF) BumpVariable(v sat_solver.CNFLiteral) {
+	if _, ok := vmtf.stamp[v.Var()]; ok {
+		vmtf.bumped = append(vmtf.bumped, v.Var())
+	}
+}
+
+/**
+ * Move the variables seen during the analysis to the end of the queue keeping their relative order.
+ */
+func (vmtf *VMTF) Decay(clause sat_solver.CNFClause) {
+	sort.Slice(vmtf.bumped, func(i, j int) bool {
+		return vmtf.stamp[vmtf.bumped[i]] < vmtf.stamp[vmtf.bumped[j]]
+	})
+	for _, v := range vmtf.bumped {
+		if v != vmtf.last {
+			vmtf.dequeue(v)
+			vmtf.enqueue(v)
+		} else {
+			vmtf.stampCounter++
+			vmtf.stamp[v] = vmtf.stampCounter
+		}
+		if vmtf.solver.isDecisionCandidate(v) {
+			vmtf.search = v
+		}
+	}
+	vmtf.bumped = vmtf.bumped[:0]
+}
+
+/**
+ * Return the last unassigned variable of the queue.
+ */
+func (vmtf *VMTF) PickNext() (sat_solver.CNFLiteral, bool) {
+	for v := vmtf.search; v != sat_solver.CNF_UNDEFINED; v = vmtf.previous[v] {
+		if vmtf.solver.isDecisionCandidate(v) {
+			vmtf.search = v
+			return v, true
+		}
+	}
+	vmtf.search = vmtf.first
+	return sat_solver.CNF_UNDEFINED, false
+}
+
+func (vmtf *VMTF) OnAssign(v sat_solver.CNFLiteral) {}
+
+/**
+ * Move the search pointer to the unassigned variable if it's after the pointer.
+ */
+func (vmtf *VMTF) OnUnassign(v sat_solver.CNFLiteral) {
+	if vmtf.search == sat_solver.CNF_UNDEFINED || vmtf.stamp[v] > vmtf.stamp[vmtf.search] {
+		vmtf.search = v
+	}
+}
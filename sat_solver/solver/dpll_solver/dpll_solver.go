@@ -0,0 +1,490 @@
+package dpll_solver
+
+/**
+ * This file provides the classic DPLL solver.
+ *
+ * The solver assigns the variables one by one and after each assignment it performs:
+ *   - unit propagation: if all the literals of a clause except one are false, then the last one must be true
+ *   - pure literal elimination: if a variable occurs only positively (or only negatively) in the clauses
+ *       that are not satisfied yet, then it can be set to make all those occurrences true
+ * If some clause becomes false, the solver goes back to the last decision that was not flipped yet
+ * and tries the opposite value (chronological backtracking). There is no clause learning and no restarts,
+ * so the search explores the decision tree exactly as described in the textbooks.
+ * The next decision is the literal with the most occurrences in the clauses that are not satisfied yet (DLIS).
+ *
+ * Each clause keeps the number of its true and false literals, so the unit and pure literals are found
+ * without scanning the whole formula.
+ *
+ * The solver emits the same trace events as the CDCL solver ("start", "decide", "conflict", "reverse"
+ * and "result"), so both searches can be compared step by step. Pure literals are traced as "pure".
+ * XOR and cardinality constraints are encoded as clauses before the search starts.
+ *
+ * For more details please see:
+ *   "A Machine Program for Theorem-Proving" by Martin Davis, George Logemann and Donald Loveland (1962)
+ */
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/*
+ * DPLL solver factory
+ */
+type DPLLSolverFactory struct {}
+
+func (dpllsf DPLLSolverFactory) CanSolveFormula(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
+	_, ok := formula.Formula().(*sat_solver.CNFFormula)
+	return ok
+}
+
+func (dpllsf DPLLSolverFactory) CreateSolver(formula *sat_solver.SATFormula, context *sat_solver.SATContext) solver.Solver {
+	return NewDPLLSolver()
+}
+
+func (dpllsf DPLLSolverFactory) GetName() string {
+	return "dpll"
+}
+
+// Register solver factory
+func init() {
+	solver.RegisterSolverFactory(DPLLSolverFactory{})
+}
+
+/*
+ * Decision made by the search
+ */
+type dpllDecision struct {
+	// Decided literal
+	literal    sat_solver.CNFLiteral
+	// Position of the decided literal on the assignmentTrace
+	traceIndex int
+	// Was the opposite value already tried?
+	isFlipped  bool
+}
+
+/**
+ * DPLL solver state
+ */
+type DPLLSolver struct {
+	// Enable debug output
+	enableDebugLogging bool
+	// Context and formula that we work on
+	context            *sat_solver.SATContext
+	formula            *sat_solver.SATFormula
+	vars               *sat_solver.SATVariableMapping
+	// Simplified clauses and the largest variable that occurs in them
+	clauses            []sat_solver.CNFClause
+	maxVar             sat_solver.CNFLiteral
+	// Clauses that contain the literal (indexed by literalIndex)
+	occurrences        [][]int
+	// Number of the clauses that contain the literal and are not satisfied yet (indexed by literalIndex)
+	activeCount        []int
+	// Number of the true and false literals of each clause
+	trueCount          []int
+	falseCount         []int
+	// Value of each variable: 1 (true), -1 (false) or 0 (unassigned)
+	values             []int8
+	// All the assigned literals in the order of the assignment
+	assignmentTrace    []sat_solver.CNFLiteral
+	// Decisions in the order they were made (the decision level is the length of this list)
+	decisions          []dpllDecision
+	// Position on the assignmentTrace of the next literal to propagate
+	propagatedIndex    int
+}
+
+/**
+ * Create new DPLL solver instance
+ */
+func NewDPLLSolver() *DPLLSolver {
+	return &DPLLSolver{}
+}
+
+/**
+ * Solve sat formula
+ */
+func (s *DPLLSolver) Solve(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	f, ok := formula.Formula().(*sat_solver.CNFFormula)
+	if !ok {
+		return fmt.Errorf("DPLL Solver supports only CNF formulas."), solver.SatResultUnsat()
+	}
+	s.context = context
+	s.formula = formula
+	s.vars = formula.Variables()
+	s.enableDebugLogging = context.IsSolverTracingEnabled()
+
+	if !s.load(f) {
+		return nil, s.foundResult(solver.SatResultUnsat())
+	}
+	if s.enableDebugLogging {
+		s.context.Trace("start", "Started solver.")
+	}
+	for {
+		if !s.propagate() {
+			if s.enableDebugLogging {
+				s.context.Trace("conflict", "Conflicting clause detected on unit propagation. Decision trace: %s.", s.getDecisionTraceString())
+			}
+			if !s.backtrack() {
+				return nil, s.foundResult(solver.SatResultUnsat())
+			}
+			continue
+		}
+		if s.eliminatePureLiterals() {
+			continue
+		}
+		literal := s.findNextLiteralForDecision()
+		if literal == sat_solver.CNF_UNDEFINED {
+			return nil, s.foundResult(solver.SatResultSat(s.getOutputVariableAssignments()))
+		}
+		s.newDecision(literal)
+	}
+}
+
+/*
+ * Index of the literal in the occurrence lists
+ */
+func literalIndex(literal sat_solver.CNFLiteral) int {
+	if literal < 0 {
+		return int(-2*literal + 1)
+	}
+	return int(2*literal)
+}
+
+/*
+ * Build the clause database and assign the unit clauses.
+ * Returns false if the formula was found to be UNSAT.
+ */
+func (s *DPLLSolver) load(f *sat_solver.CNFFormula) bool {
+	clauses, maxVar := s.encodeConstraints(f)
+	s.maxVar = maxVar
+	s.values = make([]int8, maxVar + 1)
+	s.occurrences = make([][]int, 2*(maxVar + 1))
+	s.activeCount = make([]int, 2*(maxVar + 1))
+	units := []sat_solver.CNFLiteral{}
+
+	for _, clause := range clauses {
+		newClause, isSatisfied := simplifyClause(clause)
+		if isSatisfied {
+			continue
+		} else if len(newClause) == 0 {
+			return false
+		} else if len(newClause) == 1 {
+			units = append(units, newClause[0])
+		}
+		index := len(s.clauses)
+		s.clauses = append(s.clauses, newClause)
+		for _, literal := range newClause {
+			s.occurrences[literalIndex(literal)] = append(s.occurrences[literalIndex(literal)], index)
+			s.activeCount[literalIndex(literal)]++
+		}
+	}
+	s.trueCount = make([]int, len(s.clauses))
+	s.falseCount = make([]int, len(s.clauses))
+
+	for _, literal := range units {
+		switch s.literalValue(literal) {
+		case -1:
+			return false
+		case 0:
+			s.assign(literal)
+		}
+	}
+	return true
+}
+
+/*
+ * Get the clauses of the formula with the XOR and cardinality constraints encoded as clauses
+ * and the largest variable that occurs in them.
+ */
+func (s *DPLLSolver) encodeConstraints(f *sat_solver.CNFFormula) ([]sat_solver.CNFClause, sat_solver.CNFLiteral) {
+	freshID := sat_solver.CNFLiteral(1)
+	for _, v := range s.vars.GetAllVariables() {
+		if v > freshID {
+			freshID = v
+		}
+	}
+	for _, clause := range f.Variables {
+		for _, literal := range clause {
+			if literal.Var() > freshID {
+				freshID = literal.Var()
+			}
+		}
+	}
+	for _, xor := range f.Xors {
+		for _, v := range xor.Vars {
+			if v > freshID {
+				freshID = v
+			}
+		}
+	}
+	for _, c := range f.Cardinalities {
+		for _, literal := range c.Literals {
+			if literal.Var() > freshID {
+				freshID = literal.Var()
+			}
+		}
+	}
+	if len(f.Xors) == 0 && len(f.Cardinalities) == 0 {
+		return f.Variables, freshID
+	}
+
+	fresh := func() sat_solver.CNFLiteral {
+		freshID++
+		return freshID
+	}
+	clauses := append([]sat_solver.CNFClause{}, f.Variables...)
+	for _, xor := range f.Xors {
+		clauses = append(clauses, xor.ToCNF(fresh)...)
+	}
+	for _, c := range f.Cardinalities {
+		clauses = append(clauses, c.ToCNF(fresh)...)
+	}
+	return clauses, freshID
+}
+
+/*
+ * Remove constants and duplicated literals from the clause.
+ * Returns true if the clause is always satisfied.
+ */
+func simplifyClause(clause sat_solver.CNFClause) (sat_solver.CNFClause, bool) {
+	newClause := make(sat_solver.CNFClause, 0, len(clause))
+	for _, literal := range clause {
+		if literal == 1 {
+			return nil, true
+		} else if literal == -1 {
+			continue
+		}
+		isDuplicate := false
+		for _, existing := range newClause {
+			if existing == literal {
+				isDuplicate = true
+				break
+			} else if existing == -literal {
+				// Tautology is always satisfied
+				return nil, true
+			}
+		}
+		if !isDuplicate {
+			newClause = append(newClause, literal)
+		}
+	}
+	return newClause, false
+}
+
+/*
+ * Get the current value of the literal: 1 (true), -1 (false) or 0 (unassigned).
+ */
+func (s *DPLLSolver) literalValue(literal sat_solver.CNFLiteral) int8 {
+	if literal < 0 {
+		return -s.values[-literal]
+	}
+	return s.values[literal]
+}
+
+/*
+ * Assign the literal and update the counters of the clauses.
+ */
+func (s *DPLLSolver) assign(literal sat_solver.CNFLiteral) {
+	if literal < 0 {
+		s.values[-literal] = -1
+	} else {
+		s.values[literal] = 1
+	}
+	s.assignmentTrace = append(s.assignmentTrace, literal)
+	for _, index := range s.occurrences[literalIndex(literal)] {
+		s.trueCount[index]++
+		if s.trueCount[index] == 1 {
+			// The clause is satisfied now, so its literals are no longer active
+			for _, l := range s.clauses[index] {
+				s.activeCount[literalIndex(l)]--
+			}
+		}
+	}
+	for _, index := range s.occurrences[literalIndex(-literal)] {
+		s.falseCount[index]++
+	}
+}
+
+/*
+ * Unassign all the literals on the assignmentTrace starting from the given position.
+ */
+func (s *DPLLSolver) unassignFrom(traceIndex int) {
+	for i := len(s.assignmentTrace) - 1; i >= traceIndex; i-- {
+		literal := s.assignmentTrace[i]
+		for _, index := range s.occurrences[literalIndex(literal)] {
+			s.trueCount[index]--
+			if s.trueCount[index] == 0 {
+				for _, l := range s.clauses[index] {
+					s.activeCount[literalIndex(l)]++
+				}
+			}
+		}
+		for _, index := range s.occurrences[literalIndex(-literal)] {
+			s.falseCount[index]--
+		}
+		s.values[literal.Var()] = 0
+	}
+	s.assignmentTrace = s.assignmentTrace[:traceIndex]
+	if s.propagatedIndex > traceIndex {
+		s.propagatedIndex = traceIndex
+	}
+}
+
+/*
+ * Perform unit propagation of all the literals assigned since the last call.
+ * Returns false if some clause became false.
+ */
+func (s *DPLLSolver) propagate() bool {
+	for s.propagatedIndex < len(s.assignmentTrace) {
+		literal := s.assignmentTrace[s.propagatedIndex]
+		s.propagatedIndex++
+		for _, index := range s.occurrences[literalIndex(-literal)] {
+			if s.trueCount[index] > 0 {
+				continue
+			}
+			clause := s.clauses[index]
+			if s.falseCount[index] == len(clause) {
+				return false
+			} else if s.falseCount[index] == len(clause) - 1 {
+				// The only unassigned literal of the clause must be true
+				for _, l := range clause {
+					if s.literalValue(l) == 0 {
+						s.assign(l)
+						break
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+/*
+ * Assign all the pure literals.
+ * Returns true if anything was assigned.
+ */
+func (s *DPLLSolver) eliminatePureLiterals() bool {
+	isAssigned := false
+	for v := sat_solver.CNFLiteral(2); v <= s.maxVar; v++ {
+		if s.values[v] != 0 {
+			continue
+		}
+		positive, negative := s.activeCount[literalIndex(v)], s.activeCount[literalIndex(-v)]
+		literal := sat_solver.CNF_UNDEFINED
+		if positive > 0 && negative == 0 {
+			literal = v
+		} else if negative > 0 && positive == 0 {
+			literal = -v
+		} else {
+			continue
+		}
+		if s.enableDebugLogging {
+			s.context.Trace("pure", "Assign pure literal %s (%s)", literal.String(s.vars), literal.DebugString())
+		}
+		s.assign(literal)
+		isAssigned = true
+	}
+	return isAssigned
+}
+
+/*
+ * Find the unassigned literal with the most occurrences in the clauses that are not satisfied yet.
+ * Returns CNF_UNDEFINED if all the clauses are satisfied.
+ */
+func (s *DPLLSolver) findNextLiteralForDecision() sat_solver.CNFLiteral {
+	best, bestCount := sat_solver.CNF_UNDEFINED, 0
+	for v := sat_solver.CNFLiteral(2); v <= s.maxVar; v++ {
+		if s.values[v] != 0 {
+			continue
+		}
+		for _, literal := range []sat_solver.CNFLiteral{ v, -v } {
+			if count := s.activeCount[literalIndex(literal)]; count > bestCount {
+				best, bestCount = literal, count
+			}
+		}
+	}
+	return best
+}
+
+/*
+ * Create new decision for a given literal.
+ */
+func (s *DPLLSolver) newDecision(literal sat_solver.CNFLiteral) {
+	if s.enableDebugLogging {
+		s.context.Trace("decide", "Create new decision for %s (%s)", literal.String(s.vars), literal.DebugString())
+	}
+	s.decisions = append(s.decisions, dpllDecision{
+		literal:    literal,
+		traceIndex: len(s.assignmentTrace),
+		isFlipped:  false,
+	})
+	s.assign(literal)
+}
+
+/*
+ * Go back to the last decision that was not flipped yet and assign the opposite value.
+ * Returns false if there is no such decision (so the formula is UNSAT).
+ */
+func (s *DPLLSolver) backtrack() bool {
+	for len(s.decisions) > 0 {
+		decision := s.decisions[len(s.decisions) - 1]
+		s.decisions = s.decisions[:len(s.decisions) - 1]
+		s.unassignFrom(decision.traceIndex)
+		if decision.isFlipped {
+			continue
+		}
+		if s.enableDebugLogging {
+			s.context.Trace("reverse", "Jumping back to getDecisionLevel %d.", len(s.decisions))
+		}
+		s.decisions = append(s.decisions, dpllDecision{
+			literal:    -decision.literal,
+			traceIndex: len(s.assignmentTrace),
+			isFlipped:  true,
+		})
+		s.assign(-decision.literal)
+		return true
+	}
+	return false
+}
+
+/*
+ * Get human-readable string describing the current decision trace.
+ */
+func (s *DPLLSolver) getDecisionTraceString() string {
+	rows := []string{}
+	decisionID := 0
+	for i, l := range s.assignmentTrace {
+		decision := ""
+		if decisionID < len(s.decisions) && s.decisions[decisionID].traceIndex == i {
+			decision = "> "
+			decisionID++
+		}
+		rows = append(rows, fmt.Sprintf("%s%s", decision, l.DebugString()))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(rows, ", "))
+}
+
+/*
+ * Save the result when we found something.
+ */
+func (s *DPLLSolver) foundResult(result solver.SatResult) solver.SatResult {
+	if s.enableDebugLogging {
+		s.context.Trace("result", "Found result %s.", result.String())
+	}
+	return result
+}
+
+/**
+ * Get assignments for the variables of the formula when we found SAT.
+ * Variables that do not matter are set to false.
+ */
+func (s *DPLLSolver) getOutputVariableAssignments() map[string]bool {
+	model := make(map[sat_solver.CNFLiteral]bool)
+	for _, v := range s.vars.GetAllVariables() {
+		model[v] = s.values[v] > 0
+	}
+	return s.formula.FounderAssignment(model)
+}
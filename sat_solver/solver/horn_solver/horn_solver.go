@@ -0,0 +1,309 @@
+package horn_solver
+
+/**
+ * This file provides the linear time solver for Horn and renamable Horn formulas.
+ *
+ * A clause is Horn if it has at most one positive literal. Horn formulas are solved by unit resolution
+ * starting from the assignment with all the variables set to false: each clause counts its negative literals
+ * whose variables are not true yet. When the counter drops to 0, the positive literal of the clause must be true,
+ * so its variable is set to true, or the formula is UNSAT if there is no positive literal.
+ * Each literal is visited at most once, so the whole algorithm runs in linear time.
+ *
+ * The formula is renamable Horn if it becomes Horn after negating all the occurrences of some variables.
+ * The renaming is found by a 2-SAT instance over the variables "x is negated": for each clause
+ * at most one of its literals can be positive after the renaming. The "at most one" constraints are encoded
+ * with the ladder encoding, which uses only binary clauses and keeps the instance linear in the formula size.
+ *
+ * For more details please see:
+ *   "Linear-time algorithms for testing the satisfiability of propositional Horn formulae"
+ *     by William F. Dowling and Jean H. Gallier (1984)
+ *   "Renaming a Set of Clauses as a Horn Set" by Harry R. Lewis (1978)
+ */
+
+import (
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver/twosat_solver"
+)
+
+/*
+ * Horn solver factory
+ */
+type HornSolverFactory struct {}
+
+func (hsf HornSolverFactory) CanSolveFormula(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
+	f, ok := formula.Formula().(*sat_solver.CNFFormula)
+	if !ok || len(f.Xors) > 0 || len(f.Cardinalities) > 0 {
+		return false
+	}
+	isRenamable, _ := FindHornRenaming(f.Variables, maxVariable(formula, f))
+	return isRenamable
+}
+
+func (hsf HornSolverFactory) IsPreferredFor(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
+	return hsf.CanSolveFormula(formula, context)
+}
+
+func (hsf HornSolverFactory) CreateSolver(formula *sat_solver.SATFormula, context *sat_solver.SATContext) solver.Solver {
+	return NewHornSolver()
+}
+
+func (hsf HornSolverFactory) GetName() string {
+	return "horn"
+}
+
+// Register solver factory
+func init() {
+	solver.RegisterSolverFactory(HornSolverFactory{})
+}
+
+/**
+ * Horn solver instance
+ */
+type HornSolver struct {}
+
+func NewHornSolver() *HornSolver {
+	return &HornSolver{}
+}
+
+/**
+ * Solve sat formula
+ */
+func (s *HornSolver) Solve(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	f, ok := formula.Formula().(*sat_solver.CNFFormula)
+	if !ok || len(f.Xors) > 0 || len(f.Cardinalities) > 0 {
+		return fmt.Errorf("Horn Solver supports only CNF formulas without XOR and cardinality constraints."), solver.SatResultUnsat()
+	}
+	maxVar := maxVariable(formula, f)
+	isRenamable, renaming := FindHornRenaming(f.Variables, maxVar)
+	if !isRenamable {
+		return fmt.Errorf("Horn Solver supports only renamable Horn formulas."), solver.SatResultUnsat()
+	}
+	if context.IsSolverTracingEnabled() {
+		renamed := 0
+		for _, isRenamed := range renaming {
+			if isRenamed {
+				renamed++
+			}
+		}
+		context.Trace("horn", "The formula is Horn after renaming %d variables.", renamed)
+	}
+
+	isSat, values := SolveHorn(f.Variables, maxVar, renaming)
+	if !isSat {
+		return nil, solver.SatResultUnsat()
+	}
+	model := map[sat_solver.CNFLiteral]bool{}
+	for _, v := range formula.Variables().GetAllVariables() {
+		model[v] = values[v]
+	}
+	return nil, solver.SatResultSat(formula.FounderAssignment(model))
+}
+
+/*
+ * Get the largest variable of the formula.
+ */
+func maxVariable(formula *sat_solver.SATFormula, f *sat_solver.CNFFormula) sat_solver.CNFLiteral {
+	maxVar := sat_solver.CNFLiteral(1)
+	for _, v := range formula.Variables().GetAllVariables() {
+		if v > maxVar {
+			maxVar = v
+		}
+	}
+	for _, clause := range f.Variables {
+		for _, literal := range clause {
+			if literal.Var() > maxVar {
+				maxVar = literal.Var()
+			}
+		}
+	}
+	return maxVar
+}
+
+/*
+ * Removes constants and duplicated literals from the clauses.
+ * Literals already seen in the current clause are marked with its number, so each clause is simplified
+ * in time linear in its size.
+ */
+type clauseSimplifier struct {
+	marks  []int
+	clause int
+}
+
+func newClauseSimplifier(maxVar sat_solver.CNFLiteral) *clauseSimplifier {
+	return &clauseSimplifier{
+		marks:  make([]int, 2*(maxVar + 1)),
+		clause: 0,
+	}
+}
+
+func literalIndex(literal sat_solver.CNFLiteral) int {
+	if literal < 0 {
+		return int(-2*literal + 1)
+	}
+	return int(2*literal)
+}
+
+/*
+ * Returns the simplified clause or true if the clause is always satisfied.
+ */
+func (cs *clauseSimplifier) simplify(clause sat_solver.CNFClause) (sat_solver.CNFClause, bool) {
+	cs.clause++
+	newClause := make(sat_solver.CNFClause, 0, len(clause))
+	for _, literal := range clause {
+		if literal == 1 || cs.marks[literalIndex(-literal)] == cs.clause {
+			return nil, true
+		} else if literal == -1 || cs.marks[literalIndex(literal)] == cs.clause {
+			continue
+		}
+		cs.marks[literalIndex(literal)] = cs.clause
+		newClause = append(newClause, literal)
+	}
+	return newClause, false
+}
+
+/**
+ * Check if the clauses are Horn (each clause has at most one positive literal).
+ */
+func IsHorn(clauses []sat_solver.CNFClause, maxVar sat_solver.CNFLiteral) bool {
+	simplifier := newClauseSimplifier(maxVar)
+	for _, clause := range clauses {
+		newClause, isSatisfied := simplifier.simplify(clause)
+		if isSatisfied {
+			continue
+		}
+		positive := 0
+		for _, literal := range newClause {
+			if literal > 0 {
+				positive++
+			}
+		}
+		if positive > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * Find the set of variables that makes the clauses Horn when all their occurrences are negated.
+ * Returns false if there is no such set. Otherwise renaming[v] is true for the variables that should be negated.
+ */
+func FindHornRenaming(clauses []sat_solver.CNFClause, maxVar sat_solver.CNFLiteral) (bool, []bool) {
+	if IsHorn(clauses, maxVar) {
+		return true, make([]bool, maxVar + 1)
+	}
+	// The variable v of the 2-SAT instance is true if v is negated by the renaming.
+	// The literal l is positive after the renaming if -l is true, so at most one of -l1, ..., -lk can be true.
+	freshID := maxVar
+	constraints := []sat_solver.CNFClause{}
+	simplifier := newClauseSimplifier(maxVar)
+	for _, clause := range clauses {
+		newClause, isSatisfied := simplifier.simplify(clause)
+		if isSatisfied || len(newClause) < 2 {
+			continue
+		}
+		// Ladder encoding: s_i is true if any of -l1, ..., -li is true
+		previous := sat_solver.CNF_UNDEFINED
+		for i, literal := range newClause {
+			if previous != sat_solver.CNF_UNDEFINED {
+				constraints = append(constraints, sat_solver.CNFClause{ -previous, literal })
+			}
+			if i == len(newClause) - 1 {
+				break
+			}
+			freshID++
+			current := freshID
+			constraints = append(constraints, sat_solver.CNFClause{ literal, current })
+			if previous != sat_solver.CNF_UNDEFINED {
+				constraints = append(constraints, sat_solver.CNFClause{ -previous, current })
+			}
+			previous = current
+		}
+	}
+	isSat, values := twosat_solver.Solve2CNF(constraints, freshID)
+	if !isSat {
+		return false, nil
+	}
+	return true, values[:maxVar + 1]
+}
+
+/**
+ * Solve the clauses that are Horn after negating the variables with renaming[v] set to true.
+ * Returns false if the clauses are UNSAT. Otherwise the model (of the original clauses) indexed by
+ * the variable IDs is returned.
+ */
+func SolveHorn(clauses []sat_solver.CNFClause, maxVar sat_solver.CNFLiteral, renaming []bool) (bool, []bool) {
+	rename := func(literal sat_solver.CNFLiteral) sat_solver.CNFLiteral {
+		if renaming[literal.Var()] {
+			return -literal
+		}
+		return literal
+	}
+
+	// For each clause: number of its negative literals with the variables that are not true yet
+	// and its only positive literal (or 0)
+	negativeCount := make([]int, 0, len(clauses))
+	head := make([]sat_solver.CNFLiteral, 0, len(clauses))
+	negativeOccurrences := make([][]int, maxVar + 1)
+	values := make([]bool, maxVar + 1)
+	queue := []sat_solver.CNFLiteral{}
+	assign := func(v sat_solver.CNFLiteral) {
+		if !values[v] {
+			values[v] = true
+			queue = append(queue, v)
+		}
+	}
+
+	simplifier := newClauseSimplifier(maxVar)
+	for _, clause := range clauses {
+		newClause, isSatisfied := simplifier.simplify(clause)
+		if isSatisfied {
+			continue
+		}
+		index := len(head)
+		count := 0
+		positive := sat_solver.CNF_UNDEFINED
+		for _, literal := range newClause {
+			literal = rename(literal)
+			if literal > 0 {
+				positive = literal
+			} else {
+				count++
+				negativeOccurrences[literal.Var()] = append(negativeOccurrences[literal.Var()], index)
+			}
+		}
+		negativeCount = append(negativeCount, count)
+		head = append(head, positive)
+		if count == 0 {
+			if positive == sat_solver.CNF_UNDEFINED {
+				return false, nil
+			}
+			assign(positive)
+		}
+	}
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, index := range negativeOccurrences[v] {
+			negativeCount[index]--
+			if negativeCount[index] == 0 {
+				if head[index] == sat_solver.CNF_UNDEFINED {
+					return false, nil
+				}
+				assign(head[index])
+			}
+		}
+	}
+
+	// Go back from the renamed variables to the original ones
+	for v := range values {
+		if renaming[v] {
+			values[v] = !values[v]
+		}
+	}
+	return true, values
+}
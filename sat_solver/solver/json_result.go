@@ -0,0 +1,77 @@
+package solver
+
+import (
+	"encoding/json"
+)
+
+/**
+ * Result in the JSON format (--output json), for example:
+ *
+ *   {"status":"sat","model":{"a":true,"b":false},"stats":{"conflicts":2,"restarts":0,"time_ms":1}}
+ *
+ * The status is "sat", "unsat" or "unknown". The model is null unless the status is "sat".
+ * The optional keys are present only for the results that have them: "cost" of the optimal solution,
+ * "backbone" and "core" (the failed assumptions, see AssumptionsSolverResult).
+ * The stats contain the counters of the solver (if it provides them, see StatisticsSolverResult).
+ */
+type JSONResult struct {
+	Status   string            `json:"status"`
+	Model    map[string]bool   `json:"model"`
+	Cost     *int64            `json:"cost,omitempty"`
+	Backbone *map[string]bool  `json:"backbone,omitempty"`
+	Core     *[]string         `json:"core,omitempty"`
+	Stats    map[string]int64  `json:"stats"`
+}
+
+/**
+ * Convert the result to JSON. The wrapping results (optimum, partial model, backbone, core) are unwrapped
+ * and their data is included as well.
+ */
+func NewJSONResult(result SolverResult) JSONResult {
+	jsonResult := JSONResult{
+		Status: "unknown",
+		Stats:  map[string]int64{},
+	}
+	if result.IsSAT() {
+		jsonResult.Status = "sat"
+		jsonResult.Model = result.GetSatisfyingAssignment()
+	} else if result.IsUNSAT() {
+		jsonResult.Status = "unsat"
+	}
+	for result != nil {
+		switch wrapper := result.(type) {
+		case OptimumSolverResult:
+			cost := wrapper.Cost
+			jsonResult.Cost = &cost
+			result = wrapper.SolverResult
+		case PartialModelSolverResult:
+			result = wrapper.SolverResult
+		case BackboneSolverResult:
+			if wrapper.IsSAT() {
+				jsonResult.Backbone = &wrapper.Backbone
+			}
+			result = wrapper.SolverResult
+		case AssumptionsSolverResult:
+			if wrapper.IsUNSAT() {
+				jsonResult.Core = &wrapper.Core
+			}
+			result = wrapper.SolverResult
+		default:
+			if statistics, ok := result.(StatisticsSolverResult); ok {
+				for name, value := range statistics.GetStatistics() {
+					jsonResult.Stats[name] = value
+				}
+			}
+			result = nil
+		}
+	}
+	return jsonResult
+}
+
+func (result JSONResult) String() string {
+	text, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+	return string(text)
+}
@@ -0,0 +1,251 @@
+package lookahead_solver
+
+/**
+ * This file provides the lookahead procedure used to pick the branching literal.
+ *
+ * At each node of the search the most promising variables are preselected (the ones that occur in many
+ * short clauses) and both of their literals are assigned in turn and propagated:
+ *   - if the propagation of the literal fails, then the literal is failed and its negation is assigned
+ *       at the current decision level
+ *   - literals implied by both x and -x are necessary assignments and are assigned as well
+ *   - otherwise the difference heuristic measures how much the formula is reduced: every clause that loses
+ *       a literal without becoming satisfied adds REDUCTION_WEIGHT_BASE^(k-2), where k is its new size
+ * The lookahead is repeated while it finds new assignments. Then the variable with the largest
+ * 1024 * diff(x) * diff(-x) + diff(x) + diff(-x) is chosen and its less reducing literal is tried first.
+ *
+ * Local learning: if the lookahead on l implied x through a clause with more than two literals,
+ * then the binary clause (-l v x) is learned for the current node, so the next lookaheads propagate it directly.
+ *
+ * Double lookahead: if the lookahead on l reduced the formula by more than the trigger, then all the candidates
+ * are looked ahead again under l. Their failed literals are assigned under l and if that fails, then l is failed too.
+ * The trigger is raised to the reduction of the last double lookahead and decays at each node, so the expensive
+ * double lookahead is done only for the literals that reduce the formula the most.
+ *
+ * For more details please see:
+ *   "March_eq: Implementing Additional Reasoning into an Efficient Look-Ahead SAT Solver"
+ *     by Marijn Heule, Mark Dufour, Joris van Zwieten and Hans van Maaren (SAT 2004)
+ *   "Look-Ahead Based SAT Solvers" by Marijn Heule and Hans van Maaren (Handbook of Satisfiability, 2009)
+ */
+
+import (
+	"sort"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+const (
+	// Weight of the reduced clause with k literals is REDUCTION_WEIGHT_BASE^(k-2)
+	REDUCTION_WEIGHT_BASE = 0.2
+	// Minimal number of the preselected variables
+	LOOKAHEAD_MIN_CANDIDATES = 10
+	// Percent of the free variables that are preselected
+	LOOKAHEAD_CANDIDATES_PERCENT = 10
+	// The double lookahead trigger is multiplied by this factor at each node
+	DOUBLE_LOOKAHEAD_DECAY = 0.9
+)
+
+/*
+ * Preselect the variables for the lookahead.
+ * Each unassigned literal is scored by the weights of the clauses that are not satisfied yet and contain it.
+ * Returns no variables if all the clauses are satisfied.
+ */
+func (s *LookaheadSolver) preselectCandidates() []sat_solver.CNFLiteral {
+	score := make([]float64, 2*(s.maxVar + 1))
+	for index, clause := range s.clauses {
+		if s.trueCount[index] > 0 {
+			continue
+		}
+		weight := s.reductionWeight[len(clause) - s.falseCount[index]]
+		for _, literal := range clause {
+			if s.literalValue(literal) == 0 {
+				score[literalIndex(literal)] += weight
+			}
+		}
+	}
+
+	candidates := []sat_solver.CNFLiteral{}
+	rank := make([]float64, s.maxVar + 1)
+	for v := sat_solver.CNFLiteral(2); v <= s.maxVar; v++ {
+		positive, negative := score[literalIndex(v)], score[literalIndex(-v)]
+		if s.values[v] != 0 || positive + negative == 0 {
+			continue
+		}
+		candidates = append(candidates, v)
+		rank[v] = 1024*positive*negative + positive + negative
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if rank[candidates[i]] != rank[candidates[j]] {
+			return rank[candidates[i]] > rank[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	count := len(candidates) * LOOKAHEAD_CANDIDATES_PERCENT / 100
+	if count < LOOKAHEAD_MIN_CANDIDATES {
+		count = LOOKAHEAD_MIN_CANDIDATES
+	}
+	if count < len(candidates) {
+		candidates = candidates[:count]
+	}
+	return candidates
+}
+
+/*
+ * Run the lookahead at the current node.
+ * Returns false if the node is conflicting. Otherwise the literal for the next decision is returned
+ * or CNF_UNDEFINED if all the clauses are satisfied.
+ */
+func (s *LookaheadSolver) lookahead() (bool, sat_solver.CNFLiteral) {
+	s.doubleLookaheadTrigger *= DOUBLE_LOOKAHEAD_DECAY
+	candidates := s.preselectCandidates()
+	if len(candidates) == 0 {
+		return true, sat_solver.CNF_UNDEFINED
+	}
+	s.lookaheadCount++
+
+	reduction := map[sat_solver.CNFLiteral]float64{}
+	failedCount, necessaryCount := 0, 0
+	for isChanged := true; isChanged; {
+		isChanged = false
+		for _, v := range candidates {
+			if s.values[v] != 0 {
+				continue
+			}
+			s.necessaryCandidates = s.necessaryCandidates[:0]
+			for _, literal := range []sat_solver.CNFLiteral{ v, -v } {
+				isFailed, literalReduction := s.lookaheadLiteral(literal, candidates)
+				if isFailed {
+					failedCount++
+					isChanged = true
+					s.assign(-literal)
+					if !s.propagate() {
+						return false, sat_solver.CNF_UNDEFINED
+					}
+					break
+				}
+				reduction[literal] = literalReduction
+			}
+			if s.values[v] != 0 {
+				continue
+			}
+			// Literals implied by both v and -v must be true
+			for _, literal := range s.necessaryCandidates {
+				if s.literalValue(literal) == 0 {
+					necessaryCount++
+					isChanged = true
+					s.assign(literal)
+				}
+			}
+			if !s.propagate() {
+				return false, sat_solver.CNF_UNDEFINED
+			}
+		}
+	}
+	if s.enableDebugLogging {
+		s.context.Trace("lookahead", "Lookahead on %d variables found %d failed literals and %d necessary assignments.",
+			len(candidates), failedCount, necessaryCount)
+	}
+
+	best, bestScore := sat_solver.CNF_UNDEFINED, -1.0
+	for _, v := range candidates {
+		if s.values[v] != 0 {
+			continue
+		}
+		positive, negative := reduction[v], reduction[-v]
+		score := 1024*positive*negative + positive + negative
+		if score > bestScore {
+			bestScore = score
+			// The literal that reduces the formula less is more likely to lead to a model
+			if positive <= negative {
+				best = v
+			} else {
+				best = -v
+			}
+		}
+	}
+	if best == sat_solver.CNF_UNDEFINED {
+		// All the candidates were assigned by the lookahead, so look at the remaining variables
+		return s.lookahead()
+	}
+	return true, best
+}
+
+/*
+ * Assign the literal, propagate it and measure the reduction of the formula. The assignment is reverted before returning.
+ * Returns true if the literal is failed.
+ * The literals implied by v are stamped with a new stamp and the literals implied by -v that have that stamp
+ * are saved as the candidates for the necessary assignments.
+ */
+func (s *LookaheadSolver) lookaheadLiteral(literal sat_solver.CNFLiteral, candidates []sat_solver.CNFLiteral) (bool, float64) {
+	start := len(s.assignmentTrace)
+	s.reduction = 0
+	s.isMeasuringReduction = true
+	s.isCollectingImplications = true
+	s.learningCandidates = s.learningCandidates[:0]
+	s.assign(literal)
+	isFailed := !s.propagate()
+	s.isCollectingImplications = false
+	literalReduction := s.reduction
+	if !isFailed && literalReduction > s.doubleLookaheadTrigger {
+		isFailed = !s.doubleLookahead(candidates)
+		s.doubleLookaheadTrigger = literalReduction
+	}
+	s.isMeasuringReduction = false
+
+	if !isFailed {
+		if literal > 0 {
+			s.stamp++
+			for _, implied := range s.assignmentTrace[start+1:] {
+				s.impliedStamp[literalIndex(implied)] = s.stamp
+			}
+		} else {
+			for _, implied := range s.assignmentTrace[start+1:] {
+				if s.impliedStamp[literalIndex(implied)] == s.stamp {
+					s.necessaryCandidates = append(s.necessaryCandidates, implied)
+				}
+			}
+		}
+	} else if literal > 0 {
+		// Nothing is implied by both literals of the variable
+		s.stamp++
+	}
+	learned := append([]sat_solver.CNFLiteral{}, s.learningCandidates...)
+	s.unassignFrom(start)
+
+	if !isFailed {
+		for _, implied := range learned {
+			if s.literalValue(implied) == 0 {
+				s.addLearnedClause(sat_solver.CNFClause{ -literal, implied })
+			}
+		}
+	}
+	return isFailed, literalReduction
+}
+
+/*
+ * Look ahead on all the candidates under the current lookahead literal.
+ * Failed literals are assigned the opposite value. Returns false if that causes a conflict.
+ */
+func (s *LookaheadSolver) doubleLookahead(candidates []sat_solver.CNFLiteral) bool {
+	s.doubleLookaheadCount++
+	reduction := s.reduction
+	for _, v := range candidates {
+		for _, literal := range []sat_solver.CNFLiteral{ v, -v } {
+			if s.values[v] != 0 {
+				break
+			}
+			start := len(s.assignmentTrace)
+			s.assign(literal)
+			isFailed := !s.propagate()
+			s.unassignFrom(start)
+			if isFailed {
+				s.assign(-literal)
+				if !s.propagate() {
+					return false
+				}
+			}
+		}
+	}
+	s.reduction = reduction
+	return true
+}
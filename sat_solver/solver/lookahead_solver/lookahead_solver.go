@@ -0,0 +1,354 @@
+package lookahead_solver
+
+/**
+ * This file provides the lookahead solver in the style of march.
+ *
+ * The search is DPLL with chronological backtracking, but at each node the branching literal is chosen
+ * by the lookahead (see lookahead.go), which also finds the failed literals and necessary assignments
+ * and learns binary clauses valid for the current node. It does much more work per node than CDCL,
+ * but the search tree is much smaller, which pays off on small hard random 3-SAT instances.
+ *
+ * The same search can split the formula into cubes: the decisions of each branch that reached the given
+ * depth form a cube, branches refuted by the lookahead are dropped. The formula is satisfiable if and only if
+ * it's satisfiable together with one of the cubes, so each cube can be solved separately (see GenerateCubes).
+ *
+ * The solver emits the same trace events as the CDCL solver and the "lookahead" event for each node.
+ * XOR and cardinality constraints are encoded as clauses before the search starts.
+ */
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/*
+ * Lookahead solver factory
+ */
+type LookaheadSolverFactory struct {}
+
+func (lsf LookaheadSolverFactory) CanSolveFormula(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
+	_, ok := formula.Formula().(*sat_solver.CNFFormula)
+	return ok
+}
+
+func (lsf LookaheadSolverFactory) CreateSolver(formula *sat_solver.SATFormula, context *sat_solver.SATContext) solver.Solver {
+	return NewLookaheadSolver()
+}
+
+func (lsf LookaheadSolverFactory) GetName() string {
+	return "lookahead"
+}
+
+// Register solver factory
+func init() {
+	solver.RegisterSolverFactory(LookaheadSolverFactory{})
+}
+
+/*
+ * Decision made by the search
+ */
+type lookaheadDecision struct {
+	// Decided literal
+	literal    sat_solver.CNFLiteral
+	// Position of the decided literal on the assignmentTrace
+	traceIndex int
+	// Was the opposite value already tried?
+	isFlipped  bool
+}
+
+/**
+ * Lookahead solver state
+ */
+type LookaheadSolver struct {
+	// Enable debug output
+	enableDebugLogging       bool
+	// Context and formula that we work on
+	context                  *sat_solver.SATContext
+	formula                  *sat_solver.SATFormula
+	vars                     *sat_solver.SATVariableMapping
+	// Clauses of the formula followed by the learned binary clauses and the largest variable
+	clauses                  []sat_solver.CNFClause
+	maxVar                   sat_solver.CNFLiteral
+	// Decision level of each learned clause
+	learnedLevels            []int
+	// Clauses that contain the literal (indexed by literalIndex)
+	occurrences              [][]int
+	// Number of the true and false literals of each clause
+	trueCount                []int
+	falseCount               []int
+	// Value of each variable: 1 (true), -1 (false) or 0 (unassigned)
+	values                   []int8
+	// All the assigned literals in the order of the assignment
+	assignmentTrace          []sat_solver.CNFLiteral
+	// Decisions in the order they were made (the decision level is the length of this list)
+	decisions                []lookaheadDecision
+	// Position on the assignmentTrace of the next literal to propagate
+	propagatedIndex          int
+	// Weight of the reduced clause by its new size
+	reductionWeight          []float64
+	// Reduction of the formula by the current lookahead
+	reduction                float64
+	isMeasuringReduction     bool
+	// Literals implied through the longer clauses by the current lookahead
+	isCollectingImplications bool
+	learningCandidates       []sat_solver.CNFLiteral
+	// Literals implied by the lookahead on the positive literal are marked with the current stamp
+	impliedStamp             []int
+	stamp                    int
+	// Literals implied by both literals of the variable
+	necessaryCandidates      []sat_solver.CNFLiteral
+	// Reduction that triggers the double lookahead
+	doubleLookaheadTrigger   float64
+	// Statistics
+	lookaheadCount           int
+	doubleLookaheadCount     int
+	learnedCount             int
+	// Cubes found by GenerateCubes
+	cubes                    [][]sat_solver.CNFLiteral
+}
+
+/**
+ * Create new lookahead solver instance
+ */
+func NewLookaheadSolver() *LookaheadSolver {
+	return &LookaheadSolver{}
+}
+
+/**
+ * Solve sat formula
+ */
+func (s *LookaheadSolver) Solve(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	err, isLoaded := s.load(formula, context)
+	if err != nil {
+		return err, solver.SatResultUnsat()
+	}
+	if !isLoaded || !s.search(0) {
+		return nil, s.foundResult(solver.SatResultUnsat())
+	}
+	return nil, s.foundResult(solver.SatResultSat(s.getOutputVariableAssignments()))
+}
+
+/**
+ * Split the formula into cubes: conjunctions of at most maxDepth literals, such that the formula is satisfiable
+ * if and only if it's satisfiable together with one of them.
+ * Returns no cubes if the lookahead proved that the formula is UNSAT.
+ * The cubes use the variable IDs of the formula (fresh IDs above the formula variables can occur
+ * if the formula has XOR or cardinality constraints).
+ */
+func (s *LookaheadSolver) GenerateCubes(formula *sat_solver.SATFormula, context *sat_solver.SATContext, maxDepth int) (error, [][]sat_solver.CNFLiteral) {
+	if maxDepth < 1 {
+		return fmt.Errorf("Cube depth must be positive, got %d.", maxDepth), nil
+	}
+	err, isLoaded := s.load(formula, context)
+	if err != nil || !isLoaded {
+		return err, [][]sat_solver.CNFLiteral{}
+	}
+	s.cubes = [][]sat_solver.CNFLiteral{}
+	s.search(maxDepth)
+	if s.enableDebugLogging {
+		s.context.Trace("cubes", "Generated %d cubes of depth at most %d.", len(s.cubes), maxDepth)
+	}
+	return nil, s.cubes
+}
+
+/*
+ * Load the formula and assign its unit clauses.
+ * Returns false if the formula was found to be UNSAT.
+ */
+func (s *LookaheadSolver) load(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, bool) {
+	f, ok := formula.Formula().(*sat_solver.CNFFormula)
+	if !ok {
+		return fmt.Errorf("Lookahead Solver supports only CNF formulas."), false
+	}
+	s.context = context
+	s.formula = formula
+	s.vars = formula.Variables()
+	s.enableDebugLogging = context.IsSolverTracingEnabled()
+	clauses, maxVar := s.encodeConstraints(f)
+	return nil, s.loadClauses(clauses, maxVar)
+}
+
+/*
+ * Get the clauses of the formula with the XOR and cardinality constraints encoded as clauses
+ * and the largest variable that occurs in them.
+ */
+func (s *LookaheadSolver) encodeConstraints(f *sat_solver.CNFFormula) ([]sat_solver.CNFClause, sat_solver.CNFLiteral) {
+	freshID := sat_solver.CNFLiteral(1)
+	for _, v := range s.vars.GetAllVariables() {
+		if v > freshID {
+			freshID = v
+		}
+	}
+	for _, clause := range f.Variables {
+		for _, literal := range clause {
+			if literal.Var() > freshID {
+				freshID = literal.Var()
+			}
+		}
+	}
+	for _, xor := range f.Xors {
+		for _, v := range xor.Vars {
+			if v > freshID {
+				freshID = v
+			}
+		}
+	}
+	for _, c := range f.Cardinalities {
+		for _, literal := range c.Literals {
+			if literal.Var() > freshID {
+				freshID = literal.Var()
+			}
+		}
+	}
+	if len(f.Xors) == 0 && len(f.Cardinalities) == 0 {
+		return f.Variables, freshID
+	}
+
+	fresh := func() sat_solver.CNFLiteral {
+		freshID++
+		return freshID
+	}
+	clauses := append([]sat_solver.CNFClause{}, f.Variables...)
+	for _, xor := range f.Xors {
+		clauses = append(clauses, xor.ToCNF(fresh)...)
+	}
+	for _, c := range f.Cardinalities {
+		clauses = append(clauses, c.ToCNF(fresh)...)
+	}
+	return clauses, freshID
+}
+
+/*
+ * Run the search on the loaded formula.
+ * If cubeDepth is positive, then the decisions of each branch that reached that depth or satisfied
+ * all the clauses are saved as a cube and the search goes on with the next branch.
+ * Otherwise the search stops at the first model.
+ * Returns true if the model was found.
+ */
+func (s *LookaheadSolver) search(cubeDepth int) bool {
+	if s.enableDebugLogging {
+		s.context.Trace("start", "Started solver.")
+	}
+	for {
+		isConflict := !s.propagate()
+		if !isConflict && cubeDepth > 0 && len(s.decisions) >= cubeDepth {
+			s.cubes = append(s.cubes, s.getDecisionLiterals())
+		} else if !isConflict {
+			isConsistent, literal := s.lookahead()
+			if isConsistent && literal != sat_solver.CNF_UNDEFINED {
+				s.newDecision(literal)
+				continue
+			} else if isConsistent && cubeDepth == 0 {
+				return true
+			} else if isConsistent {
+				s.cubes = append(s.cubes, s.getDecisionLiterals())
+			}
+			isConflict = !isConsistent
+		}
+
+		if isConflict && s.enableDebugLogging {
+			s.context.Trace("conflict", "Conflicting clause detected on unit propagation. Decision trace: %s.", s.getDecisionTraceString())
+		}
+		if !s.backtrack() {
+			return false
+		}
+	}
+}
+
+/*
+ * Create new decision for a given literal.
+ */
+func (s *LookaheadSolver) newDecision(literal sat_solver.CNFLiteral) {
+	if s.enableDebugLogging {
+		s.context.Trace("decide", "Create new decision for %s (%s)", literal.String(s.vars), literal.DebugString())
+	}
+	s.decisions = append(s.decisions, lookaheadDecision{
+		literal:    literal,
+		traceIndex: len(s.assignmentTrace),
+		isFlipped:  false,
+	})
+	s.assign(literal)
+}
+
+/*
+ * Go back to the last decision that was not flipped yet and assign the opposite value.
+ * The clauses learned below that decision are removed.
+ * Returns false if there is no such decision (so the search is finished).
+ */
+func (s *LookaheadSolver) backtrack() bool {
+	for len(s.decisions) > 0 {
+		decision := s.decisions[len(s.decisions) - 1]
+		s.decisions = s.decisions[:len(s.decisions) - 1]
+		s.unassignFrom(decision.traceIndex)
+		s.removeLearnedClauses(len(s.decisions))
+		if decision.isFlipped {
+			continue
+		}
+		if s.enableDebugLogging {
+			s.context.Trace("reverse", "Jumping back to getDecisionLevel %d.", len(s.decisions))
+		}
+		s.decisions = append(s.decisions, lookaheadDecision{
+			literal:    -decision.literal,
+			traceIndex: len(s.assignmentTrace),
+			isFlipped:  true,
+		})
+		s.assign(-decision.literal)
+		return true
+	}
+	return false
+}
+
+/*
+ * Get the literals of all the current decisions.
+ */
+func (s *LookaheadSolver) getDecisionLiterals() []sat_solver.CNFLiteral {
+	literals := make([]sat_solver.CNFLiteral, len(s.decisions))
+	for i, decision := range s.decisions {
+		literals[i] = decision.literal
+	}
+	return literals
+}
+
+/*
+ * Get human-readable string describing the current decision trace.
+ */
+func (s *LookaheadSolver) getDecisionTraceString() string {
+	rows := []string{}
+	decisionID := 0
+	for i, l := range s.assignmentTrace {
+		decision := ""
+		if decisionID < len(s.decisions) && s.decisions[decisionID].traceIndex == i {
+			decision = "> "
+			decisionID++
+		}
+		rows = append(rows, fmt.Sprintf("%s%s", decision, l.DebugString()))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(rows, ", "))
+}
+
+/*
+ * Save the result when we found something.
+ */
+func (s *LookaheadSolver) foundResult(result solver.SatResult) solver.SatResult {
+	if s.enableDebugLogging {
+		s.context.Trace("lookahead", "Finished after %d lookaheads (%d double lookaheads, %d learned clauses).",
+			s.lookaheadCount, s.doubleLookaheadCount, s.learnedCount)
+		s.context.Trace("result", "Found result %s.", result.String())
+	}
+	return result
+}
+
+/**
+ * Get assignments for the variables of the formula when we found SAT.
+ * Variables that do not matter are set to false.
+ */
+func (s *LookaheadSolver) getOutputVariableAssignments() map[string]bool {
+	model := make(map[sat_solver.CNFLiteral]bool)
+	for _, v := range s.vars.GetAllVariables() {
+		model[v] = s.values[v] > 0
+	}
+	return s.formula.FounderAssignment(model)
+}
@@ -0,0 +1,234 @@
+package lookahead_solver
+
+/**
+ * This file provides the clause database and unit propagation of the lookahead solver.
+ *
+ * Each clause keeps the number of its true and false literals, so the clauses that become unit or false
+ * are found by looking only at the occurrences of the assigned literal. The same counters are used to measure
+ * how much the formula is reduced by the lookahead on a literal (see lookahead.go).
+ *
+ * Binary clauses learned during the lookahead are valid only under the assignment of the node where
+ * they were found, so they are appended to the end of the database together with the decision level
+ * and removed when the search backtracks over that level.
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+/*
+ * Index of the literal in the occurrence lists
+ */
+func literalIndex(literal sat_solver.CNFLiteral) int {
+	if literal < 0 {
+		return int(-2*literal + 1)
+	}
+	return int(2*literal)
+}
+
+/*
+ * Remove constants and duplicated literals from the clause.
+ * Returns true if the clause is always satisfied.
+ */
+func simplifyClause(clause sat_solver.CNFClause) (sat_solver.CNFClause, bool) {
+	newClause := make(sat_solver.CNFClause, 0, len(clause))
+	for _, literal := range clause {
+		if literal == 1 {
+			return nil, true
+		} else if literal == -1 {
+			continue
+		}
+		isDuplicate := false
+		for _, existing := range newClause {
+			if existing == literal {
+				isDuplicate = true
+				break
+			} else if existing == -literal {
+				// Tautology is always satisfied
+				return nil, true
+			}
+		}
+		if !isDuplicate {
+			newClause = append(newClause, literal)
+		}
+	}
+	return newClause, false
+}
+
+/*
+ * Build the clause database and assign the unit clauses.
+ * Returns false if the formula was found to be UNSAT.
+ */
+func (s *LookaheadSolver) loadClauses(clauses []sat_solver.CNFClause, maxVar sat_solver.CNFLiteral) bool {
+	s.maxVar = maxVar
+	s.values = make([]int8, maxVar + 1)
+	s.occurrences = make([][]int, 2*(maxVar + 1))
+	s.impliedStamp = make([]int, 2*(maxVar + 1))
+	units := []sat_solver.CNFLiteral{}
+	maxSize := 2
+
+	for _, clause := range clauses {
+		newClause, isSatisfied := simplifyClause(clause)
+		if isSatisfied {
+			continue
+		} else if len(newClause) == 0 {
+			return false
+		} else if len(newClause) == 1 {
+			units = append(units, newClause[0])
+		}
+		if len(newClause) > maxSize {
+			maxSize = len(newClause)
+		}
+		index := len(s.clauses)
+		s.clauses = append(s.clauses, newClause)
+		for _, literal := range newClause {
+			s.occurrences[literalIndex(literal)] = append(s.occurrences[literalIndex(literal)], index)
+		}
+	}
+	s.trueCount = make([]int, len(s.clauses))
+	s.falseCount = make([]int, len(s.clauses))
+
+	// Weight of the clause with k unassigned literals is REDUCTION_WEIGHT_BASE^(k-2)
+	s.reductionWeight = make([]float64, maxSize + 1)
+	weight := 1.0
+	for size := 2; size <= maxSize; size++ {
+		s.reductionWeight[size] = weight
+		weight *= REDUCTION_WEIGHT_BASE
+	}
+
+	for _, literal := range units {
+		switch s.literalValue(literal) {
+		case -1:
+			return false
+		case 0:
+			s.assign(literal)
+		}
+	}
+	return true
+}
+
+/*
+ * Get the current value of the literal: 1 (true), -1 (false) or 0 (unassigned).
+ */
+func (s *LookaheadSolver) literalValue(literal sat_solver.CNFLiteral) int8 {
+	if literal < 0 {
+		return -s.values[-literal]
+	}
+	return s.values[literal]
+}
+
+/*
+ * Assign the literal and update the counters of the clauses.
+ * During the lookahead the weights of the clauses reduced by the assignment are added to s.reduction.
+ */
+func (s *LookaheadSolver) assign(literal sat_solver.CNFLiteral) {
+	if literal < 0 {
+		s.values[-literal] = -1
+	} else {
+		s.values[literal] = 1
+	}
+	s.assignmentTrace = append(s.assignmentTrace, literal)
+	for _, index := range s.occurrences[literalIndex(literal)] {
+		s.trueCount[index]++
+	}
+	for _, index := range s.occurrences[literalIndex(-literal)] {
+		s.falseCount[index]++
+		if s.isMeasuringReduction && s.trueCount[index] == 0 {
+			if remaining := len(s.clauses[index]) - s.falseCount[index]; remaining >= 2 {
+				s.reduction += s.reductionWeight[remaining]
+			}
+		}
+	}
+}
+
+/*
+ * Unassign all the literals on the assignmentTrace starting from the given position.
+ */
+func (s *LookaheadSolver) unassignFrom(traceIndex int) {
+	for i := len(s.assignmentTrace) - 1; i >= traceIndex; i-- {
+		literal := s.assignmentTrace[i]
+		for _, index := range s.occurrences[literalIndex(literal)] {
+			s.trueCount[index]--
+		}
+		for _, index := range s.occurrences[literalIndex(-literal)] {
+			s.falseCount[index]--
+		}
+		s.values[literal.Var()] = 0
+	}
+	s.assignmentTrace = s.assignmentTrace[:traceIndex]
+	if s.propagatedIndex > traceIndex {
+		s.propagatedIndex = traceIndex
+	}
+}
+
+/*
+ * Perform unit propagation of all the literals assigned since the last call.
+ * Returns false if some clause became false.
+ * If the propagation is a part of the lookahead on a literal, then the literals implied by the clauses
+ * with more than two literals are saved in s.learningCandidates.
+ */
+func (s *LookaheadSolver) propagate() bool {
+	for s.propagatedIndex < len(s.assignmentTrace) {
+		literal := s.assignmentTrace[s.propagatedIndex]
+		s.propagatedIndex++
+		for _, index := range s.occurrences[literalIndex(-literal)] {
+			if s.trueCount[index] > 0 {
+				continue
+			}
+			clause := s.clauses[index]
+			if s.falseCount[index] == len(clause) {
+				return false
+			} else if s.falseCount[index] == len(clause) - 1 {
+				// The only unassigned literal of the clause must be true
+				for _, l := range clause {
+					if s.literalValue(l) == 0 {
+						if s.isCollectingImplications && len(clause) > 2 {
+							s.learningCandidates = append(s.learningCandidates, l)
+						}
+						s.assign(l)
+						break
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+/*
+ * Add the binary clause learned at the current decision level.
+ * Both literals must be unassigned or true, so the clause is neither unit nor false.
+ */
+func (s *LookaheadSolver) addLearnedClause(clause sat_solver.CNFClause) {
+	index := len(s.clauses)
+	s.clauses = append(s.clauses, clause)
+	s.trueCount = append(s.trueCount, 0)
+	s.falseCount = append(s.falseCount, 0)
+	s.learnedLevels = append(s.learnedLevels, len(s.decisions))
+	for _, literal := range clause {
+		s.occurrences[literalIndex(literal)] = append(s.occurrences[literalIndex(literal)], index)
+		if s.literalValue(literal) > 0 {
+			s.trueCount[index]++
+		}
+	}
+	s.learnedCount++
+}
+
+/*
+ * Remove the learned clauses that were found above the given decision level.
+ * The clauses are removed in the reverse order of adding, so they are always at the end
+ * of the database and of the occurrence lists.
+ */
+func (s *LookaheadSolver) removeLearnedClauses(decisionLevel int) {
+	for len(s.learnedLevels) > 0 && s.learnedLevels[len(s.learnedLevels) - 1] > decisionLevel {
+		index := len(s.clauses) - 1
+		for _, literal := range s.clauses[index] {
+			occurrences := s.occurrences[literalIndex(literal)]
+			s.occurrences[literalIndex(literal)] = occurrences[:len(occurrences) - 1]
+		}
+		s.clauses = s.clauses[:index]
+		s.trueCount = s.trueCount[:index]
+		s.falseCount = s.falseCount[:index]
+		s.learnedLevels = s.learnedLevels[:len(s.learnedLevels) - 1]
+	}
+}
@@ -0,0 +1,68 @@
+package maxsat_solver
+
+/**
+ * This file implements the linear SAT-UNSAT search.
+ *
+ * After each model with the cost c is found, the constraint sum(w_i * -b_i) <= c - 1 over the selectors
+ * of the soft clauses is added (encoded with the configured pseudo-Boolean encoding) and the solver is called again.
+ * The last model is optimal when the formula becomes UNSAT or the cost reaches the lower bound.
+ */
+
+import (
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/pb"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+func (s *MaxSATSolver) linearSearch() (error, solver.SolverResult) {
+	err, encoder := pb.GetEncoder(s.context.GetConfiguration().PBEncoding)
+	if err != nil {
+		return err, solver.EmptySolverResult{}
+	}
+	// Totalizer outputs added by OLL are not used here, only the selectors of the soft clauses
+	terms := []pb.Term{}
+	for _, selector := range s.selectors {
+		if weight := s.softWeights[selector]; weight > 0 {
+			terms = append(terms, pb.Term{ Coefficient: weight, Literal: -selector })
+		}
+	}
+
+	var best solver.SolverResult = nil
+	for {
+		err, result, _ := s.solver.SolveWithAssumptions(nil)
+		if err != nil {
+			return err, solver.EmptySolverResult{}
+		}
+		if !result.IsSAT() {
+			if best == nil {
+				return nil, result
+			}
+			return nil, best
+		}
+		optimum := s.optimum(result)
+		best = optimum
+		if optimum.Cost <= s.lowerBound {
+			return nil, best
+		}
+
+		bound := &sat_solver.CNFFormula{
+			Variables: []sat_solver.CNFClause{},
+		}
+		err = pb.AddConstraint(bound, pb.Constraint{
+			Terms:      terms,
+			Comparator: pb.PB_LESS_EQUAL,
+			Bound:      optimum.Cost - s.constantCost - 1,
+		}, encoder, s.solver.NewVariable)
+		if err != nil {
+			return err, solver.EmptySolverResult{}
+		}
+		for _, c := range bound.Cardinalities {
+			bound.Variables = append(bound.Variables, c.ToCNF(s.solver.NewVariable)...)
+		}
+		for _, clause := range bound.Variables {
+			if !s.solver.AddClause(clause) {
+				return nil, best
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package maxsat_solver
+
+/**
+ * This file provides the weighted partial MaxSAT solver.
+ *
+ * Each soft clause C gets a selector literal b and the hard clause (-b v C) is added, so assuming b forces
+ * the clause to be satisfied. Unit soft clauses are their own selectors. The hard clauses and the selector
+ * clauses are loaded once into the incremental CDCL solver and all the searches are done with assumptions.
+ *
+ * Two algorithms are available (see SATConfiguration.MaxSATAlgorithm):
+ *   - "oll" (default) - core-guided search that relaxes the found cores with totalizers (see oll.go)
+ *   - "linear" - linear SAT-UNSAT search that bounds the cost of the last model (see linear.go)
+ * The core-guided search falls back to the linear search when a core is too large to relax it.
+ */
+
+import (
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/*
+ * MaxSAT solver factory
+ */
+type MaxSATSolverFactory struct {}
+
+func (msf MaxSATSolverFactory) CanSolveFormula(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
+	_, ok := formula.Formula().(*sat_solver.WeightedCNFFormula)
+	return ok
+}
+
+func (msf MaxSATSolverFactory) CreateSolver(formula *sat_solver.SATFormula, context *sat_solver.SATContext) solver.Solver {
+	return NewMaxSATSolver()
+}
+
+func (msf MaxSATSolverFactory) GetName() string {
+	return "maxsat"
+}
+
+// Register solver factory
+func init() {
+	solver.RegisterSolverFactory(MaxSATSolverFactory{})
+}
+
+const MAXSAT_ALGORITHM_OLL = "oll"
+const MAXSAT_ALGORITHM_LINEAR = "linear"
+
+/**
+ * MaxSAT solver state
+ */
+type MaxSATSolver struct {
+	context      *sat_solver.SATContext
+	formula      *sat_solver.WeightedCNFFormula
+	vars         *sat_solver.SATVariableMapping
+	// Incremental SAT solver with the hard clauses and the selector clauses loaded
+	solver       solver.IncrementalSolver
+	// Selectors of the soft clauses in the order of creation
+	selectors    []sat_solver.CNFLiteral
+	// Weight of each selector (sum of the weights of the soft clauses with the same selector)
+	softWeights  map[sat_solver.CNFLiteral]int64
+	// Remaining weights of the soft literals used by the core-guided search
+	weights      map[sat_solver.CNFLiteral]int64
+	// Cost of the empty soft clauses (they are always falsified)
+	constantCost int64
+	// No solution can be cheaper than this
+	lowerBound   int64
+}
+
+/**
+ * Create new MaxSAT solver instance
+ */
+func NewMaxSATSolver() *MaxSATSolver {
+	return &MaxSATSolver{
+		selectors:   []sat_solver.CNFLiteral{},
+		softWeights: map[sat_solver.CNFLiteral]int64{},
+		weights:     map[sat_solver.CNFLiteral]int64{},
+	}
+}
+
+/**
+ * Find the assignment that satisfies the hard constraints and minimizes the cost of the falsified soft clauses.
+ */
+func (s *MaxSATSolver) Solve(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	wf, ok := formula.Formula().(*sat_solver.WeightedCNFFormula)
+	if !ok {
+		return fmt.Errorf("MaxSAT Solver supports only weighted CNF formulas."), solver.EmptySolverResult{}
+	}
+	s.context = context
+	s.formula = wf
+	s.vars = formula.Variables()
+
+	cnf := &sat_solver.CNFFormula{
+		Variables:     append([]sat_solver.CNFClause{}, wf.Hard.Variables...),
+		Xors:          wf.Hard.Xors,
+		Cardinalities: wf.Hard.Cardinalities,
+	}
+	for _, c := range wf.Soft {
+		if c.Weight <= 0 {
+			continue
+		} else if len(c.Clause) == 0 {
+			s.constantCost += c.Weight
+			continue
+		}
+		selector := c.Clause[0]
+		if len(c.Clause) > 1 {
+			_, selector = s.vars.Fresh()
+			cnf.Variables = append(cnf.Variables, append(sat_solver.CNFClause{ -selector }, c.Clause...))
+		}
+		if _, ok := s.softWeights[selector]; !ok {
+			s.selectors = append(s.selectors, selector)
+		}
+		s.softWeights[selector] += c.Weight
+	}
+	for selector, weight := range s.softWeights {
+		s.weights[selector] = weight
+	}
+	s.lowerBound = s.constantCost
+
+	err, incrementalSolver := solver.CreateIncrementalSolver(solver.DEFAULT_SOLVER_NAME, sat_solver.NewSATFormula(cnf, s.vars, nil), context)
+	if err != nil {
+		return err, solver.EmptySolverResult{}
+	}
+	s.solver = incrementalSolver
+	for _, selector := range s.selectors {
+		s.solver.FreezeVariable(selector)
+	}
+
+	algorithm := context.GetConfiguration().MaxSATAlgorithm
+	switch algorithm {
+	case MAXSAT_ALGORITHM_LINEAR:
+		return s.linearSearch()
+	case MAXSAT_ALGORITHM_OLL, "":
+		return s.oll()
+	}
+	return fmt.Errorf("Unknown MaxSAT algorithm '%s'.", algorithm), solver.EmptySolverResult{}
+}
+
+/**
+ * Compute the cost of the model found by the SAT solver using the original soft clauses.
+ */
+func (s *MaxSATSolver) cost(result solver.SolverResult) int64 {
+	assignment := result.GetSatisfyingAssignment()
+	return s.formula.Cost(func(literal sat_solver.CNFLiteral) bool {
+		return assignment[s.vars.Reverse(literal.Var())] == (literal > 0)
+	})
+}
+
+/**
+ * Wrap the model into the result with its cost.
+ */
+func (s *MaxSATSolver) optimum(result solver.SolverResult) solver.OptimumSolverResult {
+	cost := s.cost(result)
+	if s.context.IsSolverTracingEnabled() {
+		s.context.Trace("maxsat", "Found solution with cost %d (lower bound is %d).", cost, s.lowerBound)
+	}
+	return solver.OptimumSolverResult{
+		SolverResult: result,
+		Cost:         cost,
+	}
+}
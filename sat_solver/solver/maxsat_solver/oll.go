@@ -0,0 +1,200 @@
+package maxsat_solver
+
+/**
+ * This file implements the core-guided OLL algorithm.
+ *
+ * The solver is called with all the selectors that still have a positive weight as assumptions.
+ * If the result is UNSAT, then the failed assumptions form a core: at least one of them must be false.
+ * The minimal weight of the core is added to the lower bound and subtracted from the weights of the core.
+ * The core is relaxed with a totalizer over the negations of its literals, whose output o_k is true
+ * if at least k of the core literals are false. Then -o_2 becomes a new soft literal with the minimal weight.
+ * When -o_k is found in a later core, -o_(k+1) is added in the same way.
+ *
+ * The soft literals are stratified by their weights: only the literals with the weight not smaller than the current
+ * stratum are assumed, so the cores with heavy literals are found first. When the solver finds a model,
+ * then the stratum is lowered. The model found with all the soft literals assumed is optimal,
+ * because its cost is equal to the lower bound.
+ * Each core is trimmed by solving again with the core as the assumptions, because smaller cores give
+ * smaller totalizers and better lower bounds.
+ *
+ * For more details please see the paper by Morgado, Dodaro and Marques-Silva:
+ *   "Core-Guided MaxSAT with Soft Cardinality Constraints" (CP 2014)
+ */
+
+import (
+	"math"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+// Cores with more literals than this are not relaxed with totalizers, the linear search is used instead
+const OLL_MAX_CORE_SIZE = 1000
+
+// Maximal number of the additional solver calls used to trim a single core
+const OLL_CORE_TRIM_ROUNDS = 3
+
+/*
+ * Soft literal created from the totalizer output: -outputs[index]
+ */
+type totalizerOutput struct {
+	outputs []sat_solver.CNFLiteral
+	index   int
+}
+
+func (s *MaxSATSolver) oll() (error, solver.SolverResult) {
+	totalizerOutputs := map[sat_solver.CNFLiteral]totalizerOutput{}
+	var best *solver.OptimumSolverResult = nil
+	stratum := s.nextStratum(math.MaxInt64)
+	for {
+		assumptions := []sat_solver.CNFLiteral{}
+		for _, selector := range s.selectors {
+			if s.weights[selector] > 0 && s.weights[selector] >= stratum {
+				assumptions = append(assumptions, selector)
+			}
+		}
+		err, result, core := s.solver.SolveWithAssumptions(assumptions)
+		if err != nil {
+			return err, solver.EmptySolverResult{}
+		}
+		if result.IsSAT() {
+			optimum := s.optimum(result)
+			if best == nil || optimum.Cost < best.Cost {
+				best = &optimum
+			}
+			stratum = s.nextStratum(stratum)
+			if best.Cost <= s.lowerBound || stratum == 0 {
+				return nil, *best
+			}
+			if s.context.IsSolverTracingEnabled() {
+				s.context.Trace("maxsat", "Lowered the stratum to %d.", stratum)
+			}
+			continue
+		} else if !result.IsUNSAT() || len(core) == 0 {
+			// Hard clauses are UNSAT or the search was not finished
+			return nil, result
+		}
+		err, core = s.trimCore(core)
+		if err != nil {
+			return err, solver.EmptySolverResult{}
+		}
+		if len(core) > OLL_MAX_CORE_SIZE {
+			if s.context.IsSolverTracingEnabled() {
+				s.context.Trace("maxsat", "Core of size %d is too large, switching to the linear search.", len(core))
+			}
+			return s.linearSearch()
+		}
+
+		minWeight := s.weights[core[0]]
+		for _, literal := range core[1:] {
+			if s.weights[literal] < minWeight {
+				minWeight = s.weights[literal]
+			}
+		}
+		s.lowerBound += minWeight
+		if s.context.IsSolverTracingEnabled() {
+			s.context.Trace("maxsat", "Found core of size %d with weight %d (lower bound is %d).", len(core), minWeight, s.lowerBound)
+		}
+
+		for _, literal := range core {
+			s.weights[literal] -= minWeight
+			if output, ok := totalizerOutputs[literal]; ok && output.index + 1 < len(output.outputs) {
+				next := -output.outputs[output.index + 1]
+				totalizerOutputs[next] = totalizerOutput{
+					outputs: output.outputs,
+					index:   output.index + 1,
+				}
+				s.addSoftLiteral(next, minWeight)
+			}
+		}
+		if len(core) > 1 {
+			inputs := make([]sat_solver.CNFLiteral, len(core))
+			for i, literal := range core {
+				inputs[i] = -literal
+			}
+			outputs := s.buildTotalizer(inputs)
+			if outputs == nil {
+				return nil, solver.SolverQuickUnsatResult{}
+			}
+			next := -outputs[1]
+			totalizerOutputs[next] = totalizerOutput{
+				outputs: outputs,
+				index:   1,
+			}
+			s.addSoftLiteral(next, minWeight)
+		}
+	}
+}
+
+/*
+ * Get the largest positive weight of the soft literals that is smaller than the given stratum.
+ * Returns 0 if there is no such weight.
+ */
+func (s *MaxSATSolver) nextStratum(stratum int64) int64 {
+	next := int64(0)
+	for _, selector := range s.selectors {
+		if weight := s.weights[selector]; weight < stratum && weight > next {
+			next = weight
+		}
+	}
+	return next
+}
+
+/*
+ * Try to find a smaller core by solving with the core as the assumptions.
+ */
+func (s *MaxSATSolver) trimCore(core []sat_solver.CNFLiteral) (error, []sat_solver.CNFLiteral) {
+	for i := 0; i < OLL_CORE_TRIM_ROUNDS && len(core) > 2; i++ {
+		err, result, smallerCore := s.solver.SolveWithAssumptions(core)
+		if err != nil {
+			return err, nil
+		}
+		if !result.IsUNSAT() || len(smallerCore) == 0 || len(smallerCore) >= len(core) {
+			break
+		}
+		core = smallerCore
+	}
+	return nil, core
+}
+
+func (s *MaxSATSolver) addSoftLiteral(literal sat_solver.CNFLiteral, weight int64) {
+	s.solver.FreezeVariable(literal)
+	if _, ok := s.weights[literal]; !ok {
+		s.selectors = append(s.selectors, literal)
+	}
+	s.weights[literal] += weight
+}
+
+/**
+ * Build the totalizer over the inputs. The output i is true if at least i+1 inputs are true.
+ * Only this direction is encoded, because the outputs are used only negatively.
+ * Returns nil if adding the clauses made the formula UNSAT.
+ */
+func (s *MaxSATSolver) buildTotalizer(inputs []sat_solver.CNFLiteral) []sat_solver.CNFLiteral {
+	if len(inputs) == 1 {
+		return inputs
+	}
+	left := s.buildTotalizer(inputs[:len(inputs)/2])
+	right := s.buildTotalizer(inputs[len(inputs)/2:])
+	if left == nil || right == nil {
+		return nil
+	}
+	outputs := make([]sat_solver.CNFLiteral, len(inputs))
+	for i := range outputs {
+		outputs[i] = s.solver.NewVariable()
+	}
+	ok := true
+	for i, l := range left {
+		ok = ok && s.solver.AddClause(sat_solver.CNFClause{ -l, outputs[i] })
+		for j, r := range right {
+			ok = ok && s.solver.AddClause(sat_solver.CNFClause{ -l, -r, outputs[i+j+1] })
+		}
+	}
+	for j, r := range right {
+		ok = ok && s.solver.AddClause(sat_solver.CNFClause{ -r, outputs[j] })
+	}
+	if !ok {
+		return nil
+	}
+	return outputs
+}
@@ -113,7 +113,9 @@ func (result SatResult) GetSatisfyingAssignment() map[string]bool {
 type NaiveSolverFactory struct {}
 
 func (nsf NaiveSolverFactory) CanSolveFormula(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
-	return true
+	// Soft clauses of the weighted formulas would be treated as hard ones
+	_, isWeighted := formula.Formula().(*sat_solver.WeightedCNFFormula)
+	return !isWeighted
 }
 
 func (nsf NaiveSolverFactory) CreateSolver(formula *sat_solver.SATFormula, context *sat_solver.SATContext) solv.Solver {
@@ -152,23 +154,19 @@ func (solver *NaiveSolver) Solve(formula *sat_solver.SATFormula, context *sat_so
 	iterCount := int64(math.Exp2(float64(varCount)))
 	for i := int64(0); i < iterCount; i++ {
 		for j := int64(0); j < varCount; j++ {
-			vars[j] = (int64(1) >> uint64(j)) & values != 0
+			vars[j] = (values >> uint64(j)) & 1 != 0
 		}
 		//
 		// Evaluate formula if it's true then we print the result
 		//
 		if formula.Evaluate(vars) {
-			formulaVars := formula.Variables()
-			result := map[string]bool{}
+			model := map[sat_solver.CNFLiteral]bool{}
 			for k, v := range vars {
-				// If the variable was introduced later during optimizations we discard it
-				if formulaVars.IsFounderVariable(sat_solver.CNFLiteral(k+1)) {
-					result[formulaVars.Reverse(sat_solver.CNFLiteral(k+1))] = v
-				}
+				model[sat_solver.CNFLiteral(k+1)] = v
 			}
 			return nil, SatResult{
 				resultType: SAT_RESULT_SAT,
-				assgn:      result,
+				assgn:      formula.FounderAssignment(model),
 			}
 		}
 		values++
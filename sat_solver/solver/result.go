@@ -0,0 +1,132 @@
+package solver
+
+/**
+ * Result of the execution of the solver, shared by the solvers that do not report any statistics.
+ * The undefined result is returned by the incomplete solvers (for example the local search)
+ * when they gave up before finding the solution.
+ */
+type SatResult struct {
+	// Type of the result
+	resultType SatResultType
+	// Optionally a variables' assignment leading to SAT
+	assgn map[string]bool
+}
+
+// Type of the SAT result
+type SatResultType int8
+
+const (
+	// Solution was not found
+	SAT_RESULT_UNDEFINED  SatResultType  = 0
+	// Formula cannot be satisfied
+	SAT_RESULT_UNSAT      SatResultType  = 1
+	// Formula can be satisified
+	SAT_RESULT_SAT        SatResultType  = 2
+)
+
+/*
+ * Return human readable representation of the result
+ */
+func (result SatResult) String() string {
+	switch result.resultType {
+	case SAT_RESULT_UNDEFINED:
+		return "Undefined"
+	case SAT_RESULT_SAT:
+		return "SAT"
+	case SAT_RESULT_UNSAT:
+		return "UNSAT"
+	}
+	return "Undefined"
+}
+
+/*
+ * Return human readable representation of the result
+ */
+func (result SatResult) Brief() string {
+	return result.String()
+}
+
+/**
+ * Convert result to boolean (SAT = true, anything other = false)
+ */
+func (result SatResult) ToBool() bool {
+	return result.resultType == SAT_RESULT_SAT
+}
+
+/**
+ * Convert result to integer (SAT = 1, UNSAT = 0, undefined = -1)
+ */
+func (result SatResult) ToInt() int {
+	return ResultToInt(result)
+}
+
+/**
+ * Check if result is undefined
+ */
+func (result SatResult) IsUndefined() bool {
+	return result.resultType == SAT_RESULT_UNDEFINED
+}
+
+/**
+ * Check if result is SAT
+ */
+func (result SatResult) IsSAT() bool {
+	return result.resultType == SAT_RESULT_SAT
+}
+
+/**
+ * Check if result is USNAT
+ */
+func (result SatResult) IsUNSAT() bool {
+	return result.resultType == SAT_RESULT_UNSAT
+}
+
+/**
+ * Get assignment that leads to SAT.
+ */
+func (result SatResult) GetSatisfyingAssignment() map[string]bool {
+	return result.assgn
+}
+
+/**
+ * Create new UNDEFINED result
+ */
+func SatResultUndefined() SatResult {
+	return SatResult{
+		resultType: SAT_RESULT_UNDEFINED,
+		assgn:      map[string]bool{},
+	}
+}
+
+/**
+ * Create new UNSAT result
+ */
+func SatResultUnsat() SatResult {
+	return SatResult{
+		resultType: SAT_RESULT_UNSAT,
+		assgn:      map[string]bool{},
+	}
+}
+
+/**
+ * Create new SAT result
+ */
+func SatResultSat(assignment map[string]bool) SatResult {
+	return SatResult{
+		resultType: SAT_RESULT_SAT,
+		assgn:      assignment,
+	}
+}
+
+/**
+ * Convert any result to the integer printed by the command line tools.
+ * The undefined results are reported as -1, so they are not mistaken for UNSAT.
+ */
+func ResultToInt(result SolverResult) int {
+	if result.IsSAT() {
+		return 1
+	} else if result.IsUndefined() {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,408 @@
+package sls_solver
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+)
+
+const SLS_ALGORITHM_PROBSAT = "probsat"
+const SLS_ALGORITHM_WALKSAT = "walksat"
+
+// Default noise of WalkSAT: probability of flipping a random variable of the false clause
+const WALKSAT_DEFAULT_NOISE = 0.567
+
+// Epsilon used by the polynomial ProbSAT break function (eps + break)^-cb
+const PROBSAT_POLY_EPS = 1.0
+
+// Break values with precomputed ProbSAT probabilities
+const PROBSAT_MAX_CACHED_BREAK = 64
+
+/**
+ * State of the local search over the CNF clauses.
+ *
+ * For each clause the number of its true literals is kept. If exactly one literal is true, then the clause
+ * remembers its variable, because flipping that variable would break the clause. The break score of each
+ * variable (number of the clauses that become false after flipping it) is updated after each flip.
+ * The make score (number of the false clauses that become true) is computed only when it's needed.
+ * The false clauses are kept in an array together with the position of each clause, so they can be added,
+ * removed and picked at random in constant time.
+ */
+type LocalSearch struct {
+	clauses        []sat_solver.CNFClause
+	// Clauses that contain the literal (see literalIndex)
+	occurrences    [][]int
+	// Current value of each variable
+	assignment     []bool
+	trueCount      []int
+	// The only true variable of the clause if its trueCount is 1
+	criticalVar    []sat_solver.CNFLiteral
+	breakScore     []int
+	falseClauses   []int
+	// Position of the clause in falseClauses or -1 if the clause is true
+	falsePosition  []int
+	// Length of the longest clause (used to pick the ProbSAT parameters)
+	maxClauseSize  int
+	// Set if one of the clauses has no literals
+	isUnsat        bool
+	rng            *rand.Rand
+	// ProbSAT probabilities for the small break values
+	probabilities  []float64
+	// Buffer for the probabilities of the literals of the picked clause
+	weights        []float64
+	// Assignment with the smallest number of the false clauses found by the last search
+	bestAssignment []bool
+	bestFalseCount int
+	// Total number of the flips done so far
+	flips          int64
+}
+
+/**
+ * Create the local search state for the clauses over variables 2..maxVar.
+ * Constant literals and duplicated literals are removed and tautologies are skipped.
+ */
+func NewLocalSearch(clauses []sat_solver.CNFClause, maxVar sat_solver.CNFLiteral, seed int64) *LocalSearch {
+	ls := &LocalSearch{
+		clauses:     []sat_solver.CNFClause{},
+		occurrences: make([][]int, 2*(maxVar+1)),
+		assignment:  make([]bool, maxVar+1),
+		breakScore:  make([]int, maxVar+1),
+		rng:         rand.New(rand.NewSource(seed)),
+	}
+	for _, clause := range clauses {
+		newClause, isSatisfied := simplifyClause(clause)
+		if isSatisfied {
+			continue
+		}
+		if len(newClause) == 0 {
+			ls.isUnsat = true
+			continue
+		}
+		if len(newClause) > ls.maxClauseSize {
+			ls.maxClauseSize = len(newClause)
+		}
+		for _, literal := range newClause {
+			index := literalIndex(literal)
+			ls.occurrences[index] = append(ls.occurrences[index], len(ls.clauses))
+		}
+		ls.clauses = append(ls.clauses, newClause)
+	}
+	ls.trueCount = make([]int, len(ls.clauses))
+	ls.criticalVar = make([]sat_solver.CNFLiteral, len(ls.clauses))
+	ls.falsePosition = make([]int, len(ls.clauses))
+	return ls
+}
+
+/*
+ * Remove constants and duplicated literals from the clause.
+ * Returns true if the clause is always satisfied.
+ */
+func simplifyClause(clause sat_solver.CNFClause) (sat_solver.CNFClause, bool) {
+	newClause := sat_solver.CNFClause{}
+	seen := map[sat_solver.CNFLiteral]bool{}
+	for _, literal := range clause {
+		if literal == 1 || seen[-literal] {
+			return nil, true
+		} else if literal == -1 || seen[literal] {
+			continue
+		}
+		seen[literal] = true
+		newClause = append(newClause, literal)
+	}
+	return newClause, false
+}
+
+/*
+ * Index of the literal in the occurrences array
+ */
+func literalIndex(literal sat_solver.CNFLiteral) int {
+	if literal < 0 {
+		return int(-2*literal + 1)
+	}
+	return int(2*literal)
+}
+
+/**
+ * Returns true if one of the clauses cannot be satisfied by any assignment.
+ */
+func (ls *LocalSearch) IsUNSAT() bool {
+	return ls.isUnsat
+}
+
+/**
+ * Current value of the variable.
+ */
+func (ls *LocalSearch) Value(v sat_solver.CNFLiteral) bool {
+	return ls.assignment[v]
+}
+
+/**
+ * Number of the clauses that are false under the current assignment.
+ */
+func (ls *LocalSearch) FalseClauseCount() int {
+	return len(ls.falseClauses)
+}
+
+/**
+ * Total number of the flips done so far.
+ */
+func (ls *LocalSearch) Flips() int64 {
+	return ls.flips
+}
+
+/**
+ * Assign random values to all the variables.
+ */
+func (ls *LocalSearch) Randomize() {
+	for v := range ls.assignment {
+		ls.assignment[v] = ls.rng.Intn(2) == 1
+	}
+	ls.recompute()
+}
+
+/**
+ * Set the values of all the variables (indexed by the variable IDs).
+ */
+func (ls *LocalSearch) SetAssignment(assignment []bool) {
+	copy(ls.assignment, assignment)
+	ls.recompute()
+}
+
+/**
+ * Get the assignment with the smallest number of the false clauses found by the last search
+ * and the number of its false clauses.
+ */
+func (ls *LocalSearch) BestAssignment() ([]bool, int) {
+	return ls.bestAssignment, ls.bestFalseCount
+}
+
+/*
+ * Compute the counters of the clauses and the break scores from scratch.
+ */
+func (ls *LocalSearch) recompute() {
+	for v := range ls.breakScore {
+		ls.breakScore[v] = 0
+	}
+	ls.falseClauses = ls.falseClauses[:0]
+	for i, clause := range ls.clauses {
+		ls.trueCount[i] = 0
+		ls.falsePosition[i] = -1
+		for _, literal := range clause {
+			if ls.isTrue(literal) {
+				ls.trueCount[i]++
+				ls.criticalVar[i] = literal.Var()
+			}
+		}
+		if ls.trueCount[i] == 0 {
+			ls.addFalseClause(i)
+		} else if ls.trueCount[i] == 1 {
+			ls.breakScore[ls.criticalVar[i]]++
+		}
+	}
+}
+
+func (ls *LocalSearch) isTrue(literal sat_solver.CNFLiteral) bool {
+	return ls.assignment[literal.Var()] == (literal > 0)
+}
+
+func (ls *LocalSearch) addFalseClause(clause int) {
+	ls.falsePosition[clause] = len(ls.falseClauses)
+	ls.falseClauses = append(ls.falseClauses, clause)
+}
+
+func (ls *LocalSearch) removeFalseClause(clause int) {
+	position := ls.falsePosition[clause]
+	last := ls.falseClauses[len(ls.falseClauses)-1]
+	ls.falseClauses[position] = last
+	ls.falsePosition[last] = position
+	ls.falseClauses = ls.falseClauses[:len(ls.falseClauses)-1]
+	ls.falsePosition[clause] = -1
+}
+
+/**
+ * Flip the value of the variable and update the clause counters and the break scores.
+ */
+func (ls *LocalSearch) Flip(v sat_solver.CNFLiteral) {
+	ls.flips++
+	ls.assignment[v] = !ls.assignment[v]
+	madeTrue, madeFalse := v, -v
+	if !ls.assignment[v] {
+		madeTrue, madeFalse = -v, v
+	}
+	for _, clause := range ls.occurrences[literalIndex(madeTrue)] {
+		ls.trueCount[clause]++
+		if ls.trueCount[clause] == 1 {
+			ls.removeFalseClause(clause)
+			ls.criticalVar[clause] = v
+			ls.breakScore[v]++
+		} else if ls.trueCount[clause] == 2 {
+			ls.breakScore[ls.criticalVar[clause]]--
+		}
+	}
+	for _, clause := range ls.occurrences[literalIndex(madeFalse)] {
+		ls.trueCount[clause]--
+		if ls.trueCount[clause] == 0 {
+			ls.addFalseClause(clause)
+			ls.breakScore[v]--
+		} else if ls.trueCount[clause] == 1 {
+			for _, literal := range ls.clauses[clause] {
+				if ls.isTrue(literal) {
+					ls.criticalVar[clause] = literal.Var()
+					break
+				}
+			}
+			ls.breakScore[ls.criticalVar[clause]]++
+		}
+	}
+}
+
+/**
+ * Number of the false clauses that become true after flipping the variable.
+ */
+func (ls *LocalSearch) makeScore(v sat_solver.CNFLiteral) int {
+	madeTrue := v
+	if ls.assignment[v] {
+		madeTrue = -v
+	}
+	score := 0
+	for _, clause := range ls.occurrences[literalIndex(madeTrue)] {
+		if ls.trueCount[clause] == 0 {
+			score++
+		}
+	}
+	return score
+}
+
+/**
+ * Run the search from the current assignment until all the clauses are true or maxFlips flips are done.
+ * Returns true if a satisfying assignment was found. The best assignment seen during the search is remembered
+ * (see BestAssignment()).
+ */
+func (ls *LocalSearch) Search(algorithm string, noise float64, maxFlips int64) bool {
+	if algorithm == SLS_ALGORITHM_PROBSAT {
+		ls.initProbabilities(noise)
+	}
+	ls.bestAssignment = append(ls.bestAssignment[:0], ls.assignment...)
+	ls.bestFalseCount = len(ls.falseClauses)
+	for i := int64(0); i < maxFlips; i++ {
+		if len(ls.falseClauses) < ls.bestFalseCount {
+			copy(ls.bestAssignment, ls.assignment)
+			ls.bestFalseCount = len(ls.falseClauses)
+		}
+		if len(ls.falseClauses) == 0 {
+			return true
+		}
+		clause := ls.falseClauses[ls.rng.Intn(len(ls.falseClauses))]
+		if algorithm == SLS_ALGORITHM_WALKSAT {
+			ls.Flip(ls.pickWalkSAT(clause, noise))
+		} else {
+			ls.Flip(ls.pickProbSAT(clause))
+		}
+	}
+	if len(ls.falseClauses) < ls.bestFalseCount {
+		copy(ls.bestAssignment, ls.assignment)
+		ls.bestFalseCount = len(ls.falseClauses)
+	}
+	return len(ls.falseClauses) == 0
+}
+
+/*
+ * Precompute the ProbSAT probabilities for the small break values.
+ * The polynomial break function is used for 3-SAT and the exponential one for the longer clauses.
+ * If the noise is 0 then the cb parameter recommended by Balint and Schöning for the clause length is used.
+ */
+func (ls *LocalSearch) initProbabilities(cb float64) {
+	polynomial := ls.maxClauseSize <= 3
+	if cb <= 0 {
+		cb = defaultProbSATBase(ls.maxClauseSize)
+	}
+	ls.probabilities = make([]float64, PROBSAT_MAX_CACHED_BREAK)
+	for b := range ls.probabilities {
+		if polynomial {
+			ls.probabilities[b] = math.Pow(PROBSAT_POLY_EPS + float64(b), -cb)
+		} else {
+			ls.probabilities[b] = math.Pow(cb, -float64(b))
+		}
+	}
+}
+
+func defaultProbSATBase(maxClauseSize int) float64 {
+	switch {
+	case maxClauseSize <= 3:
+		return 2.38
+	case maxClauseSize == 4:
+		return 3.0
+	case maxClauseSize == 5:
+		return 3.7
+	case maxClauseSize == 6:
+		return 5.1
+	}
+	return 5.4
+}
+
+/*
+ * ProbSAT: pick the variable of the clause with the probability proportional to f(break).
+ */
+func (ls *LocalSearch) pickProbSAT(clause int) sat_solver.CNFLiteral {
+	literals := ls.clauses[clause]
+	sum := 0.0
+	weights := ls.weights[:0]
+	for i, literal := range literals {
+		b := ls.breakScore[literal.Var()]
+		// Very large break values have negligible probabilities anyway
+		if b >= len(ls.probabilities) {
+			b = len(ls.probabilities) - 1
+		}
+		weights = append(weights, ls.probabilities[b])
+		sum += weights[i]
+	}
+	ls.weights = weights
+	r := ls.rng.Float64() * sum
+	for i, literal := range literals {
+		r -= weights[i]
+		if r <= 0 {
+			return literal.Var()
+		}
+	}
+	return literals[len(literals)-1].Var()
+}
+
+/*
+ * WalkSAT (SKC variant): flip a variable with break score 0 if there is one.
+ * Otherwise with the probability equal to noise flip a random variable of the clause, or else
+ * flip the variable with the smallest break score (ties are broken by the largest make score).
+ */
+func (ls *LocalSearch) pickWalkSAT(clause int, noise float64) sat_solver.CNFLiteral {
+	literals := ls.clauses[clause]
+	best := []sat_solver.CNFLiteral{}
+	bestBreak := -1
+	for _, literal := range literals {
+		v := literal.Var()
+		b := ls.breakScore[v]
+		if bestBreak == -1 || b < bestBreak {
+			best = append(best[:0], v)
+			bestBreak = b
+		} else if b == bestBreak {
+			best = append(best, v)
+		}
+	}
+	if bestBreak > 0 && ls.rng.Float64() < noise {
+		return literals[ls.rng.Intn(len(literals))].Var()
+	}
+	if len(best) > 1 && bestBreak > 0 {
+		candidates := []sat_solver.CNFLiteral{}
+		bestMake := -1
+		for _, v := range best {
+			m := ls.makeScore(v)
+			if m > bestMake {
+				candidates = append(candidates[:0], v)
+				bestMake = m
+			} else if m == bestMake {
+				candidates = append(candidates, v)
+			}
+		}
+		best = candidates
+	}
+	return best[ls.rng.Intn(len(best))]
+}
@@ -0,0 +1,198 @@
+package sls_solver
+
+/**
+ * This file provides the stochastic local search solver.
+ *
+ * The search starts from a random assignment. In each step a random false clause is picked and one of its
+ * variables is flipped. The variable is chosen using its break score (number of the clauses that become false)
+ * and make score (number of the false clauses that become true). Two algorithms are available
+ * (see SATConfiguration.SLSAlgorithm):
+ *   - "probsat" (default) - picks the variable with the probability that decreases with its break score
+ *       (Balint, Schöning: "Choosing Probability Distributions for Stochastic Local Search and the Role
+ *       of Make versus Break", SAT 2012)
+ *   - "walksat" - WalkSAT/SKC, which flips a random variable with the probability equal to the noise
+ *       and the variable with the smallest break score otherwise
+ *       (Selman, Kautz, Cohen: "Noise Strategies for Improving Local Search", AAAI 1994)
+ * After SLSMaxFlips flips without finding a model the search is restarted from a new random assignment.
+ * If all the restarts fail, then the result is UNDEFINED: local search cannot prove that the formula is UNSAT.
+ *
+ * XOR and cardinality constraints are encoded as clauses before the search starts.
+ */
+
+import (
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/*
+ * SLS solver factory
+ */
+type SLSSolverFactory struct {}
+
+func (slssf SLSSolverFactory) CanSolveFormula(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
+	_, ok := formula.Formula().(*sat_solver.CNFFormula)
+	return ok
+}
+
+func (slssf SLSSolverFactory) CreateSolver(formula *sat_solver.SATFormula, context *sat_solver.SATContext) solver.Solver {
+	return NewSLSSolver()
+}
+
+func (slssf SLSSolverFactory) GetName() string {
+	return "sls"
+}
+
+// Register solver factory
+func init() {
+	solver.RegisterSolverFactory(SLSSolverFactory{})
+}
+
+// Number of flips before the restart used when the configuration does not specify it
+const SLS_DEFAULT_MAX_FLIPS = 1000000
+
+/**
+ * SLS solver state
+ */
+type SLSSolver struct {
+	context *sat_solver.SATContext
+	formula *sat_solver.SATFormula
+	search  *LocalSearch
+}
+
+/**
+ * Create new SLS solver instance
+ */
+func NewSLSSolver() *SLSSolver {
+	return &SLSSolver{}
+}
+
+/**
+ * Solve sat formula
+ */
+func (s *SLSSolver) Solve(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	f, ok := formula.Formula().(*sat_solver.CNFFormula)
+	if !ok {
+		return fmt.Errorf("SLS Solver supports only CNF formulas."), solver.SatResultUndefined()
+	}
+	s.context = context
+	s.formula = formula
+
+	conf := context.GetConfiguration()
+	err, algorithm, noise := GetAlgorithmParameters(conf)
+	if err != nil {
+		return err, solver.SatResultUndefined()
+	}
+	maxFlips := conf.SLSMaxFlips
+	if maxFlips <= 0 {
+		maxFlips = SLS_DEFAULT_MAX_FLIPS
+	}
+
+	clauses, maxVar := s.clauses(f)
+	s.search = NewLocalSearch(clauses, maxVar, conf.SLSSeed)
+	if s.search.IsUNSAT() {
+		return nil, solver.SatResultUnsat()
+	}
+	for try := 0; try <= conf.SLSRestarts; try++ {
+		s.search.Randomize()
+		if s.search.Search(algorithm, noise, maxFlips) {
+			if context.IsSolverTracingEnabled() {
+				context.Trace("sls", "Found model after %d flips and %d restarts.", s.search.Flips(), try)
+			}
+			return nil, solver.SatResultSat(s.getOutputVariableAssignments())
+		}
+		if context.IsSolverTracingEnabled() {
+			context.Trace("sls", "Restart %d: %d clauses are false after %d flips.", try, s.search.FalseClauseCount(), s.search.Flips())
+		}
+	}
+	return nil, solver.SatResultUndefined()
+}
+
+/**
+ * Get the SLS algorithm and its noise from the configuration. The defaults are used for the empty values.
+ */
+func GetAlgorithmParameters(conf *sat_solver.SATConfiguration) (error, string, float64) {
+	algorithm := conf.SLSAlgorithm
+	switch algorithm {
+	case "":
+		algorithm = SLS_ALGORITHM_PROBSAT
+	case SLS_ALGORITHM_PROBSAT, SLS_ALGORITHM_WALKSAT:
+	default:
+		return fmt.Errorf("Unknown SLS algorithm '%s'.", algorithm), "", 0
+	}
+	noise := conf.SLSNoise
+	if noise <= 0 && algorithm == SLS_ALGORITHM_WALKSAT {
+		noise = WALKSAT_DEFAULT_NOISE
+	}
+	return nil, algorithm, noise
+}
+
+/*
+ * Get the clauses of the formula with the XOR and cardinality constraints encoded as clauses
+ * and the largest variable that occurs in them.
+ * Fresh variables of the encodings are numbered after the largest variable of the formula.
+ */
+func (s *SLSSolver) clauses(f *sat_solver.CNFFormula) ([]sat_solver.CNFClause, sat_solver.CNFLiteral) {
+	freshID := s.maxVariable(f)
+	if len(f.Xors) == 0 && len(f.Cardinalities) == 0 {
+		return f.Variables, freshID
+	}
+	fresh := func() sat_solver.CNFLiteral {
+		freshID++
+		return freshID
+	}
+	clauses := append([]sat_solver.CNFClause{}, f.Variables...)
+	for _, xor := range f.Xors {
+		clauses = append(clauses, xor.ToCNF(fresh)...)
+	}
+	for _, c := range f.Cardinalities {
+		clauses = append(clauses, c.ToCNF(fresh)...)
+	}
+	return clauses, freshID
+}
+
+/*
+ * Get the largest variable of the formula.
+ */
+func (s *SLSSolver) maxVariable(f *sat_solver.CNFFormula) sat_solver.CNFLiteral {
+	maxVar := sat_solver.CNFLiteral(1)
+	for _, v := range s.formula.Variables().GetAllVariables() {
+		if v > maxVar {
+			maxVar = v
+		}
+	}
+	for _, clause := range f.Variables {
+		for _, literal := range clause {
+			if literal.Var() > maxVar {
+				maxVar = literal.Var()
+			}
+		}
+	}
+	for _, xor := range f.Xors {
+		for _, v := range xor.Vars {
+			if v > maxVar {
+				maxVar = v
+			}
+		}
+	}
+	for _, c := range f.Cardinalities {
+		for _, literal := range c.Literals {
+			if literal.Var() > maxVar {
+				maxVar = literal.Var()
+			}
+		}
+	}
+	return maxVar
+}
+
+/**
+ * Get assignments for the variables of the formula when we found SAT.
+ */
+func (s *SLSSolver) getOutputVariableAssignments() map[string]bool {
+	model := make(map[sat_solver.CNFLiteral]bool)
+	for _, v := range s.formula.Variables().GetAllVariables() {
+		model[v] = s.search.Value(v)
+	}
+	return s.formula.FounderAssignment(model)
+}
@@ -15,6 +15,25 @@ type Solver interface {
 	Solve(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, SolverResult)
 }
 
+/**
+ * Solver that can solve the same formula many times under different assumptions.
+ * New clauses and variables can be added between the calls.
+ */
+type IncrementalSolver interface {
+	Solver
+	// Load the formula without solving it
+	Load(formula *sat_solver.SATFormula, context *sat_solver.SATContext) error
+	// Solve under the assumptions. For UNSAT results the failed subset of the assumptions is returned.
+	SolveWithAssumptions(assumptions []sat_solver.CNFLiteral) (error, SolverResult, []sat_solver.CNFLiteral)
+	// Add clause to the formula, returns false if the formula became UNSAT
+	AddClause(clause sat_solver.CNFClause) bool
+	NewVariable() sat_solver.CNFLiteral
+	// Create new variable with the given name, so it's included in the satisfying assignments
+	NewNamedVariable(name string) sat_solver.CNFLiteral
+	// Prevent the variable from being removed by the simplifications
+	FreezeVariable(v sat_solver.CNFLiteral)
+}
+
 /**
  * Solver result representation
  */
@@ -29,17 +48,16 @@ type SolverResult interface {
 	IsUndefined() bool
 }
 
+/**
+ * Result with the counters of the search, for example the number of conflicts.
+ */
+type StatisticsSolverResult interface {
+	GetStatistics() map[string]int64
+}
+
 func GetSolverResultSatisfyingAssignmentString(result SolverResult) string {
 	if result.IsSAT() {
-		assgn := result.GetSatisfyingAssignment()
-		rows := make([]string, len(assgn))
-		i := 0
-		for k, v := range assgn {
-			rows[i] = fmt.Sprintf("\t| %s  =>  %t", k, v)
-			i++
-		}
-		sort.Strings(rows)
-		return fmt.Sprintf("SATAssignment:\n%s", strings.Join(rows, "\n"))
+		return fmt.Sprintf("SATAssignment:\n%s", assignmentRowsString(result.GetSatisfyingAssignment()))
 	} else if result.IsUndefined() {
 		return "SATAssignment: N/A"
 	} else if result.IsUNSAT() {
@@ -48,6 +66,27 @@ func GetSolverResultSatisfyingAssignmentString(result SolverResult) string {
 	return "SatAssignment: N/A"
 }
 
+/**
+ * Format the backbone in the same way as the satisfying assignment.
+ */
+func GetBackboneString(result BackboneSolverResult) string {
+	if result.IsSAT() {
+		return fmt.Sprintf("Backbone:\n%s", assignmentRowsString(result.Backbone))
+	}
+	return "Backbone: N/A"
+}
+
+func assignmentRowsString(assgn map[string]bool) string {
+	rows := make([]string, len(assgn))
+	i := 0
+	for k, v := range assgn {
+		rows[i] = fmt.Sprintf("\t| %s  =>  %t", k, v)
+		i++
+	}
+	sort.Strings(rows)
+	return strings.Join(rows, "\n")
+}
+
 type EmptySolverResult struct {}
 
 func (EmptySolverResult) ToBool() bool {
@@ -116,6 +155,95 @@ func (SolverQuickUnsatResult) IsUndefined() bool {
 	return false
 }
 
+/**
+ * Result of the optimization: the best solution found and the value of its objective
+ */
+type OptimumSolverResult struct {
+	SolverResult
+	Cost int64
+}
+
+func (result OptimumSolverResult) String() string {
+	return fmt.Sprintf("%s (cost = %d)", result.SolverResult.String(), result.Cost)
+}
+
+func (result OptimumSolverResult) Brief() string {
+	return fmt.Sprintf("%s (cost = %d)", result.SolverResult.Brief(), result.Cost)
+}
+
+/**
+ * Result with the satisfying assignment reduced to the variables that are needed to satisfy the formula
+ */
+type PartialModelSolverResult struct {
+	SolverResult
+	Model map[string]bool
+}
+
+func (result PartialModelSolverResult) GetSatisfyingAssignment() map[string]bool {
+	return result.Model
+}
+
+func (result PartialModelSolverResult) String() string {
+	return fmt.Sprintf("%s (partial model with %d variables)", result.SolverResult.String(), len(result.Model))
+}
+
+func (result PartialModelSolverResult) Brief() string {
+	return fmt.Sprintf("%s (partial model with %d variables)", result.SolverResult.Brief(), len(result.Model))
+}
+
+/**
+ * Result of the backbone computation: the first solution found and the variables that have the same value
+ * in all the solutions
+ */
+type BackboneSolverResult struct {
+	SolverResult
+	Backbone map[string]bool
+}
+
+func (result BackboneSolverResult) String() string {
+	return fmt.Sprintf("%s (backbone size = %d)", result.SolverResult.String(), len(result.Backbone))
+}
+
+func (result BackboneSolverResult) Brief() string {
+	return fmt.Sprintf("%s (backbone size = %d)", result.SolverResult.Brief(), len(result.Backbone))
+}
+
+/**
+ * Result of solving under the assumptions. For UNSAT results the core is the subset of the assumptions
+ * that is enough to make the formula UNSAT (empty if the formula is UNSAT without any assumptions).
+ * The assumptions are written as the literals: "x" or "-x".
+ */
+type AssumptionsSolverResult struct {
+	SolverResult
+	Core []string
+}
+
+func (result AssumptionsSolverResult) String() string {
+	if result.IsUNSAT() {
+		return fmt.Sprintf("%s (core size = %d)", result.SolverResult.String(), len(result.Core))
+	}
+	return result.SolverResult.String()
+}
+
+func (result AssumptionsSolverResult) Brief() string {
+	if result.IsUNSAT() {
+		return fmt.Sprintf("%s (core size = %d)", result.SolverResult.Brief(), len(result.Core))
+	}
+	return result.SolverResult.Brief()
+}
+
+/**
+ * Format the core of the UNSAT result under the assumptions.
+ */
+func GetCoreString(result AssumptionsSolverResult) string {
+	if result.IsUNSAT() && len(result.Core) == 0 {
+		return "Core: (empty)"
+	} else if result.IsUNSAT() {
+		return fmt.Sprintf("Core: %s", strings.Join(result.Core, " "))
+	}
+	return "Core: N/A"
+}
+
 func Solve(formula *sat_solver.SATFormula, solverName string, context *sat_solver.SATContext) (error, SolverResult) {
 	err, solvingContext := context.StartProcessing("Solve formula (CDCL solver)", "")
 	if err != nil {
@@ -2,6 +2,7 @@ package solver
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/styczynski/go-sat-solver/sat_solver"
 )
@@ -12,6 +13,15 @@ type SolverFactory interface {
 	GetName() string
 }
 
+/**
+ * Factory of a solver specialised for some class of formulas (for example 2-CNF).
+ * When no solver name is given, such solver is used instead of the default one if it prefers the formula.
+ */
+type SpecializedSolverFactory interface {
+	SolverFactory
+	IsPreferredFor(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool
+}
+
 var DEFAULT_SOLVER_NAME = "cdcl"
 var SOLVER_FACTORIES = map[string]SolverFactory{}
 
@@ -19,8 +29,30 @@ func RegisterSolverFactory(factory SolverFactory) {
 	SOLVER_FACTORIES[factory.GetName()] = factory
 }
 
+/*
+ * Find the specialised solver factory that prefers the formula.
+ * The factories are checked in the order of their names, so the choice does not depend on the map iteration order.
+ */
+func findPreferredSolverFactory(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (SolverFactory, bool) {
+	names := []string{}
+	for name := range SOLVER_FACTORIES {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if factory, ok := SOLVER_FACTORIES[name].(SpecializedSolverFactory); ok && factory.IsPreferredFor(formula, context) {
+			return factory, true
+		}
+	}
+	return nil, false
+}
+
 func CreateSolver(name string, formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, Solver) {
 	if len(name) == 0 {
+		if factory, ok := findPreferredSolverFactory(formula, context); ok {
+			context.Trace("solver", "Using the specialised solver '%s' for the formula.", factory.GetName())
+			return nil, factory.CreateSolver(formula, context)
+		}
 		if defaultFactory, ok := SOLVER_FACTORIES[DEFAULT_SOLVER_NAME]; ok {
 			name = defaultFactory.GetName()
 		} else {
@@ -45,4 +77,26 @@ func CreateSolver(name string, formula *sat_solver.SATFormula, context *sat_solv
 	} else {
 		return fmt.Errorf("Solver with name '%s' not found.", name), nil
 	}
+}
+
+/**
+ * Create the solver that supports assumptions and load the formula into it.
+ */
+func CreateIncrementalSolver(name string, formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, IncrementalSolver) {
+	if len(name) == 0 {
+		name = DEFAULT_SOLVER_NAME
+	}
+	solverFactory, ok := SOLVER_FACTORIES[name]
+	if !ok {
+		return fmt.Errorf("Solver with name '%s' not found.", name), nil
+	}
+	incrementalSolver, ok := solverFactory.CreateSolver(formula, context).(IncrementalSolver)
+	if !ok {
+		return fmt.Errorf("Solver with name '%s' does not support incremental solving.", name), nil
+	}
+	err := incrementalSolver.Load(formula, context)
+	if err != nil {
+		return err, nil
+	}
+	return nil, incrementalSolver
 }
\ No newline at end of file
@@ -0,0 +1,262 @@
+package twosat_solver
+
+/**
+ * This file provides the linear time solver for 2-CNF formulas (each clause has at most two literals).
+ *
+ * Each clause (a v b) gives two implications: -a => b and -b => a (unit clause (a) gives -a => a).
+ * The formula is UNSAT if and only if some variable is in the same strongly connected component
+ * of the implication graph as its negation. Otherwise the variable x is set to true if the component of x
+ * comes after the component of -x in the topological order.
+ * The components are found with the iterative version of Tarjan's algorithm, which numbers them
+ * in the reverse topological order.
+ *
+ * For more details please see the paper by Aspvall, Plass and Tarjan:
+ *   "A linear-time algorithm for testing the truth of certain quantified boolean formulas" (1979)
+ */
+
+import (
+	"fmt"
+
+	"github.com/styczynski/go-sat-solver/sat_solver"
+	"github.com/styczynski/go-sat-solver/sat_solver/solver"
+)
+
+/*
+ * 2-SAT solver factory
+ */
+type TwoSATSolverFactory struct {}
+
+func (tssf TwoSATSolverFactory) CanSolveFormula(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
+	f, ok := formula.Formula().(*sat_solver.CNFFormula)
+	return ok && Is2CNF(f)
+}
+
+func (tssf TwoSATSolverFactory) IsPreferredFor(formula *sat_solver.SATFormula, context *sat_solver.SATContext) bool {
+	return tssf.CanSolveFormula(formula, context)
+}
+
+func (tssf TwoSATSolverFactory) CreateSolver(formula *sat_solver.SATFormula, context *sat_solver.SATContext) solver.Solver {
+	return NewTwoSATSolver()
+}
+
+func (tssf TwoSATSolverFactory) GetName() string {
+	return "2sat"
+}
+
+// Register solver factory
+func init() {
+	solver.RegisterSolverFactory(TwoSATSolverFactory{})
+}
+
+/**
+ * 2-SAT solver instance
+ */
+type TwoSATSolver struct {}
+
+func NewTwoSATSolver() *TwoSATSolver {
+	return &TwoSATSolver{}
+}
+
+/**
+ * Check if all the clauses of the formula have at most two literals and there are no XOR or cardinality constraints.
+ * Constant literals are not counted.
+ */
+func Is2CNF(f *sat_solver.CNFFormula) bool {
+	if len(f.Xors) > 0 || len(f.Cardinalities) > 0 {
+		return false
+	}
+	for _, clause := range f.Variables {
+		size := 0
+		for _, literal := range clause {
+			if literal != 1 && literal != -1 {
+				size++
+			}
+		}
+		if size > 2 {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * Solve sat formula
+ */
+func (s *TwoSATSolver) Solve(formula *sat_solver.SATFormula, context *sat_solver.SATContext) (error, solver.SolverResult) {
+	f, ok := formula.Formula().(*sat_solver.CNFFormula)
+	if !ok || !Is2CNF(f) {
+		return fmt.Errorf("2-SAT Solver supports only 2-CNF formulas."), solver.SatResultUnsat()
+	}
+	maxVar := sat_solver.CNFLiteral(1)
+	for _, v := range formula.Variables().GetAllVariables() {
+		if v > maxVar {
+			maxVar = v
+		}
+	}
+	for _, clause := range f.Variables {
+		for _, literal := range clause {
+			if literal.Var() > maxVar {
+				maxVar = literal.Var()
+			}
+		}
+	}
+
+	isSat, values := Solve2CNF(f.Variables, maxVar)
+	if context.IsSolverTracingEnabled() {
+		context.Trace("2sat", "Solved implication graph with %d clauses, the formula is satisfiable: %t.", len(f.Variables), isSat)
+	}
+	if !isSat {
+		return nil, solver.SatResultUnsat()
+	}
+	model := map[sat_solver.CNFLiteral]bool{}
+	for _, v := range formula.Variables().GetAllVariables() {
+		model[v] = values[v]
+	}
+	return nil, solver.SatResultSat(formula.FounderAssignment(model))
+}
+
+/*
+ * Index of the literal in the implication graph
+ */
+func literalIndex(literal sat_solver.CNFLiteral) int {
+	if literal < 0 {
+		return int(-2*literal + 1)
+	}
+	return int(2*literal)
+}
+
+/**
+ * Solve the 2-CNF clauses over the variables 2..maxVar.
+ * Returns false if the clauses are UNSAT. Otherwise the model indexed by the variable IDs is returned.
+ */
+func Solve2CNF(clauses []sat_solver.CNFClause, maxVar sat_solver.CNFLiteral) (bool, []bool) {
+	nodes := 2*int(maxVar + 1)
+	implications := [][2]sat_solver.CNFLiteral{}
+	for _, clause := range clauses {
+		literals := make([]sat_solver.CNFLiteral, 0, 2)
+		isSatisfied := false
+		for _, literal := range clause {
+			if literal == 1 {
+				isSatisfied = true
+				break
+			} else if literal != -1 {
+				literals = append(literals, literal)
+			}
+		}
+		if isSatisfied {
+			continue
+		}
+		switch len(literals) {
+		case 0:
+			return false, nil
+		case 1:
+			implications = append(implications, [2]sat_solver.CNFLiteral{ -literals[0], literals[0] })
+		default:
+			implications = append(implications, [2]sat_solver.CNFLiteral{ -literals[0], literals[1] })
+			implications = append(implications, [2]sat_solver.CNFLiteral{ -literals[1], literals[0] })
+		}
+	}
+
+	// Store the graph as adjacency arrays: edges of the node v are edges[start[v]:start[v+1]]
+	start := make([]int, nodes + 1)
+	for _, implication := range implications {
+		start[literalIndex(implication[0]) + 1]++
+	}
+	for v := 0; v < nodes; v++ {
+		start[v+1] += start[v]
+	}
+	edges := make([]int, len(implications))
+	position := make([]int, nodes)
+	copy(position, start[:nodes])
+	for _, implication := range implications {
+		from := literalIndex(implication[0])
+		edges[position[from]] = literalIndex(implication[1])
+		position[from]++
+	}
+
+	component := stronglyConnectedComponents(nodes, start, edges)
+	values := make([]bool, maxVar + 1)
+	for v := 2; v <= int(maxVar); v++ {
+		positive, negative := component[2*v], component[2*v + 1]
+		if positive == negative {
+			return false, nil
+		}
+		// Components are numbered in the reverse topological order
+		values[v] = positive < negative
+	}
+	return true, values
+}
+
+/*
+ * Frame of the simulated recursion of Tarjan's algorithm
+ */
+type tarjanFrame struct {
+	node int
+	edge int
+}
+
+/*
+ * Find the strongly connected components with Tarjan's algorithm.
+ * Returns the component number of each node. The components are numbered in the reverse topological order.
+ */
+func stronglyConnectedComponents(nodes int, start []int, edges []int) []int {
+	index := make([]int, nodes)
+	low := make([]int, nodes)
+	component := make([]int, nodes)
+	onStack := make([]bool, nodes)
+	for v := range index {
+		index[v] = -1
+	}
+	stack := []int{}
+	callStack := []tarjanFrame{}
+	counter, componentsCount := 0, 0
+
+	visit := func(v int) {
+		index[v], low[v] = counter, counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+		callStack = append(callStack, tarjanFrame{ node: v, edge: start[v] })
+	}
+
+	for root := 0; root < nodes; root++ {
+		if index[root] != -1 {
+			continue
+		}
+		visit(root)
+		for len(callStack) > 0 {
+			frame := &callStack[len(callStack)-1]
+			v := frame.node
+			if frame.edge < start[v+1] {
+				w := edges[frame.edge]
+				frame.edge++
+				if index[w] == -1 {
+					visit(w)
+				} else if onStack[w] && index[w] < low[v] {
+					low[v] = index[w]
+				}
+				continue
+			}
+			callStack = callStack[:len(callStack)-1]
+			if low[v] == index[v] {
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w] = false
+					component[w] = componentsCount
+					if w == v {
+						break
+					}
+				}
+				componentsCount++
+			}
+			if len(callStack) > 0 {
+				parent := callStack[len(callStack)-1].node
+				if low[v] < low[parent] {
+					low[parent] = low[v]
+				}
+			}
+		}
+	}
+	return component
+}
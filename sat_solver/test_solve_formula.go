@@ -22,8 +22,29 @@ func TestSolveFormula(formula *SATFormula) (bool, map[string]bool) {
 	variableNames := map[int]string{}
 	freeID := 1
 	if f, ok := formula.Formula().(*CNFFormula); ok {
-		vars := make([][]int, len(f.Variables))
-		for i, clause := range f.Variables {
+		clauses := f.Variables
+		if len(f.Xors) > 0 || len(f.Cardinalities) > 0 {
+			// Encode XORs and cardinality constraints using variables that do not occur in the formula
+			freshID := CNFLiteral(0)
+			for _, v := range formula.Variables().GetAllVariables() {
+				if v > freshID {
+					freshID = v
+				}
+			}
+			fresh := func() CNFLiteral {
+				freshID++
+				return freshID
+			}
+			clauses = append([]CNFClause{}, f.Variables...)
+			for _, xor := range f.Xors {
+				clauses = append(clauses, xor.ToCNF(fresh)...)
+			}
+			for _, c := range f.Cardinalities {
+				clauses = append(clauses, c.ToCNF(fresh)...)
+			}
+		}
+		vars := make([][]int, len(clauses))
+		for i, clause := range clauses {
 			vars[i] = make([]int, len(clause))
 			for j, v := range clause {
 				if v == 1 || v == -1 || v == 0 {
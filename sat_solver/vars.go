@@ -74,3 +74,11 @@ func (vars *SATVariableMapping) Get(name string) CNFLiteral {
 	vars.reverse[newID] = name
 	return newID
 }
+
+/**
+ * Get the ID of the variable with the given name without creating it.
+ */
+func (vars *SATVariableMapping) Lookup(name string) (CNFLiteral, bool) {
+	id, ok := vars.names[name]
+	return id, ok
+}
@@ -0,0 +1,82 @@
+package sat_solver
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * Soft clause of the MaxSAT instance. Weight is the cost paid when the clause is falsified.
+ */
+type SoftClause struct {
+	Clause CNFClause
+	Weight int64
+}
+
+/**
+ * Check if the clause is satisfied. The value function returns the value of the literal in the model.
+ */
+func (c SoftClause) IsSatisfiedBy(value func(literal CNFLiteral) bool) bool {
+	for _, literal := range c.Clause {
+		if value(literal) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * Weighted partial MaxSAT instance.
+ * All the hard constraints must be satisfied and the sum of the weights of the falsified soft clauses
+ * should be as small as possible.
+ */
+type WeightedCNFFormula struct {
+	Hard *CNFFormula
+	Soft []SoftClause
+}
+
+/**
+ * Get the sum of the weights of the soft clauses falsified by the model.
+ */
+func (f *WeightedCNFFormula) Cost(value func(literal CNFLiteral) bool) int64 {
+	cost := int64(0)
+	for _, c := range f.Soft {
+		if !c.IsSatisfiedBy(value) {
+			cost += c.Weight
+		}
+	}
+	return cost
+}
+
+func (f *WeightedCNFFormula) NormalizeVars(vars *SATVariableMapping) (error, *SATVariableMapping, int) {
+	return fmt.Errorf("Weighted CNF formulas can be solved only by the maxsat solver."), nil, 0
+}
+
+/**
+ * Evaluate the hard constraints.
+ */
+func (f *WeightedCNFFormula) Evaluate(vars []bool) bool {
+	return f.Hard.Evaluate(vars)
+}
+
+func (f *WeightedCNFFormula) Measure() *SATFormulaStatistics {
+	stats := f.Hard.Measure()
+	stats.softClauseCount = int64(len(f.Soft))
+	return stats
+}
+
+func (f *WeightedCNFFormula) String(vars *SATVariableMapping) string {
+	result := make([]string, 0, len(f.Soft) + 1)
+	result = append(result, f.Hard.String(vars))
+	for _, c := range f.Soft {
+		result = append(result, fmt.Sprintf("[%d]%s", c.Weight, c.Clause.String(vars)))
+	}
+	return strings.Join(result, "^")
+}
+
+/**
+ * Get AST of the hard constraints (soft clauses have no representation in the AST).
+ */
+func (f *WeightedCNFFormula) AST(vars *SATVariableMapping) *Formula {
+	return f.Hard.AST(vars)
+}
@@ -0,0 +1,148 @@
+package sat_solver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/**
+ * Parity constraint over variables: the XOR of all the variables must be equal to Parity.
+ * Negated literals are not stored, because negating a literal only flips the parity.
+ */
+type XORClause struct {
+	// Positive variables sorted in ascending order without duplicates
+	Vars   []CNFLiteral
+	Parity bool
+}
+
+/**
+ * Create new XOR constraint l1 xor l2 xor ... xor ln = parity.
+ * Negated literals flip the parity, variables that occur twice cancel out and T/F constants are folded into the parity.
+ */
+func NewXORClause(literals []CNFLiteral, parity bool) XORClause {
+	count := map[CNFLiteral]int{}
+	for _, literal := range literals {
+		if literal == 1 {
+			parity = !parity
+			continue
+		} else if literal == -1 {
+			continue
+		}
+		if literal < 0 {
+			parity = !parity
+		}
+		count[literal.Var()]++
+	}
+	vars := make([]CNFLiteral, 0, len(count))
+	for v, c := range count {
+		if c % 2 == 1 {
+			vars = append(vars, v)
+		}
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i] < vars[j]
+	})
+	return XORClause{
+		Vars:   vars,
+		Parity: parity,
+	}
+}
+
+func (xor XORClause) Evaluate(vars []bool) bool {
+	value := false
+	for _, v := range xor.Vars {
+		if vars[v-1] {
+			value = !value
+		}
+	}
+	return value == xor.Parity
+}
+
+/**
+ * Check if the XOR is satisfied by the model. Variables missing from the model are treated as false.
+ */
+func (xor XORClause) IsSatisfiedBy(model map[CNFLiteral]bool) bool {
+	value := false
+	for _, v := range xor.Vars {
+		if model[v] {
+			value = !value
+		}
+	}
+	return value == xor.Parity
+}
+
+func (xor XORClause) AST(vars *SATVariableMapping) *Formula {
+	var result *Formula = nil
+	for _, v := range xor.Vars {
+		if result == nil {
+			result = MakeVar(vars.Reverse(v))
+		} else {
+			result = MakeXor(result, MakeVar(vars.Reverse(v)))
+		}
+	}
+	if result == nil {
+		return MakeBoolConstant(!xor.Parity)
+	}
+	if !xor.Parity {
+		return MakeNot(result)
+	}
+	return result
+}
+
+func (xor XORClause) String(vars *SATVariableMapping) string {
+	partialResult := make([]string, len(xor.Vars))
+	for i, id := range xor.Vars {
+		partialResult[i] = id.String(vars)
+	}
+	parity := "F"
+	if xor.Parity {
+		parity = "T"
+	}
+	return fmt.Sprintf("(%s = %s)", strings.Join(partialResult, " x "), parity)
+}
+
+/**
+ * Encode the XOR as clauses.
+ * Long XORs are split into chains of XORs of size at most 3 using fresh variables returned by the fresh function,
+ * so the encoding is linear in the XOR size.
+ */
+func (xor XORClause) ToCNF(fresh func() CNFLiteral) []CNFClause {
+	if len(xor.Vars) == 0 {
+		if xor.Parity {
+			return []CNFClause{ {} }
+		}
+		return []CNFClause{}
+	}
+	clauses := []CNFClause{}
+	vars := xor.Vars
+	for len(vars) > 3 {
+		t := fresh()
+		// t = vars[0] xor vars[1]
+		clauses = append(clauses, encodeSmallXOR([]CNFLiteral{ vars[0], vars[1], t }, false)...)
+		vars = append([]CNFLiteral{ t }, vars[2:]...)
+	}
+	return append(clauses, encodeSmallXOR(vars, xor.Parity)...)
+}
+
+// Encode XOR of at most 3 variables by forbidding all the assignments with wrong parity
+func encodeSmallXOR(vars []CNFLiteral, parity bool) []CNFClause {
+	clauses := []CNFClause{}
+	for mask := 0; mask < (1 << uint(len(vars))); mask++ {
+		value := false
+		clause := make(CNFClause, len(vars))
+		for i, v := range vars {
+			if mask & (1 << uint(i)) != 0 {
+				// Variable is true in the forbidden assignment
+				value = !value
+				clause[i] = -v
+			} else {
+				clause[i] = v
+			}
+		}
+		if value != parity {
+			clauses = append(clauses, clause)
+		}
+	}
+	return clauses
+}